@@ -0,0 +1,336 @@
+// Command ndrdump prints an annotated dump of an NDR byte stream, or of a PAC, decoded into one of the built in types.
+//
+// Each range of bytes is listed with its offset, the field it belongs to and what it is: a value, alignment padding,
+// a conformant max count moved to the beginning of a structure, the offset and actual count of a varying array, a
+// pointer's referent ID or the point at which a deferred referent is read. The decoded value follows as JSON.
+//
+// Usage:
+//
+//	ndrdump -type KerbValidationInfo [-format auto|hex|base64|binary] [file]
+//
+// The input is read from the file, or standard input if no file is given.
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/jcmturner/rpc/v2/mstypes"
+	"github.com/jcmturner/rpc/v2/ndr"
+	"github.com/jcmturner/rpc/v2/pac"
+)
+
+// typePAC is the name of the type for a PAC, which is not itself NDR encoded but holds NDR encoded buffers.
+const typePAC = "PAC"
+
+// maxDumpBytes is the maximum number of bytes shown on a line of the dump.
+const maxDumpBytes = 16
+
+// ndrTypes are the built in NDR types that can be dumped.
+var ndrTypes = map[string]func() interface{}{
+	"KerbValidationInfo": func() interface{} { return new(pac.KerbValidationInfo) },
+	"ClaimsSetMetadata":  func() interface{} { return new(mstypes.ClaimsSetMetadata) },
+	"ClaimsSet":          func() interface{} { return new(mstypes.ClaimsSet) },
+	"DeviceInfo":         func() interface{} { return new(pac.DeviceInfo) },
+	"S4UDelegationInfo":  func() interface{} { return new(pac.S4UDelegationInfo) },
+	"CredentialData":     func() interface{} { return new(pac.CredentialData) },
+}
+
+// pacNDRTypes are the names of the NDR types of the PAC buffer types.
+var pacNDRTypes = map[uint32]string{
+	pac.InfoTypeLogonInfo:         "KerbValidationInfo",
+	pac.InfoTypeS4UDelegationInfo: "S4UDelegationInfo",
+	pac.InfoTypeClientClaimsInfo:  "ClaimsSetMetadata",
+	pac.InfoTypeDeviceInfo:        "DeviceInfo",
+	pac.InfoTypeDeviceClaimsInfo:  "ClaimsSetMetadata",
+}
+
+// unmarshaler is implemented by the PAC buffer types that are not NDR encoded.
+type unmarshaler interface {
+	Unmarshal([]byte) error
+}
+
+// pacBufferTypes are the names and types of the PAC buffer types.
+var pacBufferTypes = map[uint32]struct {
+	name string
+	new  func() unmarshaler
+}{
+	pac.InfoTypeLogonInfo:           {"logon information", nil},
+	pac.InfoTypeCredentials:         {"credential information", func() unmarshaler { return new(pac.CredentialInfo) }},
+	pac.InfoTypeServerChecksum:      {"server checksum", func() unmarshaler { return new(pac.SignatureData) }},
+	pac.InfoTypeKDCChecksum:         {"KDC checksum", func() unmarshaler { return new(pac.SignatureData) }},
+	pac.InfoTypeClientInfo:          {"client information", func() unmarshaler { return new(pac.ClientInfo) }},
+	pac.InfoTypeS4UDelegationInfo:   {"S4U delegation information", nil},
+	pac.InfoTypeUPNDNSInfo:          {"UPN and DNS information", func() unmarshaler { return new(pac.UPNDNSInfo) }},
+	pac.InfoTypeClientClaimsInfo:    {"client claims", nil},
+	pac.InfoTypeDeviceInfo:          {"device information", nil},
+	pac.InfoTypeDeviceClaimsInfo:    {"device claims", nil},
+	pac.InfoTypeTicketChecksum:      {"ticket checksum", func() unmarshaler { return new(pac.SignatureData) }},
+	pac.InfoTypeAttributesInfo:      {"attributes information", func() unmarshaler { return new(pac.AttributesInfo) }},
+	pac.InfoTypeRequestor:           {"requestor", func() unmarshaler { return new(pac.Requestor) }},
+	pac.InfoTypeExtendedKDCChecksum: {"extended KDC checksum", func() unmarshaler { return new(pac.SignatureData) }},
+}
+
+func main() {
+	typ := flag.String("type", "", "the type to decode the input as: "+strings.Join(typeNames(), ", "))
+	format := flag.String("format", "auto", "the format of the input: auto, hex, base64 or binary")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s -type TYPE [-format FORMAT] [file]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *typ == "" || flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	r := os.Stdin
+	if flag.NArg() == 1 {
+		f, err := os.Open(flag.Arg(0))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		r = f
+	}
+	err := run(os.Stdout, r, *typ, *format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// typeNames returns the names of the types that can be dumped.
+func typeNames() []string {
+	n := []string{typePAC}
+	for k := range ndrTypes {
+		n = append(n, k)
+	}
+	sort.Strings(n[1:])
+	return n
+}
+
+// run reads the input in the format provided and writes the dump of it decoded as the type provided.
+func run(w io.Writer, r io.Reader, typ, format string) error {
+	b, err := readInput(r, format)
+	if err != nil {
+		return err
+	}
+	if typ == typePAC {
+		return dumpPAC(w, b)
+	}
+	if _, ok := ndrTypes[typ]; !ok {
+		return fmt.Errorf("unknown type %s, the types are: %s", typ, strings.Join(typeNames(), ", "))
+	}
+	return dumpNDR(w, b, typ, 0)
+}
+
+// readInput reads all of the input and decodes it from the format provided. The auto format treats input that is only
+// hex digits and white space as hex, input that is valid base64 as base64 and anything else as binary.
+func readInput(r io.Reader, format string) ([]byte, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("could not read input: %v", err)
+	}
+	if format == "auto" {
+		format = detectFormat(b)
+	}
+	switch format {
+	case "binary":
+		return b, nil
+	case "hex":
+		b, err = hex.DecodeString(stripSpace(string(b)))
+		if err != nil {
+			return nil, fmt.Errorf("could not decode hex input: %v", err)
+		}
+		return b, nil
+	case "base64":
+		b, err = base64.StdEncoding.DecodeString(stripSpace(string(b)))
+		if err != nil {
+			return nil, fmt.Errorf("could not decode base64 input: %v", err)
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("unknown input format %s", format)
+}
+
+func detectFormat(b []byte) string {
+	s := stripSpace(string(b))
+	if s == "" {
+		return "binary"
+	}
+	if _, err := hex.DecodeString(s); err == nil {
+		return "hex"
+	}
+	if _, err := base64.StdEncoding.DecodeString(s); err == nil {
+		return "base64"
+	}
+	return "binary"
+}
+
+func stripSpace(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// dumpNDR writes the annotated dump of the NDR byte stream decoded as the type provided, followed by the decoded value.
+// The offsets shown are from the start of the byte stream plus the base provided.
+func dumpNDR(w io.Writer, b []byte, typ string, base int) error {
+	v := ndrTypes[typ]()
+	dec := ndr.NewDecoderBytes(b)
+	dec.Annotate(true)
+	decErr := dec.Decode(v)
+	fmt.Fprintf(w, "%s (%d bytes)\n", typ, len(b))
+	writeAnnotations(w, b, dec.Annotations(), base)
+	if decErr != nil {
+		// The annotations show how far decoding got
+		return fmt.Errorf("could not decode %s: %v", typ, decErr)
+	}
+	err := writeJSON(w, v)
+	if err != nil {
+		return err
+	}
+	if m, ok := v.(*mstypes.ClaimsSetMetadata); ok && len(m.ClaimsSetBytes) > 0 {
+		c, err := m.Decompress()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w)
+		return dumpNDR(w, c, "ClaimsSet", 0)
+	}
+	return nil
+}
+
+// writeAnnotations writes a line for each annotation, with lines for any bytes between and after them that are not
+// annotated.
+func writeAnnotations(w io.Writer, b []byte, as []ndr.Annotation, base int) {
+	fmt.Fprintf(w, "%-8s %5s  %-34s %s\n", "offset", "len", "bytes", "field: note [value]")
+	var end int
+	for _, a := range as {
+		if a.Offset > end {
+			writeLine(w, b, end, a.Offset-end, base, "", "not annotated", nil)
+		}
+		writeLine(w, b, a.Offset, a.Length, base, a.Path, a.Note, a.Value)
+		if e := a.Offset + a.Length; e > end {
+			end = e
+		}
+	}
+	if end < len(b) {
+		writeLine(w, b, end, len(b)-end, base, "", "not annotated", nil)
+	}
+}
+
+func writeLine(w io.Writer, b []byte, offset, length, base int, path, note string, value interface{}) {
+	var bs string
+	if offset+length <= len(b) {
+		n := length
+		if n > maxDumpBytes {
+			n = maxDumpBytes
+		}
+		bs = hex.EncodeToString(b[offset : offset+n])
+		if n < length {
+			bs += ".."
+		}
+	}
+	desc := note
+	if path != "" {
+		desc = path + ": " + note
+	}
+	if v := formatValue(note, value); v != "" {
+		desc += " [" + v + "]"
+	}
+	fmt.Fprintf(w, "0x%06x %5d  %-34s %s\n", base+offset, length, bs, desc)
+}
+
+// formatValue returns the representation of an annotation's value. Pointer referent IDs are shown in hex.
+func formatValue(note string, value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	switch note {
+	case ndr.NotePointer, ndr.NoteReferent:
+		if id, ok := value.(uint32); ok {
+			if id == 0 && note == ndr.NotePointer {
+				return "null"
+			}
+			return fmt.Sprintf("referent 0x%08x", id)
+		}
+	}
+	switch v := value.(type) {
+	case string:
+		return fmt.Sprintf("%q", v)
+	case []byte:
+		if len(v) > maxDumpBytes {
+			return hex.EncodeToString(v[:maxDumpBytes]) + ".."
+		}
+		return hex.EncodeToString(v)
+	case ndr.CommonHeader:
+		return fmt.Sprintf("version %d, %s", v.Version, v.Endianness)
+	case ndr.PrivateHeader:
+		return fmt.Sprintf("object buffer length %d", v.ObjectBufferLength)
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	j, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not marshal decoded value to JSON: %v", err)
+	}
+	fmt.Fprintf(w, "%s\n", j)
+	return nil
+}
+
+// dumpPAC writes the buffer table of the PAC followed by the dump of each buffer.
+func dumpPAC(w io.Writer, b []byte) error {
+	var p pac.PACType
+	err := p.Unmarshal(b)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "PAC (%d bytes) version %d with %d buffers\n", len(b), p.Version, p.CBuffers)
+	fmt.Fprintf(w, "%-8s %5s  %s\n", "offset", "len", "type")
+	for _, buf := range p.Buffers {
+		fmt.Fprintf(w, "0x%06x %5d  %d %s\n", buf.Offset, buf.CBBufferSize, buf.ULType, pacBufferTypes[buf.ULType].name)
+	}
+	var errs []string
+	for i, buf := range p.Buffers {
+		data := b[buf.Offset : buf.Offset+uint64(buf.CBBufferSize)]
+		fmt.Fprintf(w, "\nBuffer %d: %d %s\n", i, buf.ULType, pacBufferTypes[buf.ULType].name)
+		if typ, ok := pacNDRTypes[buf.ULType]; ok {
+			err = dumpNDR(w, data, typ, int(buf.Offset))
+		} else if t, ok := pacBufferTypes[buf.ULType]; ok && t.new != nil {
+			v := t.new()
+			err = v.Unmarshal(data)
+			if err == nil {
+				err = writeJSON(w, v)
+			}
+		} else {
+			fmt.Fprintln(w, hex.Dump(data))
+			err = nil
+		}
+		if err != nil {
+			// Carry on with the other buffers
+			fmt.Fprintf(w, "error: %v\n", err)
+			errs = append(errs, fmt.Sprintf("buffer %d: %v", i, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New("could not decode PAC buffers: " + strings.Join(errs, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jcmturner/rpc/v2/mstypes"
+	"github.com/jcmturner/rpc/v2/ndr"
+	"github.com/jcmturner/rpc/v2/pac"
+	"github.com/stretchr/testify/assert"
+)
+
+const testKerbValidationInfo = "01100800cccccccc180200000000000000000200058e4fdd80c6d201ffffffffffffff7fffffffffffffff7fcc27969c39c6d201cce7ffc602c7d201ffffffffffffff7f12001200040002001600160008000200000000000c000200000000001000020000000000140002000000000018000200d80000005104000001020000050000001c000200200000000000000000000000000000000000000008000a002000020008000a00240002002800020000000000000000001002000000000000000000000000000000000000000000000000000000000000020000002c00020000000000000000000000000009000000000000000900000074006500730074007500730065007200310000000b000000000000000b000000540065007300740031002000550073006500720031000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000050000000102000007000000540400000700000055040000070000005b040000070000005c0400000700000005000000000000000400000041004400440043000500000000000000040000005400450053005400040000000104000000000005150000004c86cebca07160e63fdce8870200000030000200070000203400020007000020050000000105000000000005150000004c86cebca07160e63fdce8875a040000050000000105000000000005150000004c86cebca07160e63fdce8875704000000000000"
+
+func TestReadInput(t *testing.T) {
+	b, _ := hex.DecodeString(testKerbValidationInfo)
+	var tests = []struct {
+		in     string
+		format string
+		err    bool
+	}{
+		{testKerbValidationInfo, "auto", false},
+		{testKerbValidationInfo[:40] + "\n" + testKerbValidationInfo[40:] + "\n", "auto", false},
+		{testKerbValidationInfo, "hex", false},
+		{base64.StdEncoding.EncodeToString(b), "auto", false},
+		{base64.StdEncoding.EncodeToString(b), "base64", false},
+		{string(b), "auto", false},
+		{string(b), "binary", false},
+		{"zz", "hex", true},
+		{testKerbValidationInfo, "octal", true},
+	}
+	for i, test := range tests {
+		out, err := readInput(strings.NewReader(test.in), test.format)
+		if test.err {
+			assert.Error(t, err, "expected error for test %d", i+1)
+			continue
+		}
+		if err != nil {
+			t.Errorf("error reading input for test %d: %v", i+1, err)
+			continue
+		}
+		assert.Equal(t, b, out, "input not as expected for test %d", i+1)
+	}
+}
+
+func TestRun_KerbValidationInfo(t *testing.T) {
+	var w bytes.Buffer
+	err := run(&w, strings.NewReader(testKerbValidationInfo), "KerbValidationInfo", "auto")
+	if err != nil {
+		t.Fatalf("error dumping: %v", err)
+	}
+	out := w.String()
+	for _, s := range []string{
+		"KerbValidationInfo (552 bytes)",
+		"0x000000     8  01100800cccccccc                   CommonHeader: header [version 1, LittleEndian]",
+		"0x000014     4  058e4fdd                           KerbValidationInfo/LogOnTime/FileTime/LowDateTime: value [3712978437]",
+		"KerbValidationInfo/EffectiveName/RPCUnicodeString/Value: pointer [referent 0x00020004]",
+		"0x0000ec     0                                     KerbValidationInfo/EffectiveName/RPCUnicodeString/Value: deferred referent [referent 0x00020004]",
+		`KerbValidationInfo/EffectiveName/RPCUnicodeString/Value: value ["testuser1"]`,
+		"KerbValidationInfo/FullName/RPCUnicodeString/Value: alignment padding",
+		"KerbValidationInfo/UserFlags: value [LOGON_EXTRA_SIDS]",
+		`"Value": "testuser1"`,
+	} {
+		assert.Contains(t, out, s, "dump does not contain %q", s)
+	}
+	// Only the trailing padding of the vector is not part of the encoded type
+	assert.Equal(t, 1, strings.Count(out, "not annotated"), "number of ranges not annotated not as expected")
+	assert.Contains(t, out, "0x000224     4  00000000                           not annotated", "trailing bytes not reported")
+}
+
+func TestRun_Errors(t *testing.T) {
+	var w bytes.Buffer
+	err := run(&w, strings.NewReader(testKerbValidationInfo), "Unknown", "auto")
+	assert.Error(t, err, "expected error for unknown type")
+	err = run(&w, strings.NewReader(testKerbValidationInfo[:200]), "KerbValidationInfo", "auto")
+	assert.Error(t, err, "expected error for truncated input")
+
+	// Compressed claims declaring a huge uncompressed size are rejected rather than decompressed
+	var buf bytes.Buffer
+	err = ndr.NewEncoder(&buf).Encode(mstypes.ClaimsSetMetadata{
+		ClaimsSetSize:             2,
+		ClaimsSetBytes:            []byte{0x00, 0xb0},
+		CompressionFormat:         mstypes.CompressionFormatXPressHuff,
+		UncompressedClaimsSetSize: 0xfffffff0,
+	})
+	if err != nil {
+		t.Fatalf("error encoding ClaimsSetMetadata: %v", err)
+	}
+	err = run(&w, bytes.NewReader(buf.Bytes()), "ClaimsSetMetadata", "binary")
+	if assert.Error(t, err, "expected error for a huge uncompressed claims size") {
+		assert.Contains(t, err.Error(), "maximum", "error not as expected for a huge uncompressed claims size")
+	}
+}
+
+func TestRun_PAC(t *testing.T) {
+	sid, err := mstypes.ParseSID("S-1-5-21-3167651404-3865080224-2280184895-1105")
+	if err != nil {
+		t.Fatalf("error parsing SID: %v", err)
+	}
+	b := pac.Builder{
+		UserSID:         sid,
+		UserName:        "testuser1",
+		FullName:        "Test1 User1",
+		LogonDomainName: "TEST",
+		LogonServer:     "ADDC",
+		AuthTime:        time.Date(2017, 6, 9, 10, 0, 0, 0, time.UTC),
+		Groups:          []mstypes.GroupMembership{{RelativeID: 513, Attributes: 7}},
+		UPN:             "testuser1@test.gokrb5",
+		DNSDomain:       "TEST.GOKRB5",
+		ClientClaims: &mstypes.ClaimsSet{
+			ClaimsArrayCount: 1,
+			ClaimsArrays: []mstypes.ClaimsArray{{
+				ClaimsSourceType: mstypes.ClaimsSourceTypeAD,
+				ClaimsCount:      1,
+				ClaimEntries: []mstypes.ClaimEntry{{
+					ID:         "ad://ext/department",
+					Type:       mstypes.ClaimTypeIDString,
+					TypeString: mstypes.ClaimTypeString{ValueCount: 1, Value: []mstypes.LPWSTR{{Value: "Engineering"}}},
+				}},
+			}},
+		},
+		SignatureType: pac.SignatureTypeHMACSHA196AES256,
+		ServerKey:     make([]byte, 32),
+		KDCKey:        make([]byte, 32),
+	}
+	data, err := b.Build()
+	if err != nil {
+		t.Fatalf("error building PAC: %v", err)
+	}
+	var w bytes.Buffer
+	err = run(&w, bytes.NewReader(data), typePAC, "binary")
+	if err != nil {
+		t.Fatalf("error dumping: %v", err)
+	}
+	out := w.String()
+	for _, s := range []string{
+		"Buffer 0: 1 logon information",
+		"KerbValidationInfo (",
+		"client information",
+		"UPN and DNS information",
+		"ClaimsSetMetadata (",
+		"ClaimsSet (",
+		`"ad://ext/department"`,
+		"server checksum",
+	} {
+		assert.Contains(t, out, s, "dump does not contain %q", s)
+	}
+	assert.NotContains(t, out, "error:", "dump has errors")
+}
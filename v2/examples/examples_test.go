@@ -74,7 +74,7 @@ func TestExample_KerbValidationInfo(t *testing.T) {
 	}
 	assert.Equal(t, gids, k.GroupIDs, "GroupIDs not as expected")
 
-	assert.Equal(t, uint32(32), k.UserFlags, "UserFlags not as expected")
+	assert.Equal(t, mstypes.UserFlags(32), k.UserFlags, "UserFlags not as expected")
 
 	assert.Equal(t, mstypes.UserSessionKey{CypherBlock: [2]mstypes.CypherBlock{{Data: [8]byte{}}, {Data: [8]byte{}}}}, k.UserSessionKey, "UserSessionKey not as expected")
 
@@ -83,7 +83,7 @@ func TestExample_KerbValidationInfo(t *testing.T) {
 
 	assert.Equal(t, "S-1-5-21-397955417-626881126-188441444", k.LogonDomainID.String(), "LogonDomainID not as expected")
 
-	assert.Equal(t, uint32(16), k.UserAccountControl, "UserAccountControl not as expected")
+	assert.Equal(t, mstypes.UserAccountControl(16), k.UserAccountControl, "UserAccountControl not as expected")
 	assert.Equal(t, uint32(0), k.SubAuthStatus, "SubAuthStatus not as expected")
 	assert.Equal(t, time.Date(2185, 7, 21, 23, 34, 33, 709551616, time.UTC), k.LastSuccessfulILogon.Time(), "LastSuccessfulILogon not as expected")
 	assert.Equal(t, time.Date(2185, 7, 21, 23, 34, 33, 709551616, time.UTC), k.LastFailedILogon.Time(), "LastSuccessfulILogon not as expected")
@@ -94,20 +94,20 @@ func TestExample_KerbValidationInfo(t *testing.T) {
 
 	var es = []struct {
 		sid  string
-		attr uint32
+		attr mstypes.GroupAttributes
 	}{
-		{"S-1-5-21-773533881-1816936887-355810188-513", uint32(7)},
-		{"S-1-5-21-397955417-626881126-188441444-3101812", uint32(536870919)},
-		{"S-1-5-21-397955417-626881126-188441444-3291368", uint32(536870919)},
-		{"S-1-5-21-397955417-626881126-188441444-3291341", uint32(536870919)},
-		{"S-1-5-21-397955417-626881126-188441444-3322973", uint32(536870919)},
-		{"S-1-5-21-397955417-626881126-188441444-3479105", uint32(536870919)},
-		{"S-1-5-21-397955417-626881126-188441444-3271400", uint32(536870919)},
-		{"S-1-5-21-397955417-626881126-188441444-3283393", uint32(536870919)},
-		{"S-1-5-21-397955417-626881126-188441444-3338537", uint32(536870919)},
-		{"S-1-5-21-397955417-626881126-188441444-3038991", uint32(536870919)},
-		{"S-1-5-21-397955417-626881126-188441444-3037999", uint32(536870919)},
-		{"S-1-5-21-397955417-626881126-188441444-3248111", uint32(536870919)},
+		{"S-1-5-21-773533881-1816936887-355810188-513", mstypes.GroupAttributes(7)},
+		{"S-1-5-21-397955417-626881126-188441444-3101812", mstypes.GroupAttributes(536870919)},
+		{"S-1-5-21-397955417-626881126-188441444-3291368", mstypes.GroupAttributes(536870919)},
+		{"S-1-5-21-397955417-626881126-188441444-3291341", mstypes.GroupAttributes(536870919)},
+		{"S-1-5-21-397955417-626881126-188441444-3322973", mstypes.GroupAttributes(536870919)},
+		{"S-1-5-21-397955417-626881126-188441444-3479105", mstypes.GroupAttributes(536870919)},
+		{"S-1-5-21-397955417-626881126-188441444-3271400", mstypes.GroupAttributes(536870919)},
+		{"S-1-5-21-397955417-626881126-188441444-3283393", mstypes.GroupAttributes(536870919)},
+		{"S-1-5-21-397955417-626881126-188441444-3338537", mstypes.GroupAttributes(536870919)},
+		{"S-1-5-21-397955417-626881126-188441444-3038991", mstypes.GroupAttributes(536870919)},
+		{"S-1-5-21-397955417-626881126-188441444-3037999", mstypes.GroupAttributes(536870919)},
+		{"S-1-5-21-397955417-626881126-188441444-3248111", mstypes.GroupAttributes(536870919)},
 	}
 	for i, s := range es {
 		assert.Equal(t, s.sid, k.ExtraSIDs[i].SID.String(), "ExtraSID SID value not as epxected")
@@ -153,7 +153,7 @@ func TestExample_KerbValidationInfo(t *testing.T) {
 	}
 	assert.Equal(t, gids, k2.GroupIDs, "GroupIDs not as expected")
 
-	assert.Equal(t, uint32(32), k2.UserFlags, "UserFlags not as expected")
+	assert.Equal(t, mstypes.UserFlags(32), k2.UserFlags, "UserFlags not as expected")
 
 	assert.Equal(t, mstypes.UserSessionKey{CypherBlock: [2]mstypes.CypherBlock{{Data: [8]byte{}}, {Data: [8]byte{}}}}, k2.UserSessionKey, "UserSessionKey not as expected")
 
@@ -162,7 +162,7 @@ func TestExample_KerbValidationInfo(t *testing.T) {
 
 	assert.Equal(t, "S-1-5-21-3167651404-3865080224-2280184895", k2.LogonDomainID.String(), "LogonDomainID not as expected")
 
-	assert.Equal(t, uint32(528), k2.UserAccountControl, "UserAccountControl not as expected")
+	assert.Equal(t, mstypes.UserAccountControl(528), k2.UserAccountControl, "UserAccountControl not as expected")
 	assert.Equal(t, uint32(0), k2.SubAuthStatus, "SubAuthStatus not as expected")
 	assert.Equal(t, time.Date(2185, 7, 21, 23, 34, 33, 709551616, time.UTC), k2.LastSuccessfulILogon.Time(), "LastSuccessfulILogon not as expected")
 	assert.Equal(t, time.Date(2185, 7, 21, 23, 34, 33, 709551616, time.UTC), k2.LastFailedILogon.Time(), "LastSuccessfulILogon not as expected")
@@ -173,10 +173,10 @@ func TestExample_KerbValidationInfo(t *testing.T) {
 
 	var es2 = []struct {
 		sid  string
-		attr uint32
+		attr mstypes.GroupAttributes
 	}{
-		{"S-1-5-21-3167651404-3865080224-2280184895-1114", uint32(536870919)},
-		{"S-1-5-21-3167651404-3865080224-2280184895-1111", uint32(536870919)},
+		{"S-1-5-21-3167651404-3865080224-2280184895-1114", mstypes.GroupAttributes(536870919)},
+		{"S-1-5-21-3167651404-3865080224-2280184895-1111", mstypes.GroupAttributes(536870919)},
 	}
 	for i, s := range es2 {
 		assert.Equal(t, s.sid, k2.ExtraSIDs[i].SID.String(), "ExtraSID SID value not as expected")
@@ -219,7 +219,8 @@ func TestExample_KerbValidationInfo(t *testing.T) {
 	}
 	assert.Equal(t, gids, k.GroupIDs, "GroupIDs not as expected")
 
-	assert.Equal(t, uint32(544), k.UserFlags, "UserFlags not as expected")
+	assert.Equal(t, mstypes.UserFlags(544), k.UserFlags, "UserFlags not as expected")
+	assert.Equal(t, "LOGON_EXTRA_SIDS|LOGON_RESOURCE_GROUPS", k.UserFlags.String(), "UserFlags string not as expected")
 
 	assert.Equal(t, mstypes.UserSessionKey{CypherBlock: [2]mstypes.CypherBlock{{Data: [8]byte{}}, {Data: [8]byte{}}}}, k.UserSessionKey, "UserSessionKey not as expected")
 
@@ -228,7 +229,8 @@ func TestExample_KerbValidationInfo(t *testing.T) {
 
 	assert.Equal(t, "S-1-5-21-2284869408-3503417140-1141177250", k.LogonDomainID.String(), "LogonDomainID not as expected")
 
-	assert.Equal(t, uint32(528), k.UserAccountControl, "UserAccountControl not as expected")
+	assert.Equal(t, mstypes.UserAccountControl(528), k.UserAccountControl, "UserAccountControl not as expected")
+	assert.Equal(t, "USER_NORMAL_ACCOUNT|USER_DONT_EXPIRE_PASSWORD", k.UserAccountControl.String(), "UserAccountControl string not as expected")
 	assert.Equal(t, uint32(0), k.SubAuthStatus, "SubAuthStatus not as expected")
 	assert.Equal(t, time.Date(2185, 7, 21, 23, 34, 33, 709551616, time.UTC), k.LastSuccessfulILogon.Time(), "LastSuccessfulILogon not as expected")
 	assert.Equal(t, time.Date(2185, 7, 21, 23, 34, 33, 709551616, time.UTC), k.LastFailedILogon.Time(), "LastSuccessfulILogon not as expected")
@@ -239,9 +241,9 @@ func TestExample_KerbValidationInfo(t *testing.T) {
 
 	es = []struct {
 		sid  string
-		attr uint32
+		attr mstypes.GroupAttributes
 	}{
-		{"S-1-18-1", uint32(7)},
+		{"S-1-18-1", mstypes.GroupAttributes(7)},
 	}
 	for i, s := range es {
 		assert.Equal(t, s.sid, k.ExtraSIDs[i].SID.String(), "ExtraSID SID value not as epxected")
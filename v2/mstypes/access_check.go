@@ -0,0 +1,266 @@
+package mstypes
+
+// The access check algorithm is specified in https://msdn.microsoft.com/en-us/library/cc230290.aspx
+// Privileges and mandatory integrity checks are not evaluated as these cannot be derived from the group memberships
+// in a PAC. Conditional expressions of callback ACEs are not evaluated, the result is treated as unknown which means
+// callback allow ACEs are ignored and callback deny ACEs are applied.
+
+// GenericMapping defines the object specific rights the generic access rights map to.
+// https://msdn.microsoft.com/en-us/library/windows/desktop/aa446633.aspx
+type GenericMapping struct {
+	GenericRead    uint32
+	GenericWrite   uint32
+	GenericExecute uint32
+	GenericAll     uint32
+}
+
+// Generic mappings for commonly checked object types.
+var (
+	FileGenericMapping = GenericMapping{
+		GenericRead:    0x00120089,
+		GenericWrite:   0x00120116,
+		GenericExecute: 0x001200A0,
+		GenericAll:     0x001F01FF,
+	}
+	DSGenericMapping = GenericMapping{
+		GenericRead:    AccessReadControl | AccessDSListChildren | AccessDSReadProperty | AccessDSListObject,
+		GenericWrite:   AccessReadControl | AccessDSSelf | AccessDSWriteProperty,
+		GenericExecute: AccessReadControl | AccessDSListChildren,
+		GenericAll:     0x000F01FF,
+	}
+)
+
+// Map returns the access mask with any generic access rights replaced by the rights they map to.
+// An empty GenericMapping leaves the mask unchanged.
+func (g GenericMapping) Map(m uint32) uint32 {
+	if g == (GenericMapping{}) {
+		return m
+	}
+	if m&AccessGenericRead != 0 {
+		m = m&^AccessGenericRead | g.GenericRead
+	}
+	if m&AccessGenericWrite != 0 {
+		m = m&^AccessGenericWrite | g.GenericWrite
+	}
+	if m&AccessGenericExecute != 0 {
+		m = m&^AccessGenericExecute | g.GenericExecute
+	}
+	if m&AccessGenericAll != 0 {
+		m = m&^AccessGenericAll | g.GenericAll
+	}
+	return m
+}
+
+// Token is the security context of a principal against which an access check is performed.
+// The user SID is always considered enabled. Groups are only considered if they have the SEGroupEnabled attribute,
+// or, for deny ACEs only, the SEGroupUseForDenyOnly attribute.
+type Token struct {
+	UserSID RPCSID
+	Groups  []KerbSidAndAttributes
+}
+
+// ObjectType is an entry in the object type list of an access request, as the OBJECT_TYPE_LIST structure
+// https://msdn.microsoft.com/en-us/library/windows/desktop/aa379294.aspx
+// The list is a tree in pre-order where Level 0 is the object itself, Level 1 property sets and Level 2 properties.
+type ObjectType struct {
+	Level uint16
+	GUID  GUID
+}
+
+// AccessRequest defines the access being requested in an access check.
+type AccessRequest struct {
+	DesiredAccess  uint32
+	GenericMapping GenericMapping // Used to map generic rights in the DesiredAccess and the ACE masks.
+	ObjectTypes    []ObjectType   // Optional object type list against which object ACEs are evaluated.
+	PrincipalSelf  *RPCSID        // Optional SID substituted for the PRINCIPAL_SELF SID in ACEs.
+}
+
+// HasSID returns true if the SID is present in the token for the purposes of matching an ACE.
+// If deny is true the SID is being matched against a deny ACE and deny only groups are also considered.
+func (t *Token) HasSID(s RPCSID, deny bool) bool {
+	if t.UserSID.Equal(s) {
+		return true
+	}
+	for _, g := range t.Groups {
+		if !g.SID.Equal(s) {
+			continue
+		}
+		if g.Attributes.HasFlag(SEGroupEnabled) && !g.Attributes.HasFlag(SEGroupUseForDenyOnly) {
+			return true
+		}
+		if deny && g.Attributes.HasFlag(SEGroupUseForDenyOnly) {
+			return true
+		}
+	}
+	return false
+}
+
+// AccessCheck determines if the token is granted the access requested to an object protected by the security
+// descriptor. The access mask granted is returned along with whether all of the desired access was granted.
+// If AccessMaximumAllowed is in the desired access the maximum access the DACL allows is granted, in which case the
+// check succeeds if some access was granted along with any other rights desired.
+// A security descriptor without a DACL, or with a NULL DACL, grants all access.
+func AccessCheck(sd *SecurityDescriptor, t *Token, r AccessRequest) (uint32, bool) {
+	desired := r.GenericMapping.Map(r.DesiredAccess)
+	maxAllowed := desired&AccessMaximumAllowed != 0
+	desired &^= AccessMaximumAllowed
+	if desired&AccessSystemSecurity != 0 {
+		// Requires the SeSecurityPrivilege which is not available in the token.
+		return 0, false
+	}
+	if sd == nil || !sd.HasControl(SEDACLPresent) || sd.DACL == nil {
+		granted := desired
+		if maxAllowed {
+			granted |= r.GenericMapping.Map(AccessGenericAll)
+		}
+		return granted, true
+	}
+
+	tree := newAccessTree(r.ObjectTypes)
+	isOwner := sd.OwnerSID != nil && t.HasSID(*sd.OwnerSID, false)
+	if isOwner && !hasOwnerRightsACE(sd.DACL) {
+		// The owner is implicitly granted these rights unless the DACL controls them with OWNER_RIGHTS ACEs.
+		tree.allow(-1, AccessReadControl|AccessWriteDACL)
+	}
+	for i := range sd.DACL.ACEs {
+		a := &sd.DACL.ACEs[i]
+		if a.HasFlag(ACEFlagInheritOnly) || !(a.IsAccessAllowed() || a.IsAccessDenied()) {
+			continue
+		}
+		if a.IsAccessAllowed() && a.IsCallbackACE() {
+			continue
+		}
+		sid := a.SID
+		if r.PrincipalSelf != nil && sid.String() == SIDPrincipalSelf {
+			sid = *r.PrincipalSelf
+		}
+		deny := a.IsAccessDenied()
+		if !(isOwner && sid.String() == SIDOwnerRights) && !t.HasSID(sid, deny) {
+			continue
+		}
+		node := -1
+		if a.IsObjectACE() && a.Flags&ACEObjectTypePresent != 0 {
+			node = tree.find(a.ObjectType)
+			if node < 0 {
+				// The ACE does not apply to any object type in the request.
+				continue
+			}
+		}
+		mask := r.GenericMapping.Map(a.Mask)
+		if deny {
+			tree.deny(node, mask)
+		} else {
+			tree.allow(node, mask)
+		}
+	}
+
+	granted := tree.granted()
+	if maxAllowed {
+		return granted, granted != 0 && desired&^granted == 0
+	}
+	return desired & granted, desired&^granted == 0
+}
+
+func hasOwnerRightsACE(acl *ACL) bool {
+	for i := range acl.ACEs {
+		if !acl.ACEs[i].HasFlag(ACEFlagInheritOnly) && acl.ACEs[i].SID.String() == SIDOwnerRights {
+			return true
+		}
+	}
+	return false
+}
+
+// accessTree tracks the access granted and denied to each node of an object type list.
+// When no object type list is provided the tree has a single root node representing the object.
+type accessTree struct {
+	levels      []uint16
+	guids       []GUID
+	grantedBits []uint32
+	deniedBits  []uint32
+}
+
+func newAccessTree(o []ObjectType) *accessTree {
+	n := len(o)
+	if n < 1 {
+		n = 1
+	}
+	t := &accessTree{
+		levels:      make([]uint16, n, n),
+		guids:       make([]GUID, n, n),
+		grantedBits: make([]uint32, n, n),
+		deniedBits:  make([]uint32, n, n),
+	}
+	for i := range o {
+		t.levels[i] = o[i].Level
+		t.guids[i] = o[i].GUID
+	}
+	return t
+}
+
+// find returns the index of the node with the GUID provided or -1 if there is no such node.
+func (t *accessTree) find(g GUID) int {
+	for i := range t.guids {
+		if t.guids[i] == g && !g.IsZero() {
+			return i
+		}
+	}
+	return -1
+}
+
+// subtree returns the index range of the node provided and its descendants. A node of -1 is the whole tree.
+func (t *accessTree) subtree(node int) (int, int) {
+	if node < 0 {
+		return 0, len(t.levels)
+	}
+	end := node + 1
+	for end < len(t.levels) && t.levels[end] > t.levels[node] {
+		end++
+	}
+	return node, end
+}
+
+// allow grants the access mask to the node and its descendants, other than any rights already denied.
+func (t *accessTree) allow(node int, mask uint32) {
+	s, e := t.subtree(node)
+	for i := s; i < e; i++ {
+		t.grantedBits[i] |= mask &^ t.deniedBits[i]
+	}
+}
+
+// deny denies the access mask to the node, its descendants and its ancestors, other than any rights already granted.
+// Denying a right to part of an object denies that right to the object as a whole.
+func (t *accessTree) deny(node int, mask uint32) {
+	s, e := t.subtree(node)
+	for i := s; i < e; i++ {
+		t.deniedBits[i] |= mask &^ t.grantedBits[i]
+	}
+	for i, l := s-1, t.levels[s]; i >= 0 && l > 0; i-- {
+		if t.levels[i] < l {
+			t.deniedBits[i] |= mask &^ t.grantedBits[i]
+			l = t.levels[i]
+		}
+	}
+}
+
+// granted returns the access granted to the root of the tree. A right is granted to a node if granted directly or
+// if it is granted to all of the node's children.
+func (t *accessTree) granted() uint32 {
+	g, _ := t.effective(0)
+	return g
+}
+
+// effective returns the access granted to the node at index i and the index following its subtree.
+func (t *accessTree) effective(i int) (uint32, int) {
+	g := t.grantedBits[i]
+	j := i + 1
+	if j >= len(t.levels) || t.levels[j] <= t.levels[i] {
+		return g, j
+	}
+	all := ^uint32(0)
+	for j < len(t.levels) && t.levels[j] > t.levels[i] {
+		var c uint32
+		c, j = t.effective(j)
+		all &= c
+	}
+	return g | all, j
+}
@@ -0,0 +1,181 @@
+package mstypes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testDomainSID       = "S-1-5-21-1-2-3"
+	testUserClassGUID   = "bf967aba-0de6-11d0-a285-00aa003049e2" // User object class
+	testPropertySetGUID = "59ba2f42-79a2-11d0-9020-00c04fc2d3cf" // General Information property set
+	testPropertyGUID    = "bf967a0e-0de6-11d0-a285-00aa003049e2" // Name attribute
+	testOtherSetGUID    = "4c164200-20c0-11d0-a768-00aa006e0529" // User Account Restrictions property set
+)
+
+func testToken(t *testing.T) *Token {
+	sid := func(s string) RPCSID {
+		r, err := ParseSID(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+	return &Token{
+		UserSID: sid(testDomainSID + "-1105"),
+		Groups: []KerbSidAndAttributes{
+			{SID: sid(testDomainSID + "-513"), Attributes: 7},          // Enabled
+			{SID: sid(testDomainSID + "-1200"), Attributes: 1},         // Mandatory but not enabled
+			{SID: sid(testDomainSID + "-1300"), Attributes: 536870919}, // Enabled resource group
+			{SID: sid(SIDBuiltinAdministrators), Attributes: 0x10},     // Deny only
+			{SID: sid(SIDEveryone), Attributes: 7},
+		},
+	}
+}
+
+func testObjectTypes(t *testing.T, levels []uint16, guids ...string) []ObjectType {
+	o := make([]ObjectType, len(guids))
+	for i, s := range guids {
+		g, err := ParseGUID(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		o[i] = ObjectType{Level: levels[i], GUID: g}
+	}
+	return o
+}
+
+func Test_AccessCheck(t *testing.T) {
+	tok := testToken(t)
+	self, _ := ParseSID(testDomainSID + "-1105")
+	var tests = []struct {
+		SDDL          string
+		Desired       uint32
+		Mapping       GenericMapping
+		ObjectTypes   []ObjectType
+		PrincipalSelf *RPCSID
+		Granted       uint32
+		OK            bool
+	}{
+		// No DACL and NULL DACL
+		{"O:BA", 0x1, GenericMapping{}, nil, nil, 0x1, true},
+		{"D:NO_ACCESS_CONTROL", 0x3, GenericMapping{}, nil, nil, 0x3, true},
+		{"D:NO_ACCESS_CONTROL", AccessMaximumAllowed, FileGenericMapping, nil, nil, 0x1F01FF, true},
+		{"D:NO_ACCESS_CONTROL", AccessSystemSecurity, GenericMapping{}, nil, nil, 0, false},
+		// Empty DACL
+		{"D:", 0x1, GenericMapping{}, nil, nil, 0, false},
+		{"D:", AccessMaximumAllowed, GenericMapping{}, nil, nil, 0, false},
+		// Allow ACEs
+		{"D:(A;;FR;;;WD)", 0x120089, GenericMapping{}, nil, nil, 0x120089, true},
+		{"D:(A;;FR;;;WD)", 0x120116, GenericMapping{}, nil, nil, 0x120000, false},
+		{"D:(A;;0x1;;;WD)(A;;0x2;;;S-1-5-21-1-2-3-513)", 0x3, GenericMapping{}, nil, nil, 0x3, true},
+		{"D:(A;;0x1;;;S-1-5-21-1-2-3-1105)", 0x1, GenericMapping{}, nil, nil, 0x1, true},
+		{"D:(A;;0x1;;;S-1-5-21-1-2-3-1300)", 0x1, GenericMapping{}, nil, nil, 0x1, true},
+		{"D:(A;;0x1;;;S-1-5-21-1-2-3-1106)", 0x1, GenericMapping{}, nil, nil, 0, false},
+		// Deny ACEs and ordering
+		{"D:(D;;0x2;;;WD)(A;;0x3;;;WD)", 0x1, GenericMapping{}, nil, nil, 0x1, true},
+		{"D:(D;;0x2;;;WD)(A;;0x3;;;WD)", 0x2, GenericMapping{}, nil, nil, 0, false},
+		{"D:(A;;0x3;;;WD)(D;;0x2;;;WD)", 0x2, GenericMapping{}, nil, nil, 0x2, true},
+		{"D:(D;;0x1;;;S-1-5-21-1-2-3-1105)(A;;0x1;;;WD)", 0x1, GenericMapping{}, nil, nil, 0, false},
+		// Group attributes
+		{"D:(A;;0x1;;;S-1-5-21-1-2-3-1200)", 0x1, GenericMapping{}, nil, nil, 0, false},
+		{"D:(D;;0x1;;;S-1-5-21-1-2-3-1200)(A;;0x1;;;WD)", 0x1, GenericMapping{}, nil, nil, 0x1, true},
+		{"D:(A;;0x1;;;BA)", 0x1, GenericMapping{}, nil, nil, 0, false},
+		{"D:(D;;0x1;;;BA)(A;;0x1;;;WD)", 0x1, GenericMapping{}, nil, nil, 0, false},
+		// Inherit only ACEs are not applied
+		{"D:(A;IO;0x1;;;WD)", 0x1, GenericMapping{}, nil, nil, 0, false},
+		{"D:(D;OICIIO;0x1;;;WD)(A;;0x1;;;WD)", 0x1, GenericMapping{}, nil, nil, 0x1, true},
+		// Generic rights mapping
+		{"D:(A;;GR;;;WD)", AccessGenericRead, FileGenericMapping, nil, nil, 0x120089, true},
+		{"D:(A;;GR;;;WD)", AccessGenericWrite, FileGenericMapping, nil, nil, 0x120000, false},
+		{"D:(A;;GA;;;WD)", AccessDSReadProperty | AccessDSWriteProperty, DSGenericMapping, nil, nil, 0x30, true},
+		// Maximum allowed
+		{"D:(D;;0x2;;;WD)(A;;0x7;;;WD)", AccessMaximumAllowed, GenericMapping{}, nil, nil, 0x5, true},
+		{"D:(D;;0x2;;;WD)(A;;0x7;;;WD)", AccessMaximumAllowed | 0x2, GenericMapping{}, nil, nil, 0x5, false},
+		{"D:(A;;0x1;;;S-1-5-21-1-2-3-1200)", AccessMaximumAllowed, GenericMapping{}, nil, nil, 0, false},
+		// Owner implicit rights
+		{"O:S-1-5-21-1-2-3-1105D:", AccessReadControl | AccessWriteDACL, GenericMapping{}, nil, nil, AccessReadControl | AccessWriteDACL, true},
+		{"O:S-1-5-21-1-2-3-1105D:(D;;WD;;;WD)", AccessWriteDACL, GenericMapping{}, nil, nil, AccessWriteDACL, true},
+		{"O:S-1-5-21-1-2-3-1105D:", AccessWriteOwner, GenericMapping{}, nil, nil, 0, false},
+		{"O:S-1-5-21-1-2-3-1105D:(A;;RC;;;OW)", AccessWriteDACL, GenericMapping{}, nil, nil, 0, false},
+		{"O:S-1-5-21-1-2-3-1105D:(A;;RC;;;OW)", AccessReadControl, GenericMapping{}, nil, nil, AccessReadControl, true},
+		{"O:S-1-5-21-1-2-3-1106D:", AccessReadControl, GenericMapping{}, nil, nil, 0, false},
+		// Principal self substitution
+		{"D:(A;;0x1;;;PS)", 0x1, GenericMapping{}, nil, &self, 0x1, true},
+		{"D:(A;;0x1;;;PS)", 0x1, GenericMapping{}, nil, nil, 0, false},
+		// Callback ACEs
+		{"D:(XA;;0x1;;;WD)", 0x1, GenericMapping{}, nil, nil, 0, false},
+		{"D:(XD;;0x1;;;WD)(A;;0x1;;;WD)", 0x1, GenericMapping{}, nil, nil, 0, false},
+		// Object ACEs
+		{"D:(OA;;RP;" + testUserClassGUID + ";;WD)", AccessDSReadProperty, GenericMapping{}, nil, nil, 0, false},
+		{"D:(OA;;RP;;;WD)", AccessDSReadProperty, GenericMapping{}, nil, nil, AccessDSReadProperty, true},
+		{"D:(OA;;RP;" + testUserClassGUID + ";;WD)", AccessDSReadProperty, GenericMapping{},
+			testObjectTypes(t, []uint16{0}, testUserClassGUID), nil, AccessDSReadProperty, true},
+		{"D:(OA;;RP;" + testPropertySetGUID + ";;WD)", AccessDSReadProperty, GenericMapping{},
+			testObjectTypes(t, []uint16{0, 1, 2}, testUserClassGUID, testPropertySetGUID, testPropertyGUID), nil, AccessDSReadProperty, true},
+		{"D:(OA;;RP;" + testPropertyGUID + ";;WD)", AccessDSReadProperty, GenericMapping{},
+			testObjectTypes(t, []uint16{0, 1, 2, 1}, testUserClassGUID, testPropertySetGUID, testPropertyGUID, testOtherSetGUID), nil, 0, false},
+		{"D:(OA;;RP;" + testPropertySetGUID + ";;WD)(OA;;RP;" + testOtherSetGUID + ";;WD)", AccessDSReadProperty, GenericMapping{},
+			testObjectTypes(t, []uint16{0, 1, 2, 1}, testUserClassGUID, testPropertySetGUID, testPropertyGUID, testOtherSetGUID), nil, AccessDSReadProperty, true},
+		{"D:(OD;;RP;" + testPropertyGUID + ";;WD)(A;;RP;;;WD)", AccessDSReadProperty, GenericMapping{},
+			testObjectTypes(t, []uint16{0, 1, 2}, testUserClassGUID, testPropertySetGUID, testPropertyGUID), nil, 0, false},
+		{"D:(OD;;RP;" + testOtherSetGUID + ";;WD)(A;;RP;;;WD)", AccessDSReadProperty, GenericMapping{},
+			testObjectTypes(t, []uint16{0, 1, 2}, testUserClassGUID, testPropertySetGUID, testPropertyGUID), nil, AccessDSReadProperty, true},
+		{"D:(A;;RP;;;WD)(OD;;RP;" + testPropertyGUID + ";;WD)", AccessDSReadProperty, GenericMapping{},
+			testObjectTypes(t, []uint16{0, 1, 2}, testUserClassGUID, testPropertySetGUID, testPropertyGUID), nil, AccessDSReadProperty, true},
+	}
+	for i, test := range tests {
+		sd, err := ParseSDDL(test.SDDL)
+		if err != nil {
+			t.Fatalf("error parsing SDDL of test %d: %v", i, err)
+		}
+		granted, ok := AccessCheck(&sd, tok, AccessRequest{
+			DesiredAccess:  test.Desired,
+			GenericMapping: test.Mapping,
+			ObjectTypes:    test.ObjectTypes,
+			PrincipalSelf:  test.PrincipalSelf,
+		})
+		assert.Equal(t, test.OK, ok, "access check result not as expected for test %d", i)
+		assert.Equal(t, test.Granted, granted, "granted access not as expected for test %d", i)
+	}
+}
+
+func Test_AccessCheckNilSecurityDescriptor(t *testing.T) {
+	granted, ok := AccessCheck(nil, testToken(t), AccessRequest{DesiredAccess: 0x1})
+	assert.True(t, ok, "access check with no security descriptor should succeed")
+	assert.Equal(t, uint32(0x1), granted, "granted access not as expected")
+}
+
+func Test_TokenHasSID(t *testing.T) {
+	tok := testToken(t)
+	var tests = []struct {
+		SID   string
+		Deny  bool
+		Match bool
+	}{
+		{testDomainSID + "-1105", false, true},
+		{testDomainSID + "-1105", true, true},
+		{testDomainSID + "-513", false, true},
+		{testDomainSID + "-1200", false, false},
+		{testDomainSID + "-1200", true, false},
+		{testDomainSID + "-1300", false, true},
+		{SIDBuiltinAdministrators, false, false},
+		{SIDBuiltinAdministrators, true, true},
+		{SIDAuthenticatedUsers, false, false},
+	}
+	for i, test := range tests {
+		s, err := ParseSID(test.SID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, test.Match, tok.HasSID(s, test.Deny), "SID match not as expected for test %d", i)
+	}
+}
+
+func Test_GenericMapping(t *testing.T) {
+	assert.Equal(t, uint32(0x1F01FF), FileGenericMapping.Map(AccessGenericAll), "generic all not mapped as expected")
+	assert.Equal(t, uint32(0x1201BF), FileGenericMapping.Map(AccessGenericRead|AccessGenericWrite|AccessGenericExecute), "generic read, write and execute not mapped as expected")
+	assert.Equal(t, uint32(0x120089|AccessDelete), FileGenericMapping.Map(AccessGenericRead|AccessDelete), "specific rights not retained as expected")
+	assert.Equal(t, AccessGenericRead, GenericMapping{}.Map(AccessGenericRead), "empty mapping should not change the mask")
+}
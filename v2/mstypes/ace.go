@@ -0,0 +1,244 @@
+package mstypes
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// ACE type assigned numbers https://msdn.microsoft.com/en-us/library/cc230296.aspx
+const (
+	ACETypeAccessAllowed               uint8 = 0x00
+	ACETypeAccessDenied                uint8 = 0x01
+	ACETypeSystemAudit                 uint8 = 0x02
+	ACETypeSystemAlarm                 uint8 = 0x03 // Reserved for future use.
+	ACETypeAccessAllowedCompound       uint8 = 0x04 // Reserved for future use.
+	ACETypeAccessAllowedObject         uint8 = 0x05
+	ACETypeAccessDeniedObject          uint8 = 0x06
+	ACETypeSystemAuditObject           uint8 = 0x07
+	ACETypeSystemAlarmObject           uint8 = 0x08 // Reserved for future use.
+	ACETypeAccessAllowedCallback       uint8 = 0x09
+	ACETypeAccessDeniedCallback        uint8 = 0x0A
+	ACETypeAccessAllowedCallbackObject uint8 = 0x0B
+	ACETypeAccessDeniedCallbackObject  uint8 = 0x0C
+	ACETypeSystemAuditCallback         uint8 = 0x0D
+	ACETypeSystemAlarmCallback         uint8 = 0x0E // Reserved for future use.
+	ACETypeSystemAuditCallbackObject   uint8 = 0x0F
+	ACETypeSystemAlarmCallbackObject   uint8 = 0x10 // Reserved for future use.
+	ACETypeSystemMandatoryLabel        uint8 = 0x11
+	ACETypeSystemResourceAttribute     uint8 = 0x12
+	ACETypeSystemScopedPolicyID        uint8 = 0x13
+)
+
+// ACE flags https://msdn.microsoft.com/en-us/library/cc230296.aspx
+const (
+	ACEFlagObjectInherit      uint8 = 0x01
+	ACEFlagContainerInherit   uint8 = 0x02
+	ACEFlagNoPropagateInherit uint8 = 0x04
+	ACEFlagInheritOnly        uint8 = 0x08
+	ACEFlagInherited          uint8 = 0x10
+	ACEFlagCritical           uint8 = 0x20
+	ACEFlagSuccessfulAccess   uint8 = 0x40
+	ACEFlagFailedAccess       uint8 = 0x80
+)
+
+// Flags of object ACEs indicating which of the object type GUIDs are present.
+// https://msdn.microsoft.com/en-us/library/cc230289.aspx
+const (
+	ACEObjectTypePresent          uint32 = 0x00000001
+	ACEInheritedObjectTypePresent uint32 = 0x00000002
+)
+
+// Access mask bits https://msdn.microsoft.com/en-us/library/cc230294.aspx
+const (
+	AccessGenericRead          uint32 = 0x80000000
+	AccessGenericWrite         uint32 = 0x40000000
+	AccessGenericExecute       uint32 = 0x20000000
+	AccessGenericAll           uint32 = 0x10000000
+	AccessMaximumAllowed       uint32 = 0x02000000
+	AccessSystemSecurity       uint32 = 0x01000000
+	AccessSynchronize          uint32 = 0x00100000
+	AccessWriteOwner           uint32 = 0x00080000
+	AccessWriteDACL            uint32 = 0x00040000
+	AccessReadControl          uint32 = 0x00020000
+	AccessDelete               uint32 = 0x00010000
+	AccessDSCreateChild        uint32 = 0x00000001
+	AccessDSDeleteChild        uint32 = 0x00000002
+	AccessDSListChildren       uint32 = 0x00000004
+	AccessDSSelf               uint32 = 0x00000008
+	AccessDSReadProperty       uint32 = 0x00000010
+	AccessDSWriteProperty      uint32 = 0x00000020
+	AccessDSDeleteTree         uint32 = 0x00000040
+	AccessDSListObject         uint32 = 0x00000080
+	AccessDSControlAccess      uint32 = 0x00000100
+	AccessMandatoryNoWriteUp   uint32 = 0x00000001
+	AccessMandatoryNoReadUp    uint32 = 0x00000002
+	AccessMandatoryNoExecuteUp uint32 = 0x00000004
+)
+
+// Byte sizes of the fixed parts of ACEs.
+const (
+	sizeACEHeader = 4
+	sizeACEMask   = 4
+	sizeACEFlags  = 4
+)
+
+// ACEHeader implements https://msdn.microsoft.com/en-us/library/cc230296.aspx
+type ACEHeader struct {
+	AceType  uint8
+	AceFlags uint8
+	AceSize  uint16
+}
+
+// ACE implements the family of access control entry structures https://msdn.microsoft.com/en-us/library/cc230295.aspx
+// The fields populated depend on the AceType in the header:
+//   - All types have a Mask and SID.
+//   - Object types (ACCESS_ALLOWED_OBJECT_ACE etc) also have Flags and the ObjectType and InheritedObjectType as indicated by the Flags.
+//   - Callback types (ACCESS_ALLOWED_CALLBACK_ACE etc) and SYSTEM_RESOURCE_ATTRIBUTE_ACE have ApplicationData following the SID.
+type ACE struct {
+	Header              ACEHeader
+	Mask                uint32
+	Flags               uint32 // Only for object ACE types.
+	ObjectType          GUID   // Only for object ACE types when the ACEObjectTypePresent flag is set.
+	InheritedObjectType GUID   // Only for object ACE types when the ACEInheritedObjectTypePresent flag is set.
+	SID                 RPCSID
+	ApplicationData     []byte // Only for callback and resource attribute ACE types.
+}
+
+// IsObjectACE returns true if the ACE type is one of the object ACE types that may contain object type GUIDs.
+func (a *ACE) IsObjectACE() bool {
+	switch a.Header.AceType {
+	case ACETypeAccessAllowedObject, ACETypeAccessDeniedObject, ACETypeSystemAuditObject, ACETypeSystemAlarmObject,
+		ACETypeAccessAllowedCallbackObject, ACETypeAccessDeniedCallbackObject, ACETypeSystemAuditCallbackObject,
+		ACETypeSystemAlarmCallbackObject:
+		return true
+	}
+	return false
+}
+
+// IsCallbackACE returns true if the ACE type is one of the callback ACE types that carry application data.
+func (a *ACE) IsCallbackACE() bool {
+	switch a.Header.AceType {
+	case ACETypeAccessAllowedCallback, ACETypeAccessDeniedCallback, ACETypeAccessAllowedCallbackObject,
+		ACETypeAccessDeniedCallbackObject, ACETypeSystemAuditCallback, ACETypeSystemAlarmCallback,
+		ACETypeSystemAuditCallbackObject, ACETypeSystemAlarmCallbackObject:
+		return true
+	}
+	return false
+}
+
+// IsAccessAllowed returns true if the ACE type grants access.
+func (a *ACE) IsAccessAllowed() bool {
+	switch a.Header.AceType {
+	case ACETypeAccessAllowed, ACETypeAccessAllowedObject, ACETypeAccessAllowedCallback, ACETypeAccessAllowedCallbackObject:
+		return true
+	}
+	return false
+}
+
+// IsAccessDenied returns true if the ACE type denies access.
+func (a *ACE) IsAccessDenied() bool {
+	switch a.Header.AceType {
+	case ACETypeAccessDenied, ACETypeAccessDeniedObject, ACETypeAccessDeniedCallback, ACETypeAccessDeniedCallbackObject:
+		return true
+	}
+	return false
+}
+
+// HasFlag returns true if the ACE header has the flag provided set.
+func (a *ACE) HasFlag(f uint8) bool {
+	return a.Header.AceFlags&f == f
+}
+
+// hasApplicationData returns true if the ACE type carries data after the SID.
+func (a *ACE) hasApplicationData() bool {
+	return a.IsCallbackACE() || a.Header.AceType == ACETypeSystemResourceAttribute
+}
+
+// ACEFromBytes returns an ACE from its binary representation.
+// The byte slice may be longer than the ACE, the AceSize in the header is used to determine the extent of the ACE.
+func ACEFromBytes(b []byte) (a ACE, err error) {
+	if len(b) < sizeACEHeader+sizeACEMask {
+		err = errors.New("too few bytes for an ACE")
+		return
+	}
+	a.Header.AceType = b[0]
+	a.Header.AceFlags = b[1]
+	a.Header.AceSize = binary.LittleEndian.Uint16(b[2:4])
+	if int(a.Header.AceSize) > len(b) || a.Header.AceSize < sizeACEHeader+sizeACEMask {
+		err = fmt.Errorf("ACE size %d not valid for %d bytes available", a.Header.AceSize, len(b))
+		return
+	}
+	b = b[:a.Header.AceSize]
+	a.Mask = binary.LittleEndian.Uint32(b[4:8])
+	p := sizeACEHeader + sizeACEMask
+	if a.IsObjectACE() {
+		if len(b) < p+sizeACEFlags {
+			err = errors.New("too few bytes for object ACE flags")
+			return
+		}
+		a.Flags = binary.LittleEndian.Uint32(b[p : p+sizeACEFlags])
+		p += sizeACEFlags
+		if a.Flags&ACEObjectTypePresent != 0 {
+			a.ObjectType, err = GUIDFromBytes(b[p:])
+			if err != nil {
+				err = fmt.Errorf("could not read ACE object type: %v", err)
+				return
+			}
+			p += SizeGUID
+		}
+		if a.Flags&ACEInheritedObjectTypePresent != 0 {
+			a.InheritedObjectType, err = GUIDFromBytes(b[p:])
+			if err != nil {
+				err = fmt.Errorf("could not read ACE inherited object type: %v", err)
+				return
+			}
+			p += SizeGUID
+		}
+	}
+	if p > len(b) {
+		err = errors.New("too few bytes for ACE SID")
+		return
+	}
+	sid, n, e := readSIDBytes(b[p:])
+	if e != nil {
+		err = fmt.Errorf("could not read ACE SID: %v", e)
+		return
+	}
+	a.SID = sid
+	p += n
+	if a.hasApplicationData() && p < len(b) {
+		a.ApplicationData = make([]byte, len(b)-p)
+		copy(a.ApplicationData, b[p:])
+	}
+	return
+}
+
+// Bytes returns the binary representation of the ACE. The AceSize is calculated from the content of the ACE rather
+// than taken from the header and is padded to a multiple of 4 bytes.
+func (a *ACE) Bytes() []byte {
+	b := make([]byte, sizeACEHeader+sizeACEMask, sizeACEHeader+sizeACEMask+sizeACEFlags+2*SizeGUID)
+	b[0] = a.Header.AceType
+	b[1] = a.Header.AceFlags
+	binary.LittleEndian.PutUint32(b[4:8], a.Mask)
+	if a.IsObjectACE() {
+		f := make([]byte, sizeACEFlags, sizeACEFlags)
+		binary.LittleEndian.PutUint32(f, a.Flags)
+		b = append(b, f...)
+		if a.Flags&ACEObjectTypePresent != 0 {
+			b = append(b, a.ObjectType.Bytes()...)
+		}
+		if a.Flags&ACEInheritedObjectTypePresent != 0 {
+			b = append(b, a.InheritedObjectType.Bytes()...)
+		}
+	}
+	b = append(b, a.SID.Bytes()...)
+	if a.hasApplicationData() {
+		b = append(b, a.ApplicationData...)
+	}
+	if r := len(b) % 4; r != 0 {
+		b = append(b, make([]byte, 4-r)...)
+	}
+	binary.LittleEndian.PutUint16(b[2:4], uint16(len(b)))
+	return b
+}
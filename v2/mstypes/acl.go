@@ -0,0 +1,81 @@
+package mstypes
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// ACL revision values https://msdn.microsoft.com/en-us/library/cc230297.aspx
+const (
+	ACLRevision   uint8 = 0x02 // When set to 0x02, only AceTypes 0x00, 0x01, 0x02, 0x03, 0x11, 0x12, and 0x13 can be present in the ACL.
+	ACLRevisionDS uint8 = 0x04 // When set to 0x04, AceTypes 0x05, 0x06, 0x07, 0x08, and 0x11 are allowed.
+)
+
+const sizeACLHeader = 8
+
+// ACL implements https://msdn.microsoft.com/en-us/library/cc230297.aspx
+type ACL struct {
+	AclRevision uint8
+	Sbz1        uint8
+	AclSize     uint16
+	AceCount    uint16
+	Sbz2        uint16
+	ACEs        []ACE
+}
+
+// ACLFromBytes returns an ACL from its binary representation.
+// The byte slice may be longer than the ACL, the AclSize is used to determine the extent of the ACL.
+func ACLFromBytes(b []byte) (a ACL, err error) {
+	if len(b) < sizeACLHeader {
+		err = errors.New("too few bytes for an ACL")
+		return
+	}
+	a.AclRevision = b[0]
+	if a.AclRevision != ACLRevision && a.AclRevision != ACLRevisionDS {
+		err = fmt.Errorf("ACL revision %d not valid", a.AclRevision)
+		return
+	}
+	a.Sbz1 = b[1]
+	a.AclSize = binary.LittleEndian.Uint16(b[2:4])
+	a.AceCount = binary.LittleEndian.Uint16(b[4:6])
+	a.Sbz2 = binary.LittleEndian.Uint16(b[6:8])
+	if int(a.AclSize) > len(b) || a.AclSize < sizeACLHeader {
+		err = fmt.Errorf("ACL size %d not valid for %d bytes available", a.AclSize, len(b))
+		return
+	}
+	b = b[:a.AclSize]
+	p := sizeACLHeader
+	a.ACEs = make([]ACE, a.AceCount, a.AceCount)
+	for i := range a.ACEs {
+		a.ACEs[i], err = ACEFromBytes(b[p:])
+		if err != nil {
+			err = fmt.Errorf("could not read ACE %d: %v", i, err)
+			return
+		}
+		p += int(a.ACEs[i].Header.AceSize)
+	}
+	return
+}
+
+// Bytes returns the binary representation of the ACL. The AclSize and AceCount are calculated from the ACEs rather
+// than taken from the struct fields. If any ACE is an object ACE the revision is raised to ACLRevisionDS.
+func (a *ACL) Bytes() []byte {
+	b := make([]byte, sizeACLHeader, sizeACLHeader)
+	rev := a.AclRevision
+	if rev == 0 {
+		rev = ACLRevision
+	}
+	for i := range a.ACEs {
+		if a.ACEs[i].IsObjectACE() {
+			rev = ACLRevisionDS
+		}
+		b = append(b, a.ACEs[i].Bytes()...)
+	}
+	b[0] = rev
+	b[1] = a.Sbz1
+	binary.LittleEndian.PutUint16(b[2:4], uint16(len(b)))
+	binary.LittleEndian.PutUint16(b[4:6], uint16(len(a.ACEs)))
+	binary.LittleEndian.PutUint16(b[6:8], a.Sbz2)
+	return b
+}
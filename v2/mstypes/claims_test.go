@@ -2,13 +2,12 @@ package mstypes
 
 import (
 	"bytes"
-	"compress/flate"
 	"encoding/hex"
-	"io/ioutil"
+	"encoding/json"
 	"testing"
-	"unicode/utf8"
 
 	"github.com/jcmturner/rpc/v2/ndr"
+	"github.com/jcmturner/rpc/v2/xca"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -19,6 +18,10 @@ const (
 	ClientClaimsInfoMultiUint = "01100800ccccccccf00000000000000000000200c80000000400020000000000c8000000000000000000000000000000c800000001100800ccccccccb80000000000000000000200010000000400020000000000000000000000000001000000010000000100000008000200010000000c000200020002000400000010000200260000000000000026000000610064003a002f002f006500780074002f006f0062006a0065006300740043006c006100730073003a00380038006400350064006500370039003100650037006200320037006500360000000400000009000a000000000007000100000000000600010000000000000001000000000000000000"
 	ClientClaimsInfoMultiStr  = "01100800cccccccc480100000000000000000200200100000400020000000000200100000000000000000000000000002001000001100800cccccccc100100000000000000000200010000000400020000000000000000000000000001000000010000000100000008000200010000000c000200030003000400000010000200270000000000000027000000610064003a002f002f006500780074002f006f00740068006500720049007000500068006f006e0065003a003800380064003500640065003900660036006200340061006600390038003500000000000400000014000200180002001c000200200002000500000000000000050000007300740072003100000000000500000000000000050000007300740072003200000000000500000000000000050000007300740072003300000000000500000000000000050000007300740072003400000000000000000000000000"
 
+	// ClaimsSetUnknownType is a synthetic ClaimsSet, not captured from a PAC, with a claim of type 99 followed by an
+	// int64 claim.
+	ClaimsSetUnknownType = "01100800ccccccccc80000000000000000000200010000000400020000000000000000000000000001000000010000000200000008000200020000000c00020063006300020000001000020014000200010001000100000018000200110000000000000011000000610064003a002f002f006500780074002f0075006e006b006e006f0077006e000000000002000000070000000000000000000000000100000d000000000000000d000000610064003a002f002f006500780074002f0069006e007400000000000100000000000000fbffffffffffffff"
+
 	ClaimsEntryIDStr            = "ad://ext/sAMAccountName:88d5d9085ea5c0c0"
 	ClaimsEntryValueStr         = "testuser1"
 	ClaimsEntryIDInt64          = "ad://ext/msDS-SupportedE:88d5dea8f1af5f19"
@@ -26,17 +29,183 @@ const (
 	ClaimsEntryIDUInt64         = "ad://ext/objectClass:88d5de791e7b27e6"
 
 	ClaimsSetBytesCompressionFormatXPressHuff = "738788888708080007000800080007000880088808088880886687888607080000808800800000000880000000000000806667080808787707767800080000000000000000000000000000000000000000000000080000000000000000000000000000000000080000000000000000000000000000000000000000000000000057000800800000007500000000000000050700000000000064760800080000008587007700000080650808000000000075888700000000700788000000000060677000000000007000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000002e91f150e1ad792412496411f3904ff6027871529ef12043e4e79ab23c9f03aea65c0aca41e842b8d46f0321354538afe9f8c413b6e1a37377bca410ac8bc3b35398e51c0a290929e3ca764addf84e5ada9caa43c80c38de74d75cd0289a202641d26a950284dea25479c4376c3100720db619b9066d13c506c88a858a3305007490a40d7015a7528382a7c9ae54ab58204f01e1d8e044fee01925cbc46ad28cfa8d67c28e0216ce1de315aaaf43e4c88409002793b33a3823683680ce7d6606eca05f0cff9d06c88a0588dd5500d51de514570286fa148c007c699838d635b0b87ed420749011c94696fa202b002b0000"
+	ClaimsSetBytesCompressionFormatLZNT1      = "67b12001100800cc0000a802a20004000200010028040038c70900014c035c000008006c011c5a0c001c030002011610001624a30276010200002800162c00066b0102013e30000e34045e013e380d00162704b6010761006400083a002f0001650078002a7400076f000568000d7200204900700050000b6f002a6e000f3a003b38002d3600aa31005165000162000f66020db8330035000101000173140063aa1800031c000320000305046f75010773005d720033050f8b0b32d5940b33940b3482072a840b8103058f676d8015440053002d55800175806870806772800c6515801445906a648003620039b180026300378248819b1c843146268803919d62006a800663d1800643006c80107380008f31aa63820432400433400065c44d51010009000ac20107421d008c0006c401432100002344035bc101cf1f75401b416e6ec0066dabd26cc10c32c00238c01d63c005ed45163cc069431e00c201c147c158034514436c0000"
+	ClaimsSetBytesCompressionFormatXPress     = "5ae3450401100800cc0000a80200040002000128000438000700429900bb00000008d80039000c380003080059001058002410b0d6c5da01090000002858002c18000900f900303800347c01f90038580027dc023900610064003a002f080065007800555404547438006f28006868007200490070005058006f006e78003ad8013868013600318802650800627800666a00abae551d3300350800010099031418031818001c1800201800057c03390073e8027298017d00bf0032bf00dd33bf00347a002abc0039008da8aaa07f06986d5801440053002d180075880670780672c80065480145af0664380062003928006300378a04b9091c1c038a558b62263f00a1df0962006a680063680043006c08017308001f0388634a0032880033080065bc09010009000a3a0007aa0300b7d5da3100063c002b040000236c003900ff03756803c90d6ed8006d9f0d0b990132580038b80363b800cd023c380dcb03003a00f908190bffffffcf8d028b0d0000"
 )
 
-func Test_TMP(t *testing.T) {
-	blz77huff, _ := hex.DecodeString("00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000030230000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000200000000000000000000000000002000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000a8dc0000ff2601")
-	//b, _ := hex.DecodeString("00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000050555555555555555555554544040000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000400000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000d8523ed794115be9195ff9d67cdf8d0400000000")
-	r := bytes.NewReader(blz77huff)
-	dr := flate.NewReader(r)
-	s, errr := ioutil.ReadAll(dr)
-	t.Logf("test: %s %v\n", string(s), errr)
-	ru, size := utf8.DecodeRuneInString("`")
-	t.Logf("%d %v\n", ru, size)
+func Test_ClaimsSetCompressionFormatXPressHuff(t *testing.T) {
+	b, _ := hex.DecodeString(ClaimsSetBytesCompressionFormatXPressHuff)
+	m := ClaimsSetMetadata{
+		ClaimsSetSize:             uint32(len(b)),
+		ClaimsSetBytes:            b,
+		CompressionFormat:         CompressionFormatXPressHuff,
+		UncompressedClaimsSetSize: 696,
+	}
+	k, err := m.ClaimsSet()
+	if err != nil {
+		t.Fatalf("error retrieving ClaimsSet %v", err)
+	}
+	assertCompressedClaimsSet(t, k)
+	assert.Equal(t, ClaimsSetBytesCompressionFormatXPressHuff, hex.EncodeToString(m.ClaimsSetBytes), "compressed bytes should not be modified")
+
+	m.UncompressedClaimsSetSize = 2000
+	_, err = m.ClaimsSet()
+	assert.Error(t, err, "expected error when the uncompressed size is larger than the compressed data provides")
+}
+
+func Test_ClaimsSetCompressionFormatLZNT1(t *testing.T) {
+	b, _ := hex.DecodeString(ClaimsSetBytesCompressionFormatLZNT1)
+	m := ClaimsSetMetadata{
+		ClaimsSetSize:             uint32(len(b)),
+		ClaimsSetBytes:            b,
+		CompressionFormat:         CompressionFormatLZNT1,
+		UncompressedClaimsSetSize: 696,
+	}
+	k, err := m.ClaimsSet()
+	if err != nil {
+		t.Fatalf("error retrieving ClaimsSet %v", err)
+	}
+	assertCompressedClaimsSet(t, k)
+
+	m.UncompressedClaimsSetSize = 600
+	_, err = m.ClaimsSet()
+	assert.Error(t, err, "expected error when the uncompressed size is smaller than the decompressed data")
+}
+
+func Test_ClaimsSetHugeUncompressedSize(t *testing.T) {
+	for _, f := range []uint16{CompressionFormatLZNT1, CompressionFormatXPress, CompressionFormatXPressHuff} {
+		m := ClaimsSetMetadata{
+			ClaimsSetSize:             2,
+			ClaimsSetBytes:            []byte{0x00, 0xb0},
+			CompressionFormat:         f,
+			UncompressedClaimsSetSize: 0xfffffff0,
+		}
+		_, err := m.ClaimsSet()
+		if assert.Error(t, err, "expected error for an uncompressed size that is too large for format %d", f) {
+			assert.Contains(t, err.Error(), "maximum", "error not as expected for format %d", f)
+		}
+	}
+}
+
+func Test_ClaimsSetCompressionFormatXPress(t *testing.T) {
+	b, _ := hex.DecodeString(ClaimsSetBytesCompressionFormatXPress)
+	m := ClaimsSetMetadata{
+		ClaimsSetSize:             uint32(len(b)),
+		ClaimsSetBytes:            b,
+		CompressionFormat:         CompressionFormatXPress,
+		UncompressedClaimsSetSize: 696,
+	}
+	k, err := m.ClaimsSet()
+	if err != nil {
+		t.Fatalf("error retrieving ClaimsSet %v", err)
+	}
+	assertCompressedClaimsSet(t, k)
+
+	m.UncompressedClaimsSetSize = 600
+	_, err = m.ClaimsSet()
+	assert.Error(t, err, "expected error when the uncompressed size is smaller than the decompressed data")
+}
+
+func Test_ClaimsSetMetadata_Marshal(t *testing.T) {
+	// Re-encoding the claims produces the same bytes as Windows.
+	for i, h := range []string{ClientClaimsInfoStr, ClientClaimsInfoInt, ClientClaimsInfoMulti, ClientClaimsInfoMultiUint, ClientClaimsInfoMultiStr} {
+		b, _ := hex.DecodeString(h)
+		m := new(ClaimsSetMetadata)
+		err := ndr.NewDecoder(bytes.NewReader(b)).Decode(m)
+		if err != nil {
+			t.Fatalf("error decoding ClaimsSetMetadata of test %d: %v", i, err)
+		}
+		k, err := m.ClaimsSet()
+		if err != nil {
+			t.Fatalf("error retrieving ClaimsSet of test %d: %v", i, err)
+		}
+		n, err := NewClaimsSetMetadata(k)
+		if err != nil {
+			t.Fatalf("error creating ClaimsSetMetadata of test %d: %v", i, err)
+		}
+		assert.Equal(t, *m, n, "ClaimsSetMetadata not as expected for test %d", i)
+		var buf bytes.Buffer
+		err = ndr.NewEncoder(&buf).Encode(n)
+		if err != nil {
+			t.Fatalf("error encoding ClaimsSetMetadata of test %d: %v", i, err)
+		}
+		assert.Equal(t, h, hex.EncodeToString(buf.Bytes()), "encoded ClaimsSetMetadata not as expected for test %d", i)
+	}
+}
+
+func Test_NewClaimsSetMetadataCompressed(t *testing.T) {
+	b, _ := hex.DecodeString(ClaimsSetBytesCompressionFormatXPressHuff)
+	u, _ := xca.DecompressLZ77Huffman(b, 696)
+	m := ClaimsSetMetadata{
+		ClaimsSetSize:             uint32(len(b)),
+		ClaimsSetBytes:            b,
+		CompressionFormat:         CompressionFormatXPressHuff,
+		UncompressedClaimsSetSize: 696,
+	}
+	k, err := m.ClaimsSet()
+	if err != nil {
+		t.Fatalf("error retrieving ClaimsSet %v", err)
+	}
+	n, err := NewClaimsSetMetadata(k)
+	if err != nil {
+		t.Fatalf("error creating ClaimsSetMetadata: %v", err)
+	}
+	assert.Equal(t, CompressionFormatXPressHuff, n.CompressionFormat, "compression format not as expected")
+	assert.Equal(t, uint32(696), n.UncompressedClaimsSetSize, "uncompressed size not as expected")
+	assert.Equal(t, uint32(len(n.ClaimsSetBytes)), n.ClaimsSetSize, "claims set size not as expected")
+	assert.True(t, n.ClaimsSetSize < n.UncompressedClaimsSetSize, "claims set not compressed")
+	d, err := xca.DecompressLZ77Huffman(n.ClaimsSetBytes, 696)
+	if err != nil {
+		t.Fatalf("error decompressing ClaimsSet: %v", err)
+	}
+	assert.Equal(t, u, d, "ClaimsSet encoding not the same as Windows")
+	k, err = n.ClaimsSet()
+	if err != nil {
+		t.Fatalf("error retrieving ClaimsSet %v", err)
+	}
+	assertCompressedClaimsSet(t, k)
+
+	// The threshold is configurable and a negative one disables compression.
+	for i, threshold := range []int{-1, 697} {
+		n, err = NewClaimsSetMetadataThreshold(k, threshold)
+		if err != nil {
+			t.Fatalf("error creating ClaimsSetMetadata for threshold test %d: %v", i, err)
+		}
+		assert.Equal(t, CompressionFormatNone, n.CompressionFormat, "compression format not as expected for threshold test %d", i)
+		assert.Equal(t, u, n.ClaimsSetBytes, "claims set bytes not as expected for threshold test %d", i)
+	}
+
+	// The other compression formats can be read.
+	for _, test := range []struct {
+		Format   uint16
+		Compress func([]byte) []byte
+	}{
+		{CompressionFormatLZNT1, xca.CompressLZNT1},
+		{CompressionFormatXPress, xca.CompressLZ77},
+	} {
+		m.CompressionFormat = test.Format
+		m.ClaimsSetBytes = test.Compress(u)
+		k, err = m.ClaimsSet()
+		if err != nil {
+			t.Fatalf("error retrieving ClaimsSet compressed with format %d: %v", test.Format, err)
+		}
+		assertCompressedClaimsSet(t, k)
+	}
+}
+
+// assertCompressedClaimsSet checks the claims of the ClaimsSet in the compressed test vectors.
+func assertCompressedClaimsSet(t *testing.T, k ClaimsSet) {
+	assert.Equal(t, uint32(1), k.ClaimsArrayCount, "claims array count not as expected")
+	assert.Equal(t, ClaimsSourceTypeAD, k.ClaimsArrays[0].ClaimsSourceType, "claims source type not as expected")
+	assert.Equal(t, uint32(4), k.ClaimsArrays[0].ClaimsCount, "claims count not as expected")
+	assert.Equal(t, "ad://ext/otherIpPhone:88d614eeb8f14355", k.ClaimsArrays[0].ClaimEntries[0].ID, "claims entry ID not as expected")
+	assert.Equal(t, []LPWSTR{{"str1"}, {"str2"}, {"str3"}, {"str4"}}, k.ClaimsArrays[0].ClaimEntries[0].TypeString.Value, "claims value not as expected")
+	assert.Equal(t, "ad://ext/msDS-SupportedE:88d614eed4b94c72", k.ClaimsArrays[0].ClaimEntries[1].ID, "claims entry ID not as expected")
+	assert.Equal(t, []int64{ClaimsEntryValueInt64}, k.ClaimsArrays[0].ClaimEntries[1].TypeInt64.Value, "claims value not as expected")
+	assert.Equal(t, "ad://ext/objectClass:88d614eec142633e", k.ClaimsArrays[0].ClaimEntries[2].ID, "claims entry ID not as expected")
+	assert.Equal(t, []uint64{655369, 65543, 65542, 65536}, k.ClaimsArrays[0].ClaimEntries[2].TypeUInt64.Value, "claims value not as expected")
+	assert.Equal(t, "ad://ext/username:88d614eead2483c6", k.ClaimsArrays[0].ClaimEntries[3].ID, "claims entry ID not as expected")
+	assert.Equal(t, []LPWSTR{{ClaimsEntryValueStr}}, k.ClaimsArrays[0].ClaimEntries[3].TypeString.Value, "claims value not as expected")
 }
 
 func Test_ClientClaimsInfoStr_Unmarshal(t *testing.T) {
@@ -156,3 +325,142 @@ func Test_ClientClaimsInfoMultiEntry_Unmarshal(t *testing.T) {
 	assert.Equal(t, []LPWSTR{{ClaimsEntryValueStr}}, k.ClaimsArrays[0].ClaimEntries[1].TypeString.Value, "claims value not as expected")
 	assert.Equal(t, CompressionFormatNone, m.CompressionFormat, "compression format not as expected")
 }
+
+func Test_Claims(t *testing.T) {
+	b, _ := hex.DecodeString(ClaimsSetBytesCompressionFormatXPressHuff)
+	m := ClaimsSetMetadata{
+		ClaimsSetSize:             uint32(len(b)),
+		ClaimsSetBytes:            b,
+		CompressionFormat:         CompressionFormatXPressHuff,
+		UncompressedClaimsSetSize: 696,
+	}
+	k, err := m.Claims()
+	if err != nil {
+		t.Fatalf("error retrieving Claims %v", err)
+	}
+	assert.Equal(t, 4, k.Len(), "number of claims not as expected")
+	var ids []string
+	for _, c := range k.List() {
+		ids = append(ids, c.ID)
+		assert.Equal(t, ClaimsSourceTypeAD, c.SourceType, "claim source type not as expected")
+	}
+	assert.Equal(t, []string{
+		"ad://ext/otherIpPhone:88d614eeb8f14355",
+		"ad://ext/msDS-SupportedE:88d614eed4b94c72",
+		"ad://ext/objectClass:88d614eec142633e",
+		"ad://ext/username:88d614eead2483c6",
+	}, ids, "claims not in wire order")
+
+	s, ok := k.Strings("ad://ext/otherIpPhone:88d614eeb8f14355")
+	assert.True(t, ok, "string claim not found")
+	assert.Equal(t, []string{"str1", "str2", "str3", "str4"}, s, "string claim values not as expected")
+	i, ok := k.Int64s("ad://ext/msDS-SupportedE:88d614eed4b94c72")
+	assert.True(t, ok, "int64 claim not found")
+	assert.Equal(t, []int64{ClaimsEntryValueInt64}, i, "int64 claim values not as expected")
+	u, ok := k.UInt64s("ad://ext/objectClass:88d614eec142633e")
+	assert.True(t, ok, "uint64 claim not found")
+	assert.Equal(t, []uint64{655369, 65543, 65542, 65536}, u, "uint64 claim values not as expected")
+
+	_, ok = k.Strings("ad://ext/msDS-SupportedE:88d614eed4b94c72")
+	assert.False(t, ok, "claim of a different type should not be returned")
+	_, ok = k.Bool("ad://ext/username:88d614eead2483c6")
+	assert.False(t, ok, "claim of a different type should not be returned")
+	_, ok = k.Int64s("ad://ext/notpresent")
+	assert.False(t, ok, "claim not present should not be returned")
+	c, ok := k.SourceClaim(ClaimsSourceTypeAD, "ad://ext/username:88d614eead2483c6")
+	assert.True(t, ok, "claim not found by source type")
+	assert.Equal(t, []string{ClaimsEntryValueStr}, c.Values(), "claim values not as expected")
+	_, ok = k.SourceClaim(0, "ad://ext/username:88d614eead2483c6")
+	assert.False(t, ok, "claim of a different source type should not be returned")
+
+	j, err := json.Marshal(k)
+	if err != nil {
+		t.Fatalf("error marshaling claims to JSON: %v", err)
+	}
+	assert.Equal(t, `[{"id":"ad://ext/otherIpPhone:88d614eeb8f14355","sourceType":1,"type":"string","values":["str1","str2","str3","str4"]},`+
+		`{"id":"ad://ext/msDS-SupportedE:88d614eed4b94c72","sourceType":1,"type":"int64","values":[28]},`+
+		`{"id":"ad://ext/objectClass:88d614eec142633e","sourceType":1,"type":"uint64","values":[655369,65543,65542,65536]},`+
+		`{"id":"ad://ext/username:88d614eead2483c6","sourceType":1,"type":"string","values":["testuser1"]}]`, string(j), "JSON not as expected")
+}
+
+func Test_ClaimsBool(t *testing.T) {
+	s := ClaimsSet{
+		ClaimsArrayCount: 2,
+		ClaimsArrays: []ClaimsArray{
+			{
+				ClaimsSourceType: ClaimsSourceTypeAD,
+				ClaimsCount:      2,
+				ClaimEntries: []ClaimEntry{
+					{ID: "single", Type: ClaimsTypeIDBoolean, TypeBool: ClaimTypeBoolean{ValueCount: 1, Value: []bool{true}}},
+					{ID: "multi", Type: ClaimsTypeIDBoolean, TypeBool: ClaimTypeBoolean{ValueCount: 2, Value: []bool{true, false}}},
+				},
+			},
+			{
+				ClaimsSourceType: 0,
+				ClaimsCount:      1,
+				ClaimEntries: []ClaimEntry{
+					{ID: "single", Type: ClaimsTypeIDBoolean, TypeBool: ClaimTypeBoolean{ValueCount: 1, Value: []bool{false}}},
+				},
+			},
+		},
+	}
+	k := s.Claims()
+	assert.Equal(t, 3, k.Len(), "number of claims not as expected")
+	v, ok := k.Bool("single")
+	assert.True(t, ok, "boolean claim not found")
+	assert.True(t, v, "the first claim with the ID should be returned")
+	c, ok := k.SourceClaim(0, "single")
+	assert.True(t, ok, "boolean claim not found by source type")
+	assert.Equal(t, []bool{false}, c.Bools, "boolean claim values not as expected")
+	_, ok = k.Bool("multi")
+	assert.False(t, ok, "multi valued boolean claim should not be returned as a single value")
+	b, ok := k.Bools("multi")
+	assert.True(t, ok, "boolean claim not found")
+	assert.Equal(t, []bool{true, false}, b, "boolean claim values not as expected")
+
+	j, err := json.Marshal(ClaimsSet{}.Claims())
+	if err != nil {
+		t.Fatalf("error marshaling claims to JSON: %v", err)
+	}
+	assert.Equal(t, "[]", string(j), "JSON of no claims not as expected")
+}
+
+func Test_ClaimsSetCertificateSource(t *testing.T) {
+	s := ClaimsSet{
+		ClaimsArrayCount: 1,
+		ClaimsArrays: []ClaimsArray{{
+			ClaimsSourceType: ClaimsSourceTypeCertificate,
+			ClaimsCount:      1,
+			ClaimEntries: []ClaimEntry{{
+				ID:         "cert://issuer",
+				Type:       ClaimTypeIDString,
+				TypeString: ClaimTypeString{ValueCount: 1, Value: []LPWSTR{{Value: "CN=Test CA"}}},
+			}},
+		}},
+	}
+	var buf bytes.Buffer
+	err := ndr.NewEncoder(&buf).Encode(s)
+	if err != nil {
+		t.Fatalf("error encoding ClaimsSet: %v", err)
+	}
+	var d ClaimsSet
+	err = ndr.Unmarshal(buf.Bytes(), &d)
+	if err != nil {
+		t.Fatalf("error decoding ClaimsSet: %v", err)
+	}
+	j, err := json.Marshal(d.Claims())
+	if err != nil {
+		t.Fatalf("error marshaling claims to JSON: %v", err)
+	}
+	assert.Equal(t, `[{"id":"cert://issuer","sourceType":2,"type":"string","values":["CN=Test CA"]}]`, string(j),
+		"claims JSON not as expected")
+}
+
+func Test_ClaimsSetUnknownType(t *testing.T) {
+	b, _ := hex.DecodeString(ClaimsSetUnknownType)
+	var s ClaimsSet
+	err := ndr.Unmarshal(b, &s)
+	if assert.Error(t, err, "a claim of an unknown type should return an error") {
+		assert.Contains(t, err.Error(), "discriminant 99", "error does not name the claim type")
+	}
+}
@@ -0,0 +1,144 @@
+package mstypes
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UserFlags are the flags of the UserFlags field of KERB_VALIDATION_INFO https://msdn.microsoft.com/en-us/library/cc237948.aspx
+// They are those of the NETLOGON_VALIDATION_SAM_INFO structures specified in MS-NRPC section 2.2.1.4.11.
+type UserFlags uint32
+
+// UserFlags values
+const (
+	UserFlagGuest               UserFlags = 0x00000001 // LOGON_GUEST: Authentication was done via the GUEST account.
+	UserFlagNoEncryption        UserFlags = 0x00000002 // LOGON_NOENCRYPTION: No password encryption was available.
+	UserFlagCachedAccount       UserFlags = 0x00000004 // LOGON_CACHED_ACCOUNT: The logon used cached account data.
+	UserFlagUsedLMPassword      UserFlags = 0x00000008 // LOGON_USED_LM_PASSWORD: The LAN Manager key was used for authentication.
+	UserFlagExtraSIDs           UserFlags = 0x00000020 // LOGON_EXTRA_SIDS: The ExtraSIDs field contains SIDs.
+	UserFlagSubAuthSessionKey   UserFlags = 0x00000040 // LOGON_SUBAUTH_SESSION_KEY: The session key came from a subauthentication package.
+	UserFlagServerTrustAccount  UserFlags = 0x00000080 // LOGON_SERVER_TRUST_ACCOUNT: The account is a domain controller's trust account.
+	UserFlagNTLMv2Enabled       UserFlags = 0x00000100 // LOGON_NTLMV2_ENABLED: The server supports NTLMv2.
+	UserFlagResourceGroups      UserFlags = 0x00000200 // LOGON_RESOURCE_GROUPS: The ResourceGroupDomainSID and ResourceGroupIDs fields are populated.
+	UserFlagProfilePathReturned UserFlags = 0x00000400 // LOGON_PROFILE_PATH_RETURNED: The ProfilePath field is populated.
+	UserFlagNTv2                UserFlags = 0x00000800 // LOGON_NT_V2: The NTv2 response was used for authentication and session key generation.
+	UserFlagLMv2                UserFlags = 0x00001000 // LOGON_LM_V2: The LMv2 response was used for authentication and session key generation.
+	UserFlagNTLMv2              UserFlags = 0x00002000 // LOGON_NTLM_V2: The NTLMv2 response was used for authentication and session key generation.
+)
+
+var userFlagNames = []flagName{
+	{uint32(UserFlagGuest), "LOGON_GUEST"},
+	{uint32(UserFlagNoEncryption), "LOGON_NOENCRYPTION"},
+	{uint32(UserFlagCachedAccount), "LOGON_CACHED_ACCOUNT"},
+	{uint32(UserFlagUsedLMPassword), "LOGON_USED_LM_PASSWORD"},
+	{uint32(UserFlagExtraSIDs), "LOGON_EXTRA_SIDS"},
+	{uint32(UserFlagSubAuthSessionKey), "LOGON_SUBAUTH_SESSION_KEY"},
+	{uint32(UserFlagServerTrustAccount), "LOGON_SERVER_TRUST_ACCOUNT"},
+	{uint32(UserFlagNTLMv2Enabled), "LOGON_NTLMV2_ENABLED"},
+	{uint32(UserFlagResourceGroups), "LOGON_RESOURCE_GROUPS"},
+	{uint32(UserFlagProfilePathReturned), "LOGON_PROFILE_PATH_RETURNED"},
+	{uint32(UserFlagNTv2), "LOGON_NT_V2"},
+	{uint32(UserFlagLMv2), "LOGON_LM_V2"},
+	{uint32(UserFlagNTLMv2), "LOGON_NTLM_V2"},
+}
+
+// Has returns true if all of the flags provided are set.
+func (f UserFlags) Has(flags UserFlags) bool {
+	return f&flags == flags
+}
+
+// String returns the names of the flags that are set separated by "|", for example
+// "LOGON_EXTRA_SIDS|LOGON_RESOURCE_GROUPS". Bits without a name are rendered in hex.
+func (f UserFlags) String() string {
+	return flagString(uint32(f), userFlagNames)
+}
+
+// UserAccountControl are the flags of an account's UserAccountControl, as specified in MS-SAMR section 2.2.1.12.
+type UserAccountControl uint32
+
+// UserAccountControl values
+const (
+	UserAccountDisabled                    UserAccountControl = 0x00000001 // USER_ACCOUNT_DISABLED
+	UserHomeDirectoryRequired              UserAccountControl = 0x00000002 // USER_HOME_DIRECTORY_REQUIRED
+	UserPasswordNotRequired                UserAccountControl = 0x00000004 // USER_PASSWORD_NOT_REQUIRED
+	UserTempDuplicateAccount               UserAccountControl = 0x00000008 // USER_TEMP_DUPLICATE_ACCOUNT
+	UserNormalAccount                      UserAccountControl = 0x00000010 // USER_NORMAL_ACCOUNT
+	UserMNSLogonAccount                    UserAccountControl = 0x00000020 // USER_MNS_LOGON_ACCOUNT
+	UserInterdomainTrustAccount            UserAccountControl = 0x00000040 // USER_INTERDOMAIN_TRUST_ACCOUNT
+	UserWorkstationTrustAccount            UserAccountControl = 0x00000080 // USER_WORKSTATION_TRUST_ACCOUNT
+	UserServerTrustAccount                 UserAccountControl = 0x00000100 // USER_SERVER_TRUST_ACCOUNT
+	UserDontExpirePassword                 UserAccountControl = 0x00000200 // USER_DONT_EXPIRE_PASSWORD
+	UserAccountAutoLocked                  UserAccountControl = 0x00000400 // USER_ACCOUNT_AUTO_LOCKED
+	UserEncryptedTextPasswordAllowed       UserAccountControl = 0x00000800 // USER_ENCRYPTED_TEXT_PASSWORD_ALLOWED
+	UserSmartcardRequired                  UserAccountControl = 0x00001000 // USER_SMARTCARD_REQUIRED
+	UserTrustedForDelegation               UserAccountControl = 0x00002000 // USER_TRUSTED_FOR_DELEGATION
+	UserNotDelegated                       UserAccountControl = 0x00004000 // USER_NOT_DELEGATED
+	UserUseDESKeyOnly                      UserAccountControl = 0x00008000 // USER_USE_DES_KEY_ONLY
+	UserDontRequirePreauth                 UserAccountControl = 0x00010000 // USER_DONT_REQUIRE_PREAUTH
+	UserPasswordExpired                    UserAccountControl = 0x00020000 // USER_PASSWORD_EXPIRED
+	UserTrustedToAuthenticateForDelegation UserAccountControl = 0x00040000 // USER_TRUSTED_TO_AUTHENTICATE_FOR_DELEGATION
+	UserNoAuthDataRequired                 UserAccountControl = 0x00080000 // USER_NO_AUTH_DATA_REQUIRED
+	UserPartialSecretsAccount              UserAccountControl = 0x00100000 // USER_PARTIAL_SECRETS_ACCOUNT
+	UserUseAESKeys                         UserAccountControl = 0x00200000 // USER_USE_AES_KEYS
+)
+
+var userAccountControlNames = []flagName{
+	{uint32(UserAccountDisabled), "USER_ACCOUNT_DISABLED"},
+	{uint32(UserHomeDirectoryRequired), "USER_HOME_DIRECTORY_REQUIRED"},
+	{uint32(UserPasswordNotRequired), "USER_PASSWORD_NOT_REQUIRED"},
+	{uint32(UserTempDuplicateAccount), "USER_TEMP_DUPLICATE_ACCOUNT"},
+	{uint32(UserNormalAccount), "USER_NORMAL_ACCOUNT"},
+	{uint32(UserMNSLogonAccount), "USER_MNS_LOGON_ACCOUNT"},
+	{uint32(UserInterdomainTrustAccount), "USER_INTERDOMAIN_TRUST_ACCOUNT"},
+	{uint32(UserWorkstationTrustAccount), "USER_WORKSTATION_TRUST_ACCOUNT"},
+	{uint32(UserServerTrustAccount), "USER_SERVER_TRUST_ACCOUNT"},
+	{uint32(UserDontExpirePassword), "USER_DONT_EXPIRE_PASSWORD"},
+	{uint32(UserAccountAutoLocked), "USER_ACCOUNT_AUTO_LOCKED"},
+	{uint32(UserEncryptedTextPasswordAllowed), "USER_ENCRYPTED_TEXT_PASSWORD_ALLOWED"},
+	{uint32(UserSmartcardRequired), "USER_SMARTCARD_REQUIRED"},
+	{uint32(UserTrustedForDelegation), "USER_TRUSTED_FOR_DELEGATION"},
+	{uint32(UserNotDelegated), "USER_NOT_DELEGATED"},
+	{uint32(UserUseDESKeyOnly), "USER_USE_DES_KEY_ONLY"},
+	{uint32(UserDontRequirePreauth), "USER_DONT_REQUIRE_PREAUTH"},
+	{uint32(UserPasswordExpired), "USER_PASSWORD_EXPIRED"},
+	{uint32(UserTrustedToAuthenticateForDelegation), "USER_TRUSTED_TO_AUTHENTICATE_FOR_DELEGATION"},
+	{uint32(UserNoAuthDataRequired), "USER_NO_AUTH_DATA_REQUIRED"},
+	{uint32(UserPartialSecretsAccount), "USER_PARTIAL_SECRETS_ACCOUNT"},
+	{uint32(UserUseAESKeys), "USER_USE_AES_KEYS"},
+}
+
+// Has returns true if all of the flags provided are set.
+func (u UserAccountControl) Has(flags UserAccountControl) bool {
+	return u&flags == flags
+}
+
+// String returns the names of the flags that are set separated by "|", for example
+// "USER_NORMAL_ACCOUNT|USER_DONT_EXPIRE_PASSWORD". Bits without a name are rendered in hex.
+func (u UserAccountControl) String() string {
+	return flagString(uint32(u), userAccountControlNames)
+}
+
+// flagName is the name of a flag, which may be more than one bit.
+type flagName struct {
+	flag uint32
+	name string
+}
+
+// flagString returns the names of the flags set in v separated by "|", in the order of the names provided, followed
+// by any remaining bits in hex. "0" is returned if no bits are set.
+func flagString(v uint32, names []flagName) string {
+	if v == 0 {
+		return "0"
+	}
+	var s []string
+	for _, n := range names {
+		if v&n.flag == n.flag {
+			s = append(s, n.name)
+			v &^= n.flag
+		}
+	}
+	if v != 0 {
+		s = append(s, fmt.Sprintf("0x%x", v))
+	}
+	return strings.Join(s, "|")
+}
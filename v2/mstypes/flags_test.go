@@ -0,0 +1,64 @@
+package mstypes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserFlags(t *testing.T) {
+	var tests = []struct {
+		Flags    UserFlags
+		Has      UserFlags
+		Expected bool
+		String   string
+	}{
+		{0, UserFlagExtraSIDs, false, "0"},
+		{32, UserFlagExtraSIDs, true, "LOGON_EXTRA_SIDS"},
+		{544, UserFlagExtraSIDs | UserFlagResourceGroups, true, "LOGON_EXTRA_SIDS|LOGON_RESOURCE_GROUPS"},
+		{32, UserFlagExtraSIDs | UserFlagResourceGroups, false, "LOGON_EXTRA_SIDS"},
+		{0x00010021, UserFlagGuest, true, "LOGON_GUEST|LOGON_EXTRA_SIDS|0x10000"},
+	}
+	for i, test := range tests {
+		assert.Equal(t, test.Expected, test.Flags.Has(test.Has), "Has not as expected for test %d", i)
+		assert.Equal(t, test.String, test.Flags.String(), "String not as expected for test %d", i)
+	}
+}
+
+func TestUserAccountControl(t *testing.T) {
+	var tests = []struct {
+		UAC      UserAccountControl
+		Has      UserAccountControl
+		Expected bool
+		String   string
+	}{
+		{16, UserNormalAccount, true, "USER_NORMAL_ACCOUNT"},
+		{528, UserDontExpirePassword, true, "USER_NORMAL_ACCOUNT|USER_DONT_EXPIRE_PASSWORD"},
+		{528, UserAccountDisabled, false, "USER_NORMAL_ACCOUNT|USER_DONT_EXPIRE_PASSWORD"},
+		{0x1011, UserAccountDisabled | UserSmartcardRequired, true, "USER_ACCOUNT_DISABLED|USER_NORMAL_ACCOUNT|USER_SMARTCARD_REQUIRED"},
+		{0x80000000, UserNormalAccount, false, "0x80000000"},
+	}
+	for i, test := range tests {
+		assert.Equal(t, test.Expected, test.UAC.Has(test.Has), "Has not as expected for test %d", i)
+		assert.Equal(t, test.String, test.UAC.String(), "String not as expected for test %d", i)
+	}
+}
+
+func TestGroupAttributes(t *testing.T) {
+	var a GroupAttributes
+	a.SetFlag(SEGroupMandatory)
+	a.SetFlag(SEGroupEnabledByDefault | SEGroupEnabled)
+	assert.Equal(t, GroupAttributes(7), a, "attributes not as expected")
+	assert.True(t, a.HasFlag(SEGroupEnabled), "SEGroupEnabled should be set")
+	assert.False(t, a.HasFlag(SEGroupUseForDenyOnly), "SEGroupUseForDenyOnly should not be set")
+	assert.Equal(t, "SE_GROUP_MANDATORY|SE_GROUP_ENABLED_BY_DEFAULT|SE_GROUP_ENABLED", a.String(), "string not as expected")
+	a.SetFlag(SEGroupResource)
+	assert.Equal(t, GroupAttributes(536870919), a, "attributes not as expected")
+	assert.Equal(t, "SE_GROUP_MANDATORY|SE_GROUP_ENABLED_BY_DEFAULT|SE_GROUP_ENABLED|SE_GROUP_RESOURCE", a.String(), "string not as expected")
+	l := GroupAttributes(0x80000000)
+	assert.False(t, l.HasFlag(SEGroupLogonID), "SEGroupLogonID should need both of its bits")
+	assert.Equal(t, "0x80000000", l.String(), "string not as expected")
+	l.SetFlag(SEGroupLogonID)
+	assert.True(t, l.HasFlag(SEGroupLogonID), "SEGroupLogonID should be set")
+	assert.Equal(t, "SE_GROUP_LOGON_ID", l.String(), "string not as expected")
+}
@@ -5,7 +5,7 @@ package mstypes
 // The possible values for the Attributes flags are identical to those specified in KERB_SID_AND_ATTRIBUTES
 type GroupMembership struct {
 	RelativeID uint32
-	Attributes uint32
+	Attributes GroupAttributes
 }
 
 // DomainGroupMembership implements https://msdn.microsoft.com/en-us/library/hh536344.aspx
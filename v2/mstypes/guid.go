@@ -0,0 +1,73 @@
+package mstypes
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// GUID implements https://msdn.microsoft.com/en-us/library/cc230326.aspx
+type GUID struct {
+	Data1 uint32
+	Data2 uint16
+	Data3 uint16
+	Data4 [8]byte
+}
+
+// GUID byte size in the packet representation https://msdn.microsoft.com/en-us/library/cc230327.aspx
+const SizeGUID = 16
+
+// ParseGUID parses the string representation of a GUID, such as "bf967aba-0de6-11d0-a285-00aa003049e2".
+// The string may optionally be enclosed in curly braces.
+func ParseGUID(s string) (g GUID, err error) {
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "{"), "}")
+	parts := strings.Split(s, "-")
+	if len(parts) != 5 || len(parts[0]) != 8 || len(parts[1]) != 4 || len(parts[2]) != 4 || len(parts[3]) != 4 || len(parts[4]) != 12 {
+		err = fmt.Errorf("invalid GUID string %q", s)
+		return
+	}
+	b, e := hex.DecodeString(strings.Join(parts, ""))
+	if e != nil {
+		err = fmt.Errorf("invalid GUID string %q: %v", s, e)
+		return
+	}
+	g.Data1 = binary.BigEndian.Uint32(b[0:4])
+	g.Data2 = binary.BigEndian.Uint16(b[4:6])
+	g.Data3 = binary.BigEndian.Uint16(b[6:8])
+	copy(g.Data4[:], b[8:])
+	return
+}
+
+// GUIDFromBytes returns a GUID from its 16 byte packet representation.
+func GUIDFromBytes(b []byte) (g GUID, err error) {
+	if len(b) < SizeGUID {
+		err = fmt.Errorf("too few bytes for a GUID: %d", len(b))
+		return
+	}
+	g.Data1 = binary.LittleEndian.Uint32(b[0:4])
+	g.Data2 = binary.LittleEndian.Uint16(b[4:6])
+	g.Data3 = binary.LittleEndian.Uint16(b[6:8])
+	copy(g.Data4[:], b[8:SizeGUID])
+	return
+}
+
+// Bytes returns the 16 byte packet representation of the GUID.
+func (g GUID) Bytes() []byte {
+	b := make([]byte, SizeGUID, SizeGUID)
+	binary.LittleEndian.PutUint32(b[0:4], g.Data1)
+	binary.LittleEndian.PutUint16(b[4:6], g.Data2)
+	binary.LittleEndian.PutUint16(b[6:8], g.Data3)
+	copy(b[8:], g.Data4[:])
+	return b
+}
+
+// IsZero returns true if the GUID is the nil GUID.
+func (g GUID) IsZero() bool {
+	return g == GUID{}
+}
+
+// String returns the string representation of the GUID.
+func (g GUID) String() string {
+	return fmt.Sprintf("%08x-%04x-%04x-%s-%s", g.Data1, g.Data2, g.Data3, hex.EncodeToString(g.Data4[:2]), hex.EncodeToString(g.Data4[2:]))
+}
@@ -0,0 +1,60 @@
+package mstypes
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/jcmturner/rpc/v2/ndr"
+	"github.com/stretchr/testify/assert"
+)
+
+type testGUIDStruct struct {
+	G GUID
+}
+
+func Test_GUID(t *testing.T) {
+	var tests = []struct {
+		Str        string
+		Hex        string
+		ExpectFail bool
+	}{
+		{"bf967aba-0de6-11d0-a285-00aa003049e2", "ba7a96bfe60dd011a28500aa003049e2", false},
+		{"{00299570-246d-11d0-a768-00aa006e0529}", "70952900 6d24d011a76800aa006e0529", false},
+		{"00000000-0000-0000-0000-000000000000", "00000000000000000000000000000000", false},
+		{"bf967aba-0de6-11d0-a285-00aa003049e", "", true},
+		{"bf967aba0de611d0a28500aa003049e2", "", true},
+		{"bf967aba-0de6-11d0-a285-00aa003049zz", "", true},
+	}
+	for i, test := range tests {
+		g, err := ParseGUID(test.Str)
+		if test.ExpectFail {
+			assert.Error(t, err, "expected error for test %d", i+1)
+			continue
+		}
+		if err != nil {
+			t.Fatalf("test %d: %v", i+1, err)
+		}
+		h := hex.EncodeToString(g.Bytes())
+		assert.Equal(t, bytes.Replace([]byte(test.Hex), []byte(" "), nil, -1), []byte(h), "GUID bytes not as expected for test %d", i+1)
+		g2, err := GUIDFromBytes(g.Bytes())
+		if err != nil {
+			t.Fatalf("test %d: %v", i+1, err)
+		}
+		assert.Equal(t, g, g2, "GUID from bytes not as expected for test %d", i+1)
+		assert.Equal(t, g.IsZero(), i == 2, "GUID IsZero not as expected for test %d", i+1)
+	}
+	_, err := GUIDFromBytes([]byte{1, 2, 3})
+	assert.Error(t, err, "expected error for too few bytes")
+}
+
+func Test_GUIDDecode(t *testing.T) {
+	a := new(testGUIDStruct)
+	b, _ := hex.DecodeString(TestNDRHeader + "ba7a96bfe60dd011a28500aa003049e2")
+	dec := ndr.NewDecoder(bytes.NewReader(b))
+	err := dec.Decode(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "bf967aba-0de6-11d0-a285-00aa003049e2", a.G.String(), "GUID not as expected")
+}
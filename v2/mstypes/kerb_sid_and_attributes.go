@@ -1,23 +1,53 @@
 package mstypes
 
-// Attributes of a security group membership and can be combined by using the bitwise OR operation.
-// They are used by an access check mechanism to specify whether the membership is to be used in an access check decision.
+// GroupAttributes are the attributes of a security group membership and can be combined by using the bitwise OR
+// operation. They are used by an access check mechanism to specify whether the membership is to be used in an access
+// check decision. See MS-PAC section 2.2.1 and MS-DTYP section 2.5.2.
+type GroupAttributes uint32
+
+// GroupAttributes values
 const (
-	SEGroupMandatory        = 31
-	SEGroupEnabledByDefault = 30
-	SEGroupEnabled          = 29
-	SEGroupOwner            = 28
-	SEGroupResource         = 2
-	//All other bits MUST be set to zero and MUST be  ignored on receipt.
+	SEGroupMandatory        GroupAttributes = 0x00000001 // SE_GROUP_MANDATORY
+	SEGroupEnabledByDefault GroupAttributes = 0x00000002 // SE_GROUP_ENABLED_BY_DEFAULT
+	SEGroupEnabled          GroupAttributes = 0x00000004 // SE_GROUP_ENABLED
+	SEGroupOwner            GroupAttributes = 0x00000008 // SE_GROUP_OWNER
+	SEGroupUseForDenyOnly   GroupAttributes = 0x00000010 // SE_GROUP_USE_FOR_DENY_ONLY. Not sent in a PAC but may be set on a Token to only match deny ACEs.
+	SEGroupIntegrity        GroupAttributes = 0x00000020 // SE_GROUP_INTEGRITY
+	SEGroupIntegrityEnabled GroupAttributes = 0x00000040 // SE_GROUP_INTEGRITY_ENABLED
+	SEGroupResource         GroupAttributes = 0x20000000 // SE_GROUP_RESOURCE
+	SEGroupLogonID          GroupAttributes = 0xC0000000 // SE_GROUP_LOGON_ID
 )
 
+var groupAttributeNames = []flagName{
+	{uint32(SEGroupMandatory), "SE_GROUP_MANDATORY"},
+	{uint32(SEGroupEnabledByDefault), "SE_GROUP_ENABLED_BY_DEFAULT"},
+	{uint32(SEGroupEnabled), "SE_GROUP_ENABLED"},
+	{uint32(SEGroupOwner), "SE_GROUP_OWNER"},
+	{uint32(SEGroupUseForDenyOnly), "SE_GROUP_USE_FOR_DENY_ONLY"},
+	{uint32(SEGroupIntegrity), "SE_GROUP_INTEGRITY"},
+	{uint32(SEGroupIntegrityEnabled), "SE_GROUP_INTEGRITY_ENABLED"},
+	{uint32(SEGroupResource), "SE_GROUP_RESOURCE"},
+	{uint32(SEGroupLogonID), "SE_GROUP_LOGON_ID"},
+}
+
 // KerbSidAndAttributes implements https://msdn.microsoft.com/en-us/library/cc237947.aspx
 type KerbSidAndAttributes struct {
 	SID        RPCSID `ndr:"pointer"` // A pointer to an RPC_SID structure.
-	Attributes uint32
+	Attributes GroupAttributes
+}
+
+// SetFlag sets the flags provided.
+func (a *GroupAttributes) SetFlag(f GroupAttributes) {
+	*a |= f
+}
+
+// HasFlag returns true if all of the flags provided are set.
+func (a GroupAttributes) HasFlag(f GroupAttributes) bool {
+	return a&f == f
 }
 
-// SetFlag sets a flag in a uint32 attribute value.
-func SetFlag(a *uint32, i uint) {
-	*a = *a | (1 << (31 - i))
+// String returns the names of the attributes that are set separated by "|", for example
+// "SE_GROUP_MANDATORY|SE_GROUP_ENABLED_BY_DEFAULT|SE_GROUP_ENABLED". Bits without a name are rendered in hex.
+func (a GroupAttributes) String() string {
+	return flagString(uint32(a), groupAttributeNames)
 }
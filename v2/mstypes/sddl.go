@@ -0,0 +1,534 @@
+package mstypes
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SDDL is specified in https://msdn.microsoft.com/en-us/library/cc230374.aspx
+// Aliases for SIDs relative to a domain (such as DA for Domain Admins) and conditional expressions of callback ACEs
+// are not supported as they cannot be resolved without further context.
+
+var sddlSIDAliases = map[string]string{
+	"AA": SIDBuiltinAccessControlAssistanceOps,
+	"AC": SIDAllAppPackages,
+	"AN": SIDAnonymous,
+	"AO": SIDBuiltinAccountOperators,
+	"AS": SIDAuthenticationAuthorityAsserted,
+	"AU": SIDAuthenticatedUsers,
+	"BA": SIDBuiltinAdministrators,
+	"BG": SIDBuiltinGuests,
+	"BO": SIDBuiltinBackupOperators,
+	"BU": SIDBuiltinUsers,
+	"CD": SIDBuiltinCertificateServiceDCOMAccess,
+	"CG": SIDCreatorGroup,
+	"CO": SIDCreatorOwner,
+	"CY": SIDBuiltinCryptographicOperators,
+	"ED": SIDEnterpriseDomainControllers,
+	"ER": SIDBuiltinEventLogReaders,
+	"ES": SIDBuiltinRDSEndpointServers,
+	"HA": SIDBuiltinHyperVAdministrators,
+	"HI": SIDIntegrityHigh,
+	"IS": SIDBuiltinIISUsers,
+	"IU": SIDInteractive,
+	"LS": SIDLocalService,
+	"LU": SIDBuiltinPerformanceLogUsers,
+	"LW": SIDIntegrityLow,
+	"ME": SIDIntegrityMedium,
+	"MP": SIDIntegrityMediumPlus,
+	"MS": SIDBuiltinRDSManagementServers,
+	"MU": SIDBuiltinPerformanceMonitorUsers,
+	"NO": SIDBuiltinNetworkConfigurationOperators,
+	"NS": SIDNetworkService,
+	"NU": SIDNetwork,
+	"OW": SIDOwnerRights,
+	"PO": SIDBuiltinPrintOperators,
+	"PS": SIDPrincipalSelf,
+	"PU": SIDBuiltinPowerUsers,
+	"RA": SIDBuiltinRDSRemoteAccessServers,
+	"RC": SIDRestrictedCode,
+	"RD": SIDBuiltinRemoteDesktopUsers,
+	"RE": SIDBuiltinReplicator,
+	"RM": SIDBuiltinRemoteManagementUsers,
+	"RU": SIDBuiltinPreWindows2000CompatibleAccess,
+	"SI": SIDIntegritySystem,
+	"SO": SIDBuiltinServerOperators,
+	"SS": SIDServiceAsserted,
+	"SU": SIDService,
+	"SY": SIDLocalSystem,
+	"WD": SIDEveryone,
+	"WR": SIDWriteRestrictedCode,
+}
+
+var sddlSIDByString = func() map[string]string {
+	m := make(map[string]string)
+	for k, v := range sddlSIDAliases {
+		m[v] = k
+	}
+	return m
+}()
+
+var sddlACETypes = []struct {
+	code string
+	t    uint8
+}{
+	{"A", ACETypeAccessAllowed},
+	{"D", ACETypeAccessDenied},
+	{"AU", ACETypeSystemAudit},
+	{"AL", ACETypeSystemAlarm},
+	{"OA", ACETypeAccessAllowedObject},
+	{"OD", ACETypeAccessDeniedObject},
+	{"OU", ACETypeSystemAuditObject},
+	{"OL", ACETypeSystemAlarmObject},
+	{"XA", ACETypeAccessAllowedCallback},
+	{"XD", ACETypeAccessDeniedCallback},
+	{"ZA", ACETypeAccessAllowedCallbackObject},
+	{"XU", ACETypeSystemAuditCallback},
+	{"ML", ACETypeSystemMandatoryLabel},
+	{"SP", ACETypeSystemScopedPolicyID},
+}
+
+var sddlACEFlags = []struct {
+	code string
+	f    uint8
+}{
+	{"OI", ACEFlagObjectInherit},
+	{"CI", ACEFlagContainerInherit},
+	{"NP", ACEFlagNoPropagateInherit},
+	{"IO", ACEFlagInheritOnly},
+	{"ID", ACEFlagInherited},
+	{"SA", ACEFlagSuccessfulAccess},
+	{"FA", ACEFlagFailedAccess},
+}
+
+// Composite rights are matched exactly before the mask is broken down into individual rights.
+var sddlCompositeRights = []struct {
+	code string
+	m    uint32
+}{
+	{"FA", 0x001F01FF},
+	{"FR", 0x00120089},
+	{"FW", 0x00120116},
+	{"FX", 0x001200A0},
+	{"KA", 0x000F003F},
+	{"KR", 0x00020019},
+	{"KW", 0x00020006},
+}
+
+var sddlRights = []struct {
+	code string
+	m    uint32
+}{
+	{"GA", AccessGenericAll},
+	{"GR", AccessGenericRead},
+	{"GW", AccessGenericWrite},
+	{"GX", AccessGenericExecute},
+	{"CC", AccessDSCreateChild},
+	{"DC", AccessDSDeleteChild},
+	{"LC", AccessDSListChildren},
+	{"SW", AccessDSSelf},
+	{"RP", AccessDSReadProperty},
+	{"WP", AccessDSWriteProperty},
+	{"DT", AccessDSDeleteTree},
+	{"LO", AccessDSListObject},
+	{"CR", AccessDSControlAccess},
+	{"SD", AccessDelete},
+	{"RC", AccessReadControl},
+	{"WD", AccessWriteDACL},
+	{"WO", AccessWriteOwner},
+}
+
+var sddlMandatoryRights = []struct {
+	code string
+	m    uint32
+}{
+	{"NW", AccessMandatoryNoWriteUp},
+	{"NR", AccessMandatoryNoReadUp},
+	{"NX", AccessMandatoryNoExecuteUp},
+}
+
+// rights codes only valid when parsing
+var sddlParseOnlyRights = map[string]uint32{
+	"KX": 0x00020019,
+}
+
+// SDDL returns the Security Descriptor Definition Language string representation of the security descriptor.
+func (sd *SecurityDescriptor) SDDL() (string, error) {
+	var strb strings.Builder
+	if sd.OwnerSID != nil {
+		strb.WriteString("O:")
+		strb.WriteString(sddlSID(sd.OwnerSID))
+	}
+	if sd.GroupSID != nil {
+		strb.WriteString("G:")
+		strb.WriteString(sddlSID(sd.GroupSID))
+	}
+	if sd.HasControl(SEDACLPresent) {
+		strb.WriteString("D:")
+		s, err := sddlACL(sd.DACL, sd.HasControl(SEDACLProtected), sd.HasControl(SEDACLAutoInherited), sd.HasControl(SEDACLAutoInheritReq))
+		if err != nil {
+			return "", fmt.Errorf("could not format DACL: %v", err)
+		}
+		strb.WriteString(s)
+	}
+	if sd.HasControl(SESACLPresent) {
+		strb.WriteString("S:")
+		s, err := sddlACL(sd.SACL, sd.HasControl(SESACLProtected), sd.HasControl(SESACLAutoInherited), sd.HasControl(SESACLAutoInheritReq))
+		if err != nil {
+			return "", fmt.Errorf("could not format SACL: %v", err)
+		}
+		strb.WriteString(s)
+	}
+	return strb.String(), nil
+}
+
+func sddlSID(s *RPCSID) string {
+	str := s.String()
+	if a, ok := sddlSIDByString[str]; ok {
+		return a
+	}
+	return str
+}
+
+func sddlACL(acl *ACL, protected, autoInherited, autoInheritReq bool) (string, error) {
+	var strb strings.Builder
+	if protected {
+		strb.WriteString("P")
+	}
+	if autoInheritReq {
+		strb.WriteString("AR")
+	}
+	if autoInherited {
+		strb.WriteString("AI")
+	}
+	if acl == nil {
+		strb.WriteString("NO_ACCESS_CONTROL")
+		return strb.String(), nil
+	}
+	for i := range acl.ACEs {
+		s, err := acl.ACEs[i].SDDL()
+		if err != nil {
+			return "", fmt.Errorf("ACE %d: %v", i, err)
+		}
+		strb.WriteString(s)
+	}
+	return strb.String(), nil
+}
+
+// SDDL returns the Security Descriptor Definition Language string representation of the ACE.
+func (a *ACE) SDDL() (string, error) {
+	var t string
+	for _, at := range sddlACETypes {
+		if at.t == a.Header.AceType {
+			t = at.code
+			break
+		}
+	}
+	if t == "" {
+		return "", fmt.Errorf("ACE type %d has no SDDL representation", a.Header.AceType)
+	}
+	if len(a.ApplicationData) > 0 {
+		return "", errors.New("ACEs with application data are not supported")
+	}
+	var flags strings.Builder
+	f := a.Header.AceFlags
+	for _, af := range sddlACEFlags {
+		if f&af.f != 0 {
+			flags.WriteString(af.code)
+			f &^= af.f
+		}
+	}
+	if f != 0 {
+		return "", fmt.Errorf("ACE flags 0x%02x have no SDDL representation", f)
+	}
+	var obj, inhObj string
+	if a.IsObjectACE() {
+		if a.Flags&ACEObjectTypePresent != 0 {
+			obj = a.ObjectType.String()
+		}
+		if a.Flags&ACEInheritedObjectTypePresent != 0 {
+			inhObj = a.InheritedObjectType.String()
+		}
+	}
+	return fmt.Sprintf("(%s;%s;%s;%s;%s;%s)", t, flags.String(), sddlRightsString(a.Mask, a.Header.AceType), obj, inhObj, sddlSID(&a.SID)), nil
+}
+
+func sddlRightsString(m uint32, aceType uint8) string {
+	rights := sddlRights
+	if aceType == ACETypeSystemMandatoryLabel {
+		rights = sddlMandatoryRights
+	} else {
+		for _, r := range sddlCompositeRights {
+			if r.m == m {
+				return r.code
+			}
+		}
+	}
+	var strb strings.Builder
+	r := m
+	for _, ar := range rights {
+		if r&ar.m != 0 {
+			strb.WriteString(ar.code)
+			r &^= ar.m
+		}
+	}
+	if r != 0 || m == 0 {
+		return fmt.Sprintf("0x%x", m)
+	}
+	return strb.String()
+}
+
+// ParseSDDL parses a Security Descriptor Definition Language string into a self-relative SecurityDescriptor.
+func ParseSDDL(s string) (SecurityDescriptor, error) {
+	sd := SecurityDescriptor{
+		Revision: SecurityDescriptorRev1,
+		Control:  SESelfRelative,
+	}
+	s = strings.TrimSpace(s)
+	for len(s) > 0 {
+		if len(s) < 2 || s[1] != ':' {
+			return SecurityDescriptor{}, fmt.Errorf("invalid SDDL component at %q", s)
+		}
+		c := s[0]
+		var v string
+		v, s = splitSDDLComponent(s[2:])
+		switch c {
+		case 'O':
+			sid, err := parseSDDLSID(v)
+			if err != nil {
+				return SecurityDescriptor{}, fmt.Errorf("invalid SDDL owner: %v", err)
+			}
+			sd.OwnerSID = &sid
+		case 'G':
+			sid, err := parseSDDLSID(v)
+			if err != nil {
+				return SecurityDescriptor{}, fmt.Errorf("invalid SDDL group: %v", err)
+			}
+			sd.GroupSID = &sid
+		case 'D':
+			acl, p, ai, ar, err := parseSDDLACL(v)
+			if err != nil {
+				return SecurityDescriptor{}, fmt.Errorf("invalid SDDL DACL: %v", err)
+			}
+			sd.DACL = acl
+			sd.Control |= SEDACLPresent | sddlACLControl(p, SEDACLProtected) | sddlACLControl(ai, SEDACLAutoInherited) | sddlACLControl(ar, SEDACLAutoInheritReq)
+		case 'S':
+			acl, p, ai, ar, err := parseSDDLACL(v)
+			if err != nil {
+				return SecurityDescriptor{}, fmt.Errorf("invalid SDDL SACL: %v", err)
+			}
+			sd.SACL = acl
+			sd.Control |= SESACLPresent | sddlACLControl(p, SESACLProtected) | sddlACLControl(ai, SESACLAutoInherited) | sddlACLControl(ar, SESACLAutoInheritReq)
+		default:
+			return SecurityDescriptor{}, fmt.Errorf("invalid SDDL component %q", string(c))
+		}
+	}
+	// Round trip through the binary form so that sizes, counts and offsets are populated.
+	return SecurityDescriptorFromBytes(sd.Bytes())
+}
+
+func sddlACLControl(set bool, c uint16) uint16 {
+	if set {
+		return c
+	}
+	return 0
+}
+
+// splitSDDLComponent returns the value of the current component and the remainder of the string starting at the next
+// component.
+func splitSDDLComponent(s string) (string, string) {
+	var depth int
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case 'O', 'G', 'D', 'S':
+			if depth == 0 && i+1 < len(s) && s[i+1] == ':' {
+				return s[:i], s[i:]
+			}
+		}
+	}
+	return s, ""
+}
+
+func parseSDDLSID(s string) (RPCSID, error) {
+	if v, ok := sddlSIDAliases[s]; ok {
+		s = v
+	}
+	return ParseSID(s)
+}
+
+func parseSDDLACL(s string) (acl *ACL, protected, autoInherited, autoInheritReq bool, err error) {
+	i := strings.Index(s, "(")
+	if i < 0 {
+		i = len(s)
+	}
+	flags := s[:i]
+	s = s[i:]
+	null := false
+	for len(flags) > 0 {
+		switch {
+		case strings.HasPrefix(flags, "NO_ACCESS_CONTROL"):
+			null = true
+			flags = flags[len("NO_ACCESS_CONTROL"):]
+		case strings.HasPrefix(flags, "P"):
+			protected = true
+			flags = flags[1:]
+		case strings.HasPrefix(flags, "AI"):
+			autoInherited = true
+			flags = flags[2:]
+		case strings.HasPrefix(flags, "AR"):
+			autoInheritReq = true
+			flags = flags[2:]
+		default:
+			err = fmt.Errorf("invalid ACL flags %q", flags)
+			return
+		}
+	}
+	if null {
+		if len(s) > 0 {
+			err = errors.New("ACEs present with NO_ACCESS_CONTROL")
+		}
+		return
+	}
+	acl = &ACL{AclRevision: ACLRevision}
+	for len(s) > 0 {
+		if s[0] != '(' {
+			err = fmt.Errorf("invalid ACE string at %q", s)
+			return
+		}
+		end := strings.Index(s, ")")
+		if end < 0 {
+			err = fmt.Errorf("unterminated ACE string %q", s)
+			return
+		}
+		var a ACE
+		a, err = parseSDDLACE(s[1:end])
+		if err != nil {
+			err = fmt.Errorf("ACE %d: %v", len(acl.ACEs), err)
+			return
+		}
+		if a.IsObjectACE() {
+			acl.AclRevision = ACLRevisionDS
+		}
+		acl.ACEs = append(acl.ACEs, a)
+		s = s[end+1:]
+	}
+	return
+}
+
+func parseSDDLACE(s string) (a ACE, err error) {
+	parts := strings.Split(s, ";")
+	if len(parts) != 6 {
+		err = fmt.Errorf("invalid ACE string %q: expected 6 fields", s)
+		return
+	}
+	found := false
+	for _, at := range sddlACETypes {
+		if at.code == parts[0] {
+			a.Header.AceType = at.t
+			found = true
+			break
+		}
+	}
+	if !found {
+		err = fmt.Errorf("invalid ACE type %q", parts[0])
+		return
+	}
+	f := parts[1]
+	for len(f) > 0 {
+		found = false
+		for _, af := range sddlACEFlags {
+			if strings.HasPrefix(f, af.code) {
+				a.Header.AceFlags |= af.f
+				f = f[len(af.code):]
+				found = true
+				break
+			}
+		}
+		if !found {
+			err = fmt.Errorf("invalid ACE flags %q", parts[1])
+			return
+		}
+	}
+	a.Mask, err = parseSDDLRights(parts[2])
+	if err != nil {
+		return
+	}
+	if parts[3] != "" || parts[4] != "" {
+		if !a.IsObjectACE() {
+			err = fmt.Errorf("object type GUIDs provided for non-object ACE type %q", parts[0])
+			return
+		}
+		if parts[3] != "" {
+			a.ObjectType, err = ParseGUID(parts[3])
+			if err != nil {
+				return
+			}
+			a.Flags |= ACEObjectTypePresent
+		}
+		if parts[4] != "" {
+			a.InheritedObjectType, err = ParseGUID(parts[4])
+			if err != nil {
+				return
+			}
+			a.Flags |= ACEInheritedObjectTypePresent
+		}
+	}
+	a.SID, err = parseSDDLSID(parts[5])
+	if err != nil {
+		return
+	}
+	a.Header.AceSize = uint16(len(a.Bytes()))
+	return
+}
+
+func parseSDDLRights(s string) (uint32, error) {
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		m, err := strconv.ParseUint(s[2:], 16, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid access mask %q: %v", s, err)
+		}
+		return uint32(m), nil
+	}
+	if m, err := strconv.ParseUint(s, 10, 32); err == nil {
+		return uint32(m), nil
+	}
+	if len(s)%2 != 0 {
+		return 0, fmt.Errorf("invalid access rights %q", s)
+	}
+	var m uint32
+	for i := 0; i < len(s); i += 2 {
+		c := s[i : i+2]
+		if v, ok := sddlRightsCode(c); ok {
+			m |= v
+			continue
+		}
+		return 0, fmt.Errorf("invalid access right %q", c)
+	}
+	return m, nil
+}
+
+func sddlRightsCode(c string) (uint32, bool) {
+	for _, r := range sddlCompositeRights {
+		if r.code == c {
+			return r.m, true
+		}
+	}
+	for _, r := range sddlRights {
+		if r.code == c {
+			return r.m, true
+		}
+	}
+	for _, r := range sddlMandatoryRights {
+		if r.code == c {
+			return r.m, true
+		}
+	}
+	v, ok := sddlParseOnlyRights[c]
+	return v, ok
+}
@@ -0,0 +1,89 @@
+package mstypes
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SDDL(t *testing.T) {
+	b, _ := hex.DecodeString(testSecurityDescriptor)
+	sd, err := SecurityDescriptorFromBytes(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := sd.SDDL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "O:BAG:SYD:(A;;FA;;;SY)(A;OICI;0x1200a9;;;BU)", s, "SDDL not as expected")
+	sd2, err := ParseSDDL(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, testSecurityDescriptor, hex.EncodeToString(sd2.Bytes()), "security descriptor parsed from SDDL not as expected")
+	assert.Equal(t, sd, sd2, "security descriptor parsed from SDDL not equal to that parsed from bytes")
+}
+
+func Test_SDDLRoundTrip(t *testing.T) {
+	var tests = []struct {
+		SDDL     string
+		Expected string
+	}{
+		{"O:BAG:SYD:(A;;FA;;;SY)(A;OICI;0x1200a9;;;BU)", ""},
+		{"O:S-1-5-21-397955417-626881126-188441444-512G:DUD:P(A;;GA;;;S-1-5-21-397955417-626881126-188441444-512)", "invalid"},
+		{"O:S-1-5-21-397955417-626881126-188441444-512G:S-1-5-21-397955417-626881126-188441444-513D:PAI(D;;WPWD;;;WD)(A;CIIO;GA;;;CO)(A;;CCDCLCSWRPWPDTLOCRSDRCWDWO;;;S-1-5-21-397955417-626881126-188441444-512)", ""},
+		{"D:(OA;;RP;bf967aba-0de6-11d0-a285-00aa003049e2;;AU)(OA;CI;CR;00299570-246d-11d0-a768-00aa006e0529;bf967aba-0de6-11d0-a285-00aa003049e2;PS)", ""},
+		{"D:(OD;;CR;00299570-246d-11d0-a768-00aa006e0529;;WD)(A;;RPLCLORC;;;AU)", "D:(OD;;CR;00299570-246d-11d0-a768-00aa006e0529;;WD)(A;;LCRPLORC;;;AU)"},
+		{"D:NO_ACCESS_CONTROL", ""},
+		{"D:(A;;KR;;;BU)(A;;KX;;;BG)(A;;0x12;;;AN)", "D:(A;;KR;;;BU)(A;;KR;;;BG)(A;;DCRP;;;AN)"},
+		{"D:(A;;268435456;;;BA)", "D:(A;;GA;;;BA)"},
+		{"S:ARAI(AU;SAFA;GA;;;WD)(ML;;NWNR;;;HI)", "S:ARAI(AU;SAFA;GA;;;WD)(ML;;NWNR;;;HI)"},
+		{"O:SYD:AI(A;ID;FR;;;WD)S:P(AU;FA;FW;;;AU)", ""},
+		{"D:(A;;FA;;;BA)D:(A;;FA;;;BA)extra", "invalid"},
+		{"X:BA", "invalid"},
+		{"D:(A;;FA;;BA)", "invalid"},
+		{"D:(Q;;FA;;;BA)", "invalid"},
+		{"D:(A;QQ;FA;;;BA)", "invalid"},
+		{"D:(A;;QQ;;;BA)", "invalid"},
+		{"D:(A;;FA;bf967aba-0de6-11d0-a285-00aa003049e2;;BA)", "invalid"},
+		{"D:(A;;FA;;;S-1-5-21-)", "invalid"},
+		{"D:XX(A;;FA;;;BA)", "invalid"},
+		{"D:NO_ACCESS_CONTROL(A;;FA;;;BA)", "invalid"},
+		{"D:(A;;FA;;;BA", "invalid"},
+	}
+	for i, test := range tests {
+		sd, err := ParseSDDL(test.SDDL)
+		if test.Expected == "invalid" {
+			assert.Error(t, err, "expected error parsing SDDL for test %d", i+1)
+			continue
+		}
+		if err != nil {
+			t.Fatalf("test %d: %v", i+1, err)
+		}
+		sd2, err := SecurityDescriptorFromBytes(sd.Bytes())
+		if err != nil {
+			t.Fatalf("test %d: %v", i+1, err)
+		}
+		s, err := sd2.SDDL()
+		if err != nil {
+			t.Fatalf("test %d: %v", i+1, err)
+		}
+		exp := test.Expected
+		if exp == "" {
+			exp = test.SDDL
+		}
+		assert.Equal(t, exp, s, "SDDL not as expected for test %d", i+1)
+	}
+}
+
+func Test_SDDLNullDACL(t *testing.T) {
+	sd, err := ParseSDDL("O:BAD:NO_ACCESS_CONTROL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, sd.HasControl(SEDACLPresent), "DACL present control flag should be set")
+	assert.Nil(t, sd.DACL, "DACL should be nil for a NULL DACL")
+	assert.Equal(t, uint32(0), sd.OffsetDacl, "DACL offset should be zero for a NULL DACL")
+}
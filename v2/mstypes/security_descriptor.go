@@ -0,0 +1,180 @@
+package mstypes
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Security descriptor control flags https://msdn.microsoft.com/en-us/library/cc230366.aspx
+const (
+	SEOwnerDefaulted       uint16 = 0x0001
+	SEGroupDefaulted       uint16 = 0x0002
+	SEDACLPresent          uint16 = 0x0004
+	SEDACLDefaulted        uint16 = 0x0008
+	SESACLPresent          uint16 = 0x0010
+	SESACLDefaulted        uint16 = 0x0020
+	SEDACLTrusted          uint16 = 0x0040
+	SEServerSecurity       uint16 = 0x0080
+	SEDACLAutoInheritReq   uint16 = 0x0100
+	SESACLAutoInheritReq   uint16 = 0x0200
+	SEDACLAutoInherited    uint16 = 0x0400
+	SESACLAutoInherited    uint16 = 0x0800
+	SEDACLProtected        uint16 = 0x1000
+	SESACLProtected        uint16 = 0x2000
+	SERMControlValid       uint16 = 0x4000
+	SESelfRelative         uint16 = 0x8000
+	SecurityDescriptorRev1 uint8  = 0x01
+)
+
+const sizeSecurityDescriptorHeader = 20
+
+// SecurityDescriptor implements the self-relative form of https://msdn.microsoft.com/en-us/library/cc230366.aspx
+// The offset fields are populated when parsed from bytes and are recalculated when serialized.
+// If the SEDACLPresent control flag is set but the DACL is nil the security descriptor has a NULL DACL which grants
+// full access to everyone.
+type SecurityDescriptor struct {
+	Revision    uint8
+	Sbz1        uint8
+	Control     uint16
+	OffsetOwner uint32
+	OffsetGroup uint32
+	OffsetSacl  uint32
+	OffsetDacl  uint32
+	OwnerSID    *RPCSID
+	GroupSID    *RPCSID
+	SACL        *ACL
+	DACL        *ACL
+}
+
+// SRSecurityDescriptor implements the NDR representation of a self-relative security descriptor as a counted byte
+// buffer https://msdn.microsoft.com/en-us/library/cc245537.aspx
+type SRSecurityDescriptor struct {
+	Length                  uint32
+	SecurityDescriptorBytes []byte `ndr:"pointer,conformant"`
+}
+
+// SecurityDescriptor parses the self-relative security descriptor from the SecurityDescriptorBytes.
+func (s *SRSecurityDescriptor) SecurityDescriptor() (SecurityDescriptor, error) {
+	if len(s.SecurityDescriptorBytes) < 1 {
+		return SecurityDescriptor{}, errors.New("no bytes available for SecurityDescriptor")
+	}
+	return SecurityDescriptorFromBytes(s.SecurityDescriptorBytes)
+}
+
+// SecurityDescriptorFromBytes returns a SecurityDescriptor from its self-relative binary representation.
+func SecurityDescriptorFromBytes(b []byte) (sd SecurityDescriptor, err error) {
+	if len(b) < sizeSecurityDescriptorHeader {
+		err = errors.New("too few bytes for a security descriptor")
+		return
+	}
+	sd.Revision = b[0]
+	if sd.Revision != SecurityDescriptorRev1 {
+		err = fmt.Errorf("security descriptor revision %d not valid", sd.Revision)
+		return
+	}
+	sd.Sbz1 = b[1]
+	sd.Control = binary.LittleEndian.Uint16(b[2:4])
+	if !sd.HasControl(SESelfRelative) {
+		err = errors.New("security descriptor is not in self-relative form")
+		return
+	}
+	sd.OffsetOwner = binary.LittleEndian.Uint32(b[4:8])
+	sd.OffsetGroup = binary.LittleEndian.Uint32(b[8:12])
+	sd.OffsetSacl = binary.LittleEndian.Uint32(b[12:16])
+	sd.OffsetDacl = binary.LittleEndian.Uint32(b[16:20])
+	if sd.OffsetOwner != 0 {
+		sd.OwnerSID, err = sidAtOffset(b, sd.OffsetOwner)
+		if err != nil {
+			err = fmt.Errorf("could not read owner SID: %v", err)
+			return
+		}
+	}
+	if sd.OffsetGroup != 0 {
+		sd.GroupSID, err = sidAtOffset(b, sd.OffsetGroup)
+		if err != nil {
+			err = fmt.Errorf("could not read group SID: %v", err)
+			return
+		}
+	}
+	if sd.HasControl(SESACLPresent) && sd.OffsetSacl != 0 {
+		sd.SACL, err = aclAtOffset(b, sd.OffsetSacl)
+		if err != nil {
+			err = fmt.Errorf("could not read SACL: %v", err)
+			return
+		}
+	}
+	if sd.HasControl(SEDACLPresent) && sd.OffsetDacl != 0 {
+		sd.DACL, err = aclAtOffset(b, sd.OffsetDacl)
+		if err != nil {
+			err = fmt.Errorf("could not read DACL: %v", err)
+			return
+		}
+	}
+	return
+}
+
+func sidAtOffset(b []byte, o uint32) (*RPCSID, error) {
+	if int(o) < sizeSecurityDescriptorHeader || int(o) >= len(b) {
+		return nil, fmt.Errorf("offset %d out of range", o)
+	}
+	sid, _, err := readSIDBytes(b[o:])
+	if err != nil {
+		return nil, err
+	}
+	return &sid, nil
+}
+
+func aclAtOffset(b []byte, o uint32) (*ACL, error) {
+	if int(o) < sizeSecurityDescriptorHeader || int(o) >= len(b) {
+		return nil, fmt.Errorf("offset %d out of range", o)
+	}
+	acl, err := ACLFromBytes(b[o:])
+	if err != nil {
+		return nil, err
+	}
+	return &acl, nil
+}
+
+// HasControl returns true if the control flag provided is set on the security descriptor.
+func (sd *SecurityDescriptor) HasControl(c uint16) bool {
+	return sd.Control&c == c
+}
+
+// Bytes returns the self-relative binary representation of the security descriptor.
+// The components are laid out in the order SACL, DACL, owner, group as Windows does. The SESelfRelative control flag
+// is always set and the SESACLPresent and SEDACLPresent flags are set if the respective ACL is not nil.
+func (sd *SecurityDescriptor) Bytes() []byte {
+	b := make([]byte, sizeSecurityDescriptorHeader, sizeSecurityDescriptorHeader)
+	rev := sd.Revision
+	if rev == 0 {
+		rev = SecurityDescriptorRev1
+	}
+	ctl := sd.Control | SESelfRelative
+	var offsets [4]uint32 // owner, group, sacl, dacl
+	if sd.SACL != nil {
+		ctl |= SESACLPresent
+		offsets[2] = uint32(len(b))
+		b = append(b, sd.SACL.Bytes()...)
+	}
+	if sd.DACL != nil {
+		ctl |= SEDACLPresent
+		offsets[3] = uint32(len(b))
+		b = append(b, sd.DACL.Bytes()...)
+	}
+	if sd.OwnerSID != nil {
+		offsets[0] = uint32(len(b))
+		b = append(b, sd.OwnerSID.Bytes()...)
+	}
+	if sd.GroupSID != nil {
+		offsets[1] = uint32(len(b))
+		b = append(b, sd.GroupSID.Bytes()...)
+	}
+	b[0] = rev
+	b[1] = sd.Sbz1
+	binary.LittleEndian.PutUint16(b[2:4], ctl)
+	for i, o := range offsets {
+		binary.LittleEndian.PutUint32(b[4+4*i:8+4*i], o)
+	}
+	return b
+}
@@ -0,0 +1,150 @@
+package mstypes
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+
+	"github.com/jcmturner/rpc/v2/ndr"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testSecurityDescriptor = "0100048048000000580000000000000014000000020034000200000000001400ff011f0001010000000000051200000000031800a90012000102000000000005200000002102000001020000000000052000000020020000010100000000000512000000"
+	testObjectACE          = "050028001000000001000000ba7a96bfe60dd011a28500aa003049e201010000000000050b000000"
+	testCallbackACE        = "09001c00ff011f0001010000000000050b0000006172747801000000"
+)
+
+func Test_SecurityDescriptorFromBytes(t *testing.T) {
+	b, _ := hex.DecodeString(testSecurityDescriptor)
+	sd, err := SecurityDescriptorFromBytes(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, SecurityDescriptorRev1, sd.Revision, "revision not as expected")
+	assert.True(t, sd.HasControl(SESelfRelative), "self relative control flag not set")
+	assert.True(t, sd.HasControl(SEDACLPresent), "DACL present control flag not set")
+	assert.False(t, sd.HasControl(SESACLPresent), "SACL present control flag set")
+	assert.Equal(t, SIDBuiltinAdministrators, sd.OwnerSID.String(), "owner not as expected")
+	assert.Equal(t, SIDLocalSystem, sd.GroupSID.String(), "group not as expected")
+	assert.Nil(t, sd.SACL, "SACL should be nil")
+	if sd.DACL == nil {
+		t.Fatal("DACL is nil")
+	}
+	assert.Equal(t, ACLRevision, sd.DACL.AclRevision, "ACL revision not as expected")
+	assert.Equal(t, uint16(52), sd.DACL.AclSize, "ACL size not as expected")
+	assert.Equal(t, uint16(2), sd.DACL.AceCount, "ACE count not as expected")
+	assert.Equal(t, ACETypeAccessAllowed, sd.DACL.ACEs[0].Header.AceType, "ACE type not as expected")
+	assert.Equal(t, uint32(0x1f01ff), sd.DACL.ACEs[0].Mask, "ACE mask not as expected")
+	assert.Equal(t, SIDLocalSystem, sd.DACL.ACEs[0].SID.String(), "ACE SID not as expected")
+	assert.True(t, sd.DACL.ACEs[1].HasFlag(ACEFlagObjectInherit|ACEFlagContainerInherit), "ACE flags not as expected")
+	assert.Equal(t, uint32(0x1200a9), sd.DACL.ACEs[1].Mask, "ACE mask not as expected")
+	assert.Equal(t, SIDBuiltinUsers, sd.DACL.ACEs[1].SID.String(), "ACE SID not as expected")
+	assert.Equal(t, testSecurityDescriptor, hex.EncodeToString(sd.Bytes()), "serialized security descriptor not as expected")
+}
+
+func Test_SecurityDescriptorFromBytes_Invalid(t *testing.T) {
+	b, _ := hex.DecodeString(testSecurityDescriptor)
+	var tests = []struct {
+		Name   string
+		Mutate func([]byte) []byte
+	}{
+		{"too short", func(b []byte) []byte { return b[:10] }},
+		{"revision", func(b []byte) []byte { b[0] = 2; return b }},
+		{"not self-relative", func(b []byte) []byte { b[3] = 0; return b }},
+		{"owner offset out of range", func(b []byte) []byte { binary.LittleEndian.PutUint32(b[4:8], 0xff00); return b }},
+		{"DACL offset into header", func(b []byte) []byte { binary.LittleEndian.PutUint32(b[16:20], 4); return b }},
+		{"ACL size too large", func(b []byte) []byte { binary.LittleEndian.PutUint16(b[22:24], 0xff00); return b }},
+		{"ACE count too large", func(b []byte) []byte { binary.LittleEndian.PutUint16(b[24:26], 3); return b }},
+		{"ACE size too large", func(b []byte) []byte { binary.LittleEndian.PutUint16(b[30:32], 0xff); return b }},
+		{"truncated group", func(b []byte) []byte { return b[:len(b)-2] }},
+	}
+	for _, test := range tests {
+		c := make([]byte, len(b))
+		copy(c, b)
+		_, err := SecurityDescriptorFromBytes(test.Mutate(c))
+		assert.Error(t, err, "expected error for %s", test.Name)
+	}
+}
+
+func Test_ObjectACE(t *testing.T) {
+	b, _ := hex.DecodeString(testObjectACE)
+	a, err := ACEFromBytes(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, a.IsObjectACE(), "should be an object ACE")
+	assert.True(t, a.IsAccessAllowed(), "should be an access allowed ACE")
+	assert.False(t, a.IsAccessDenied(), "should not be an access denied ACE")
+	assert.Equal(t, AccessDSReadProperty, a.Mask, "mask not as expected")
+	assert.Equal(t, ACEObjectTypePresent, a.Flags, "object flags not as expected")
+	assert.Equal(t, "bf967aba-0de6-11d0-a285-00aa003049e2", a.ObjectType.String(), "object type not as expected")
+	assert.True(t, a.InheritedObjectType.IsZero(), "inherited object type should not be set")
+	assert.Equal(t, SIDAuthenticatedUsers, a.SID.String(), "SID not as expected")
+	assert.Equal(t, testObjectACE, hex.EncodeToString(a.Bytes()), "serialized ACE not as expected")
+
+	acl := ACL{ACEs: []ACE{a}}
+	ab := acl.Bytes()
+	assert.Equal(t, ACLRevisionDS, ab[0], "ACL revision should be raised for object ACEs")
+	acl2, err := ACLFromBytes(ab)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint16(1), acl2.AceCount, "ACE count not as expected")
+	assert.Equal(t, a, acl2.ACEs[0], "ACE not as expected after round trip")
+}
+
+func Test_CallbackACE(t *testing.T) {
+	b, _ := hex.DecodeString(testCallbackACE)
+	a, err := ACEFromBytes(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, a.IsCallbackACE(), "should be a callback ACE")
+	assert.True(t, a.IsAccessAllowed(), "should be an access allowed ACE")
+	assert.Equal(t, SIDAuthenticatedUsers, a.SID.String(), "SID not as expected")
+	assert.Equal(t, []byte{0x61, 0x72, 0x74, 0x78, 0x01, 0x00, 0x00, 0x00}, a.ApplicationData, "application data not as expected")
+	assert.Equal(t, testCallbackACE, hex.EncodeToString(a.Bytes()), "serialized ACE not as expected")
+	_, err = a.SDDL()
+	assert.Error(t, err, "expected error formatting conditional ACE as SDDL")
+}
+
+func Test_MandatoryLabelACE(t *testing.T) {
+	sid, _ := ParseSID(SIDIntegrityLow)
+	a := ACE{
+		Header: ACEHeader{AceType: ACETypeSystemMandatoryLabel},
+		Mask:   AccessMandatoryNoWriteUp,
+		SID:    sid,
+	}
+	a2, err := ACEFromBytes(a.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint16(20), a2.Header.AceSize, "ACE size not as expected")
+	assert.Equal(t, AccessMandatoryNoWriteUp, a2.Mask, "mask not as expected")
+	assert.Equal(t, SIDIntegrityLow, a2.SID.String(), "SID not as expected")
+	s, err := a2.SDDL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "(ML;;NW;;;LW)", s, "SDDL not as expected")
+}
+
+func Test_SRSecurityDescriptorDecode(t *testing.T) {
+	l := make([]byte, 4, 4)
+	binary.LittleEndian.PutUint32(l, uint32(len(testSecurityDescriptor)/2))
+	b, _ := hex.DecodeString(TestNDRHeader + hex.EncodeToString(l) + "04000200" + hex.EncodeToString(l) + testSecurityDescriptor)
+	s := new(SRSecurityDescriptor)
+	dec := ndr.NewDecoder(bytes.NewReader(b))
+	err := dec.Decode(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sd, err := s.SecurityDescriptor()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, SIDBuiltinAdministrators, sd.OwnerSID.String(), "owner not as expected")
+	assert.Equal(t, 2, len(sd.DACL.ACEs), "ACE count not as expected")
+}
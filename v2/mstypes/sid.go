@@ -3,11 +3,21 @@ package mstypes
 import (
 	"encoding/binary"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"math"
+	"strconv"
 	"strings"
 )
 
+// SID constraints from https://msdn.microsoft.com/en-us/library/gg465313.aspx
+const (
+	SIDRevision            uint8 = 1  // The only valid revision level of a SID.
+	SIDMaxSubAuthorities         = 15 // The maximum number of elements allowed in the SubAuthority array.
+	sidHeaderBytes               = 8  // Revision, SubAuthorityCount and IdentifierAuthority in the packed layout.
+	maxIdentifierAuthority       = 1<<48 - 1
+)
+
 // RPCSID implements https://msdn.microsoft.com/en-us/library/cc230364.aspx
 type RPCSID struct {
 	Revision            uint8    // An 8-bit unsigned integer that specifies the revision level of the SID. This value MUST be set to 0x01.
@@ -16,6 +26,159 @@ type RPCSID struct {
 	SubAuthority        []uint32 `ndr:"conformant"` // A variable length array of unsigned 32-bit integers that uniquely identifies a principal relative to the IdentifierAuthority. Its length is determined by SubAuthorityCount.
 }
 
+// ParseSID parses the string representation of a SID, such as "S-1-5-21-397955417-626881126-188441444-512", into
+// an RPCSID. The format is specified in https://msdn.microsoft.com/en-us/library/dd302645.aspx
+func ParseSID(s string) (sid RPCSID, err error) {
+	parts := strings.Split(s, "-")
+	if len(parts) < 3 || !strings.EqualFold(parts[0], "S") {
+		err = fmt.Errorf("invalid SID string %q", s)
+		return
+	}
+	rev, e := strconv.ParseUint(parts[1], 10, 8)
+	if e != nil || uint8(rev) != SIDRevision {
+		err = fmt.Errorf("invalid SID string %q: revision must be %d", s, SIDRevision)
+		return
+	}
+	var ia uint64
+	if len(parts[2]) > 2 && strings.EqualFold(parts[2][:2], "0x") {
+		ia, e = strconv.ParseUint(parts[2][2:], 16, 64)
+	} else {
+		ia, e = strconv.ParseUint(parts[2], 10, 64)
+	}
+	if e != nil || ia > maxIdentifierAuthority {
+		err = fmt.Errorf("invalid SID string %q: identifier authority not valid", s)
+		return
+	}
+	subs := parts[3:]
+	if len(subs) > SIDMaxSubAuthorities {
+		err = fmt.Errorf("invalid SID string %q: more than %d sub-authorities", s, SIDMaxSubAuthorities)
+		return
+	}
+	sid.Revision = SIDRevision
+	b := make([]byte, 8, 8)
+	binary.BigEndian.PutUint64(b, ia)
+	copy(sid.IdentifierAuthority[:], b[2:])
+	sid.SubAuthority = make([]uint32, len(subs), len(subs))
+	for i, sub := range subs {
+		v, e := strconv.ParseUint(sub, 10, 32)
+		if e != nil {
+			err = fmt.Errorf("invalid SID string %q: sub-authority %d not valid: %v", s, i, e)
+			return
+		}
+		sid.SubAuthority[i] = uint32(v)
+	}
+	sid.SubAuthorityCount = uint8(len(subs))
+	return
+}
+
+// SIDFromBytes returns an RPCSID from the packed binary layout of a SID, as found in an LDAP objectSid attribute.
+// The layout is specified in https://msdn.microsoft.com/en-us/library/cc230371.aspx
+func SIDFromBytes(b []byte) (RPCSID, error) {
+	sid, n, err := readSIDBytes(b)
+	if err != nil {
+		return sid, err
+	}
+	if n != len(b) {
+		return sid, fmt.Errorf("SID is %d bytes but %d bytes were provided", n, len(b))
+	}
+	return sid, nil
+}
+
+// readSIDBytes reads a packed SID from the start of the byte slice and returns it along with the number of bytes it occupies.
+func readSIDBytes(b []byte) (sid RPCSID, n int, err error) {
+	if len(b) < sidHeaderBytes {
+		err = errors.New("too few bytes for a SID")
+		return
+	}
+	sid.Revision = b[0]
+	if sid.Revision != SIDRevision {
+		err = fmt.Errorf("SID revision %d not valid", sid.Revision)
+		return
+	}
+	sid.SubAuthorityCount = b[1]
+	if sid.SubAuthorityCount > SIDMaxSubAuthorities {
+		err = fmt.Errorf("SID sub-authority count %d exceeds the maximum of %d", sid.SubAuthorityCount, SIDMaxSubAuthorities)
+		return
+	}
+	n = sidHeaderBytes + SizeUint32*int(sid.SubAuthorityCount)
+	if len(b) < n {
+		err = fmt.Errorf("too few bytes for a SID with %d sub-authorities", sid.SubAuthorityCount)
+		return
+	}
+	copy(sid.IdentifierAuthority[:], b[2:sidHeaderBytes])
+	sid.SubAuthority = make([]uint32, sid.SubAuthorityCount, sid.SubAuthorityCount)
+	for i := range sid.SubAuthority {
+		p := sidHeaderBytes + SizeUint32*i
+		sid.SubAuthority[i] = binary.LittleEndian.Uint32(b[p : p+SizeUint32])
+	}
+	return
+}
+
+// Bytes returns the packed binary layout of the SID. See https://msdn.microsoft.com/en-us/library/cc230371.aspx
+func (s *RPCSID) Bytes() []byte {
+	b := make([]byte, sidHeaderBytes+SizeUint32*len(s.SubAuthority))
+	b[0] = s.Revision
+	b[1] = uint8(len(s.SubAuthority))
+	copy(b[2:sidHeaderBytes], s.IdentifierAuthority[:])
+	for i, sub := range s.SubAuthority {
+		binary.LittleEndian.PutUint32(b[sidHeaderBytes+SizeUint32*i:], sub)
+	}
+	return b
+}
+
+// Equal returns true if the SID is the same as the SID provided.
+func (s *RPCSID) Equal(o RPCSID) bool {
+	if s.Revision != o.Revision || s.IdentifierAuthority != o.IdentifierAuthority || len(s.SubAuthority) != len(o.SubAuthority) {
+		return false
+	}
+	for i := range s.SubAuthority {
+		if s.SubAuthority[i] != o.SubAuthority[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// DomainSID returns the SID with the final sub-authority, the relative identifier (RID), removed.
+func (s *RPCSID) DomainSID() (RPCSID, error) {
+	if len(s.SubAuthority) < 1 {
+		return RPCSID{}, fmt.Errorf("SID %s has no sub-authorities", s.String())
+	}
+	d := RPCSID{
+		Revision:            s.Revision,
+		SubAuthorityCount:   uint8(len(s.SubAuthority) - 1),
+		IdentifierAuthority: s.IdentifierAuthority,
+		SubAuthority:        make([]uint32, len(s.SubAuthority)-1),
+	}
+	copy(d.SubAuthority, s.SubAuthority)
+	return d, nil
+}
+
+// RID returns the relative identifier of the SID which is the final sub-authority.
+func (s *RPCSID) RID() (uint32, error) {
+	if len(s.SubAuthority) < 1 {
+		return 0, fmt.Errorf("SID %s has no sub-authorities", s.String())
+	}
+	return s.SubAuthority[len(s.SubAuthority)-1], nil
+}
+
+// AppendRID returns a new SID made up of this SID with the relative identifier (RID) appended as a final sub-authority.
+// This SID is not modified.
+func (s *RPCSID) AppendRID(rid uint32) (RPCSID, error) {
+	if len(s.SubAuthority) >= SIDMaxSubAuthorities {
+		return RPCSID{}, fmt.Errorf("cannot append RID to SID %s as it already has the maximum of %d sub-authorities", s.String(), SIDMaxSubAuthorities)
+	}
+	r := RPCSID{
+		Revision:            s.Revision,
+		SubAuthorityCount:   uint8(len(s.SubAuthority) + 1),
+		IdentifierAuthority: s.IdentifierAuthority,
+		SubAuthority:        make([]uint32, len(s.SubAuthority), len(s.SubAuthority)+1),
+	}
+	copy(r.SubAuthority, s.SubAuthority)
+	r.SubAuthority = append(r.SubAuthority, rid)
+	return r, nil
+}
+
 // String returns the string representation of the RPC_SID.
 func (s *RPCSID) String() string {
 	var strb strings.Builder
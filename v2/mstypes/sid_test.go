@@ -49,3 +49,117 @@ func Test_RPCSIDDecode(t *testing.T) {
 
 	}
 }
+
+func Test_ParseSID(t *testing.T) {
+	var tests = []struct {
+		SID        string
+		Hex        string
+		String     string
+		ExpectFail bool
+	}{
+		{"S-1-5-21-397955417-626881126-188441444", "0104000000000005150000005951b81766725d2564633b0b", "S-1-5-21-397955417-626881126-188441444", false},
+		{"S-1-5-21-773533881-1816936887-355810188-513", "010500000000000515000000b9301b2eb7414c6c8c3b351501020000", "S-1-5-21-773533881-1816936887-355810188-513", false},
+		{"S-1-5-32-544", "01020000000000052000000020020000", "S-1-5-32-544", false},
+		{"S-1-1-0", "010100000000000100000000", "S-1-1-0", false},
+		{"S-1-5", "0100000000000005", "S-1-5", false},
+		{"S-1-18-1", "010100000000001201000000", "S-1-18-1", false},
+		{"S-1-0x0000ffffffff-1", "01010000ffffffff01000000", "S-1-4294967295-1", false},
+		{"S-1-0x123456789abc-1", "0101123456789abc01000000", "S-1-0x123456789abc-1", false},
+		{"s-1-5-18", "010100000000000512000000", "S-1-5-18", false},
+		{"S-1-5-1-2-3-4-5-6-7-8-9-10-11-12-13-14-15", "010f0000000000050100000002000000030000000400000005000000060000000700000008000000090000000a0000000b0000000c0000000d0000000e0000000f000000", "S-1-5-1-2-3-4-5-6-7-8-9-10-11-12-13-14-15", false},
+		{"S-1-5-1-2-3-4-5-6-7-8-9-10-11-12-13-14-15-16", "", "", true}, // Too many sub-authorities
+		{"S-2-5-21", "", "", true},                                     // Invalid revision
+		{"X-1-5-21", "", "", true},                                     // Invalid prefix
+		{"S-1", "", "", true},                                          // No identifier authority
+		{"S-1-5-4294967296", "", "", true},                             // Sub-authority overflows uint32
+		{"S-1-281474976710656", "", "", true},                          // Identifier authority overflows 48 bits
+		{"S-1-5-21-", "", "", true},                                    // Empty sub-authority
+	}
+
+	for i, test := range tests {
+		sid, err := ParseSID(test.SID)
+		if test.ExpectFail {
+			if err == nil {
+				t.Errorf("test %d: expected error parsing %s", i+1, test.SID)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("test %d: %v", i+1, err)
+		}
+		assert.Equal(t, test.Hex, hex.EncodeToString(sid.Bytes()), "SID bytes not as expected for test %d", i+1)
+		b, _ := hex.DecodeString(test.Hex)
+		sid2, err := SIDFromBytes(b)
+		if err != nil {
+			t.Fatalf("test %d: %v", i+1, err)
+		}
+		assert.True(t, sid.Equal(sid2), "SID from bytes not equal to parsed SID for test %d", i+1)
+		assert.Equal(t, test.String, sid2.String(), "SID string not as expected for test %d", i+1)
+	}
+}
+
+func Test_SIDFromBytes_Invalid(t *testing.T) {
+	var tests = []string{
+		"",
+		"01010000000000",             // Too short for header
+		"0101000000000005",           // Missing sub-authority
+		"020100000000000512000000",   // Invalid revision
+		"0210000000000005",           // Too many sub-authorities
+		"010100000000000512000000ff", // Trailing bytes
+	}
+	for i, test := range tests {
+		b, _ := hex.DecodeString(test)
+		_, err := SIDFromBytes(b)
+		if err == nil {
+			t.Errorf("test %d: expected error for %s", i+1, test)
+		}
+	}
+}
+
+func Test_RPCSIDDomainAndRID(t *testing.T) {
+	sid, err := ParseSID("S-1-5-21-3167651404-3865080224-2280184895-1114")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := sid.DomainSID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "S-1-5-21-3167651404-3865080224-2280184895", d.String(), "domain SID not as expected")
+	assert.Equal(t, uint8(4), d.SubAuthorityCount, "domain SID sub-authority count not as expected")
+	rid, err := sid.RID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint32(1114), rid, "RID not as expected")
+
+	s2, err := d.AppendRID(rid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, sid.Equal(s2), "SID rebuilt from domain and RID not equal to the original")
+	assert.Equal(t, uint8(5), s2.SubAuthorityCount, "sub-authority count not as expected")
+	assert.Equal(t, "S-1-5-21-3167651404-3865080224-2280184895", d.String(), "domain SID modified by AppendRID")
+	assert.False(t, sid.Equal(d), "SIDs should not be equal")
+
+	empty, _ := ParseSID("S-1-5")
+	_, err = empty.RID()
+	assert.Error(t, err, "expected error getting RID of SID without sub-authorities")
+	_, err = empty.DomainSID()
+	assert.Error(t, err, "expected error getting domain of SID without sub-authorities")
+
+	full, _ := ParseSID("S-1-5-1-2-3-4-5-6-7-8-9-10-11-12-13-14-15")
+	_, err = full.AppendRID(16)
+	assert.Error(t, err, "expected error appending RID beyond the maximum sub-authorities")
+}
+
+func Test_RPCSIDBytesMatchNDR(t *testing.T) {
+	a := new(testSIDStruct)
+	b, _ := hex.DecodeString(TestNDRHeader + "01020304" + "050000000105000000000005150000005951b81766725d2564633b0b74542f00")
+	dec := ndr.NewDecoder(bytes.NewReader(b))
+	err := dec.Decode(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "0105000000000005150000005951b81766725d2564633b0b74542f00", hex.EncodeToString(a.SID.Bytes()), "SID bytes not as expected")
+}
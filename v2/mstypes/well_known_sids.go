@@ -0,0 +1,293 @@
+package mstypes
+
+import "encoding/binary"
+
+// Well known SIDs from https://msdn.microsoft.com/en-us/library/cc980032.aspx
+const (
+	SIDNull                                  = "S-1-0-0"
+	SIDEveryone                              = "S-1-1-0"
+	SIDLocal                                 = "S-1-2-0"
+	SIDConsoleLogon                          = "S-1-2-1"
+	SIDCreatorOwner                          = "S-1-3-0"
+	SIDCreatorGroup                          = "S-1-3-1"
+	SIDOwnerServer                           = "S-1-3-2"
+	SIDGroupServer                           = "S-1-3-3"
+	SIDOwnerRights                           = "S-1-3-4"
+	SIDNTAuthority                           = "S-1-5"
+	SIDDialup                                = "S-1-5-1"
+	SIDNetwork                               = "S-1-5-2"
+	SIDBatch                                 = "S-1-5-3"
+	SIDInteractive                           = "S-1-5-4"
+	SIDService                               = "S-1-5-6"
+	SIDAnonymous                             = "S-1-5-7"
+	SIDProxy                                 = "S-1-5-8"
+	SIDEnterpriseDomainControllers           = "S-1-5-9"
+	SIDPrincipalSelf                         = "S-1-5-10"
+	SIDAuthenticatedUsers                    = "S-1-5-11"
+	SIDRestrictedCode                        = "S-1-5-12"
+	SIDTerminalServerUser                    = "S-1-5-13"
+	SIDRemoteInteractiveLogon                = "S-1-5-14"
+	SIDThisOrganization                      = "S-1-5-15"
+	SIDIUSR                                  = "S-1-5-17"
+	SIDLocalSystem                           = "S-1-5-18"
+	SIDLocalService                          = "S-1-5-19"
+	SIDNetworkService                        = "S-1-5-20"
+	SIDCompoundedAuthentication              = "S-1-5-21-0-0-0-496"
+	SIDClaimsValid                           = "S-1-5-21-0-0-0-497"
+	SIDBuiltinAdministrators                 = "S-1-5-32-544"
+	SIDBuiltinUsers                          = "S-1-5-32-545"
+	SIDBuiltinGuests                         = "S-1-5-32-546"
+	SIDBuiltinPowerUsers                     = "S-1-5-32-547"
+	SIDBuiltinAccountOperators               = "S-1-5-32-548"
+	SIDBuiltinServerOperators                = "S-1-5-32-549"
+	SIDBuiltinPrintOperators                 = "S-1-5-32-550"
+	SIDBuiltinBackupOperators                = "S-1-5-32-551"
+	SIDBuiltinReplicator                     = "S-1-5-32-552"
+	SIDBuiltinPreWindows2000CompatibleAccess = "S-1-5-32-554"
+	SIDBuiltinRemoteDesktopUsers             = "S-1-5-32-555"
+	SIDBuiltinNetworkConfigurationOperators  = "S-1-5-32-556"
+	SIDBuiltinIncomingForestTrustBuilders    = "S-1-5-32-557"
+	SIDBuiltinPerformanceMonitorUsers        = "S-1-5-32-558"
+	SIDBuiltinPerformanceLogUsers            = "S-1-5-32-559"
+	SIDBuiltinWindowsAuthorizationAccess     = "S-1-5-32-560"
+	SIDBuiltinTerminalServerLicenseServers   = "S-1-5-32-561"
+	SIDBuiltinDistributedCOMUsers            = "S-1-5-32-562"
+	SIDBuiltinIISUsers                       = "S-1-5-32-568"
+	SIDBuiltinCryptographicOperators         = "S-1-5-32-569"
+	SIDBuiltinEventLogReaders                = "S-1-5-32-573"
+	SIDBuiltinCertificateServiceDCOMAccess   = "S-1-5-32-574"
+	SIDBuiltinRDSRemoteAccessServers         = "S-1-5-32-575"
+	SIDBuiltinRDSEndpointServers             = "S-1-5-32-576"
+	SIDBuiltinRDSManagementServers           = "S-1-5-32-577"
+	SIDBuiltinHyperVAdministrators           = "S-1-5-32-578"
+	SIDBuiltinAccessControlAssistanceOps     = "S-1-5-32-579"
+	SIDBuiltinRemoteManagementUsers          = "S-1-5-32-580"
+	SIDWriteRestrictedCode                   = "S-1-5-33"
+	SIDNTLMAuthentication                    = "S-1-5-64-10"
+	SIDSChannelAuthentication                = "S-1-5-64-14"
+	SIDDigestAuthentication                  = "S-1-5-64-21"
+	SIDThisOrganizationCertificate           = "S-1-5-65-1"
+	SIDNTService                             = "S-1-5-80"
+	SIDAllServices                           = "S-1-5-80-0"
+	SIDUserModeDrivers                       = "S-1-5-84-0-0-0-0-0"
+	SIDLocalAccount                          = "S-1-5-113"
+	SIDLocalAccountAndAdministrator          = "S-1-5-114"
+	SIDOtherOrganization                     = "S-1-5-1000"
+	SIDAllAppPackages                        = "S-1-15-2-1"
+	SIDIntegrityUntrusted                    = "S-1-16-0"
+	SIDIntegrityLow                          = "S-1-16-4096"
+	SIDIntegrityMedium                       = "S-1-16-8192"
+	SIDIntegrityMediumPlus                   = "S-1-16-8448"
+	SIDIntegrityHigh                         = "S-1-16-12288"
+	SIDIntegritySystem                       = "S-1-16-16384"
+	SIDIntegrityProtectedProcess             = "S-1-16-20480"
+	SIDIntegritySecureProcess                = "S-1-16-28672"
+	SIDAuthenticationAuthorityAsserted       = "S-1-18-1"
+	SIDServiceAsserted                       = "S-1-18-2"
+	SIDFreshPublicKeyIdentity                = "S-1-18-3"
+	SIDKeyTrustIdentity                      = "S-1-18-4"
+	SIDKeyPropertyMFA                        = "S-1-18-5"
+	SIDKeyPropertyAttestation                = "S-1-18-6"
+)
+
+// Well known relative identifiers (RIDs) of accounts and groups within a domain.
+// https://msdn.microsoft.com/en-us/library/cc980032.aspx
+const (
+	RIDEnterpriseReadOnlyDomainControllers uint32 = 498
+	RIDAdministrator                       uint32 = 500
+	RIDGuest                               uint32 = 501
+	RIDKRBTGT                              uint32 = 502
+	RIDDomainAdmins                        uint32 = 512
+	RIDDomainUsers                         uint32 = 513
+	RIDDomainGuests                        uint32 = 514
+	RIDDomainComputers                     uint32 = 515
+	RIDDomainControllers                   uint32 = 516
+	RIDCertPublishers                      uint32 = 517
+	RIDSchemaAdmins                        uint32 = 518
+	RIDEnterpriseAdmins                    uint32 = 519
+	RIDGroupPolicyCreatorOwners            uint32 = 520
+	RIDReadOnlyDomainControllers           uint32 = 521
+	RIDCloneableDomainControllers          uint32 = 522
+	RIDProtectedUsers                      uint32 = 525
+	RIDKeyAdmins                           uint32 = 526
+	RIDEnterpriseKeyAdmins                 uint32 = 527
+	RIDRASServers                          uint32 = 553
+	RIDAllowedRODCPasswordReplication      uint32 = 571
+	RIDDeniedRODCPasswordReplication       uint32 = 572
+)
+
+// Sub-authority values that identify classes of SIDs issued by the NT authority.
+const (
+	sidAuthorityNT             uint64 = 5
+	sidAuthorityMandatoryLabel uint64 = 16
+	subAuthorityLogonID        uint32 = 5
+	subAuthorityNTNonUnique    uint32 = 21
+	subAuthorityBuiltin        uint32 = 32
+	subAuthorityService        uint32 = 80
+)
+
+var wellKnownSIDNames = map[string]string{
+	SIDNull:                                  "NULL SID",
+	SIDEveryone:                              "Everyone",
+	SIDLocal:                                 "LOCAL",
+	SIDConsoleLogon:                          "CONSOLE LOGON",
+	SIDCreatorOwner:                          "CREATOR OWNER",
+	SIDCreatorGroup:                          "CREATOR GROUP",
+	SIDOwnerServer:                           "CREATOR OWNER SERVER",
+	SIDGroupServer:                           "CREATOR GROUP SERVER",
+	SIDOwnerRights:                           "OWNER RIGHTS",
+	SIDNTAuthority:                           "NT AUTHORITY",
+	SIDDialup:                                `NT AUTHORITY\DIALUP`,
+	SIDNetwork:                               `NT AUTHORITY\NETWORK`,
+	SIDBatch:                                 `NT AUTHORITY\BATCH`,
+	SIDInteractive:                           `NT AUTHORITY\INTERACTIVE`,
+	SIDService:                               `NT AUTHORITY\SERVICE`,
+	SIDAnonymous:                             `NT AUTHORITY\ANONYMOUS LOGON`,
+	SIDProxy:                                 `NT AUTHORITY\PROXY`,
+	SIDEnterpriseDomainControllers:           `NT AUTHORITY\ENTERPRISE DOMAIN CONTROLLERS`,
+	SIDPrincipalSelf:                         `NT AUTHORITY\SELF`,
+	SIDAuthenticatedUsers:                    `NT AUTHORITY\Authenticated Users`,
+	SIDRestrictedCode:                        `NT AUTHORITY\RESTRICTED`,
+	SIDTerminalServerUser:                    `NT AUTHORITY\TERMINAL SERVER USER`,
+	SIDRemoteInteractiveLogon:                `NT AUTHORITY\REMOTE INTERACTIVE LOGON`,
+	SIDThisOrganization:                      `NT AUTHORITY\This Organization`,
+	SIDIUSR:                                  `NT AUTHORITY\IUSR`,
+	SIDLocalSystem:                           `NT AUTHORITY\SYSTEM`,
+	SIDLocalService:                          `NT AUTHORITY\LOCAL SERVICE`,
+	SIDNetworkService:                        `NT AUTHORITY\NETWORK SERVICE`,
+	SIDCompoundedAuthentication:              "Compounded Authentication",
+	SIDClaimsValid:                           "Claims Valid",
+	SIDBuiltinAdministrators:                 `BUILTIN\Administrators`,
+	SIDBuiltinUsers:                          `BUILTIN\Users`,
+	SIDBuiltinGuests:                         `BUILTIN\Guests`,
+	SIDBuiltinPowerUsers:                     `BUILTIN\Power Users`,
+	SIDBuiltinAccountOperators:               `BUILTIN\Account Operators`,
+	SIDBuiltinServerOperators:                `BUILTIN\Server Operators`,
+	SIDBuiltinPrintOperators:                 `BUILTIN\Print Operators`,
+	SIDBuiltinBackupOperators:                `BUILTIN\Backup Operators`,
+	SIDBuiltinReplicator:                     `BUILTIN\Replicator`,
+	SIDBuiltinPreWindows2000CompatibleAccess: `BUILTIN\Pre-Windows 2000 Compatible Access`,
+	SIDBuiltinRemoteDesktopUsers:             `BUILTIN\Remote Desktop Users`,
+	SIDBuiltinNetworkConfigurationOperators:  `BUILTIN\Network Configuration Operators`,
+	SIDBuiltinIncomingForestTrustBuilders:    `BUILTIN\Incoming Forest Trust Builders`,
+	SIDBuiltinPerformanceMonitorUsers:        `BUILTIN\Performance Monitor Users`,
+	SIDBuiltinPerformanceLogUsers:            `BUILTIN\Performance Log Users`,
+	SIDBuiltinWindowsAuthorizationAccess:     `BUILTIN\Windows Authorization Access Group`,
+	SIDBuiltinTerminalServerLicenseServers:   `BUILTIN\Terminal Server License Servers`,
+	SIDBuiltinDistributedCOMUsers:            `BUILTIN\Distributed COM Users`,
+	SIDBuiltinIISUsers:                       `BUILTIN\IIS_IUSRS`,
+	SIDBuiltinCryptographicOperators:         `BUILTIN\Cryptographic Operators`,
+	SIDBuiltinEventLogReaders:                `BUILTIN\Event Log Readers`,
+	SIDBuiltinCertificateServiceDCOMAccess:   `BUILTIN\Certificate Service DCOM Access`,
+	SIDBuiltinRDSRemoteAccessServers:         `BUILTIN\RDS Remote Access Servers`,
+	SIDBuiltinRDSEndpointServers:             `BUILTIN\RDS Endpoint Servers`,
+	SIDBuiltinRDSManagementServers:           `BUILTIN\RDS Management Servers`,
+	SIDBuiltinHyperVAdministrators:           `BUILTIN\Hyper-V Administrators`,
+	SIDBuiltinAccessControlAssistanceOps:     `BUILTIN\Access Control Assistance Operators`,
+	SIDBuiltinRemoteManagementUsers:          `BUILTIN\Remote Management Users`,
+	SIDWriteRestrictedCode:                   `NT AUTHORITY\WRITE RESTRICTED`,
+	SIDNTLMAuthentication:                    `NT AUTHORITY\NTLM Authentication`,
+	SIDSChannelAuthentication:                `NT AUTHORITY\SChannel Authentication`,
+	SIDDigestAuthentication:                  `NT AUTHORITY\Digest Authentication`,
+	SIDThisOrganizationCertificate:           `NT AUTHORITY\This Organization Certificate`,
+	SIDNTService:                             "NT SERVICE",
+	SIDAllServices:                           `NT SERVICE\ALL SERVICES`,
+	SIDUserModeDrivers:                       `NT AUTHORITY\USER MODE DRIVERS`,
+	SIDLocalAccount:                          `NT AUTHORITY\Local account`,
+	SIDLocalAccountAndAdministrator:          `NT AUTHORITY\Local account and member of Administrators group`,
+	SIDOtherOrganization:                     `NT AUTHORITY\Other Organization`,
+	SIDAllAppPackages:                        `APPLICATION PACKAGE AUTHORITY\ALL APPLICATION PACKAGES`,
+	SIDIntegrityUntrusted:                    `Mandatory Label\Untrusted Mandatory Level`,
+	SIDIntegrityLow:                          `Mandatory Label\Low Mandatory Level`,
+	SIDIntegrityMedium:                       `Mandatory Label\Medium Mandatory Level`,
+	SIDIntegrityMediumPlus:                   `Mandatory Label\Medium Plus Mandatory Level`,
+	SIDIntegrityHigh:                         `Mandatory Label\High Mandatory Level`,
+	SIDIntegritySystem:                       `Mandatory Label\System Mandatory Level`,
+	SIDIntegrityProtectedProcess:             `Mandatory Label\Protected Process Mandatory Level`,
+	SIDIntegritySecureProcess:                `Mandatory Label\Secure Process Mandatory Level`,
+	SIDAuthenticationAuthorityAsserted:       "Authentication authority asserted identity",
+	SIDServiceAsserted:                       "Service asserted identity",
+	SIDFreshPublicKeyIdentity:                "Fresh public key identity",
+	SIDKeyTrustIdentity:                      "Key trust identity",
+	SIDKeyPropertyMFA:                        "Key property multi-factor authentication",
+	SIDKeyPropertyAttestation:                "Key property attestation",
+}
+
+var wellKnownRIDNames = map[uint32]string{
+	RIDEnterpriseReadOnlyDomainControllers: "Enterprise Read-only Domain Controllers",
+	RIDAdministrator:                       "Administrator",
+	RIDGuest:                               "Guest",
+	RIDKRBTGT:                              "krbtgt",
+	RIDDomainAdmins:                        "Domain Admins",
+	RIDDomainUsers:                         "Domain Users",
+	RIDDomainGuests:                        "Domain Guests",
+	RIDDomainComputers:                     "Domain Computers",
+	RIDDomainControllers:                   "Domain Controllers",
+	RIDCertPublishers:                      "Cert Publishers",
+	RIDSchemaAdmins:                        "Schema Admins",
+	RIDEnterpriseAdmins:                    "Enterprise Admins",
+	RIDGroupPolicyCreatorOwners:            "Group Policy Creator Owners",
+	RIDReadOnlyDomainControllers:           "Read-only Domain Controllers",
+	RIDCloneableDomainControllers:          "Cloneable Domain Controllers",
+	RIDProtectedUsers:                      "Protected Users",
+	RIDKeyAdmins:                           "Key Admins",
+	RIDEnterpriseKeyAdmins:                 "Enterprise Key Admins",
+	RIDRASServers:                          "RAS and IAS Servers",
+	RIDAllowedRODCPasswordReplication:      "Allowed RODC Password Replication Group",
+	RIDDeniedRODCPasswordReplication:       "Denied RODC Password Replication Group",
+}
+
+// WellKnownName returns the symbolic name of the SID if it is a well known SID or a well known account or group
+// relative to a domain. The boolean returned indicates if the SID was found.
+func (s *RPCSID) WellKnownName() (string, bool) {
+	if n, ok := wellKnownSIDNames[s.String()]; ok {
+		return n, true
+	}
+	if s.IsLogonSession() {
+		return `NT AUTHORITY\LogonSessionId`, true
+	}
+	if s.IsDomainRelative() {
+		rid, _ := s.RID()
+		if n, ok := wellKnownRIDNames[rid]; ok {
+			return n, true
+		}
+	}
+	return "", false
+}
+
+// IsWellKnown returns true if the SID is a well known SID or a well known account or group relative to a domain.
+func (s *RPCSID) IsWellKnown() bool {
+	_, ok := s.WellKnownName()
+	return ok
+}
+
+// IsBuiltin returns true if the SID is within the BUILTIN domain (S-1-5-32).
+func (s *RPCSID) IsBuiltin() bool {
+	return s.authority() == sidAuthorityNT && len(s.SubAuthority) > 0 && s.SubAuthority[0] == subAuthorityBuiltin
+}
+
+// IsDomainRelative returns true if the SID is an account or group relative to a domain (S-1-5-21-X-Y-Z-RID).
+func (s *RPCSID) IsDomainRelative() bool {
+	return s.authority() == sidAuthorityNT && len(s.SubAuthority) == 5 && s.SubAuthority[0] == subAuthorityNTNonUnique
+}
+
+// IsLogonSession returns true if the SID identifies a logon session (S-1-5-5-X-Y).
+func (s *RPCSID) IsLogonSession() bool {
+	return s.authority() == sidAuthorityNT && len(s.SubAuthority) == 3 && s.SubAuthority[0] == subAuthorityLogonID
+}
+
+// IsServiceSID returns true if the SID identifies a service (S-1-5-80-...).
+func (s *RPCSID) IsServiceSID() bool {
+	return s.authority() == sidAuthorityNT && len(s.SubAuthority) > 1 && s.SubAuthority[0] == subAuthorityService
+}
+
+// IsIntegrityLevel returns true if the SID is a mandatory integrity level label (S-1-16-X).
+func (s *RPCSID) IsIntegrityLevel() bool {
+	return s.authority() == sidAuthorityMandatoryLabel && len(s.SubAuthority) == 1
+}
+
+// authority returns the 48 bit identifier authority as an integer.
+func (s *RPCSID) authority() uint64 {
+	b := append(make([]byte, 2, 2), s.IdentifierAuthority[:]...)
+	return binary.BigEndian.Uint64(b)
+}
@@ -0,0 +1,87 @@
+package mstypes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WellKnownName(t *testing.T) {
+	var tests = []struct {
+		SID   string
+		Name  string
+		Known bool
+	}{
+		{"S-1-1-0", "Everyone", true},
+		{"S-1-5-11", `NT AUTHORITY\Authenticated Users`, true},
+		{"S-1-5-18", `NT AUTHORITY\SYSTEM`, true},
+		{"S-1-5-32-544", `BUILTIN\Administrators`, true},
+		{"S-1-5-32-545", `BUILTIN\Users`, true},
+		{"S-1-18-1", "Authentication authority asserted identity", true},
+		{"S-1-16-12288", `Mandatory Label\High Mandatory Level`, true},
+		{"S-1-5-21-0-0-0-497", "Claims Valid", true},
+		{"S-1-5-5-0-123456", `NT AUTHORITY\LogonSessionId`, true},
+		{"S-1-5-21-397955417-626881126-188441444-512", "Domain Admins", true},
+		{"S-1-5-21-773533881-1816936887-355810188-513", "Domain Users", true},
+		{"S-1-5-21-3167651404-3865080224-2280184895-502", "krbtgt", true},
+		{"S-1-5-21-3167651404-3865080224-2280184895-1114", "", false},
+		{"S-1-5-21-3167651404-3865080224-2280184895", "", false},
+		{"S-1-5-32-999", "", false},
+	}
+	for i, test := range tests {
+		sid, err := ParseSID(test.SID)
+		if err != nil {
+			t.Fatalf("test %d: %v", i+1, err)
+		}
+		n, ok := sid.WellKnownName()
+		assert.Equal(t, test.Known, ok, "well known status not as expected for test %d", i+1)
+		assert.Equal(t, test.Known, sid.IsWellKnown(), "well known status not as expected for test %d", i+1)
+		assert.Equal(t, test.Name, n, "well known name not as expected for test %d", i+1)
+	}
+}
+
+func Test_SIDClassification(t *testing.T) {
+	var tests = []struct {
+		SID            string
+		Builtin        bool
+		DomainRelative bool
+		LogonSession   bool
+		Service        bool
+		IntegrityLevel bool
+	}{
+		{"S-1-5-32-544", true, false, false, false, false},
+		{"S-1-5-32", true, false, false, false, false},
+		{"S-1-5-21-397955417-626881126-188441444-3101812", false, true, false, false, false},
+		{"S-1-5-21-397955417-626881126-188441444", false, false, false, false, false},
+		{"S-1-5-5-0-123456", false, false, true, false, false},
+		{"S-1-5-80-956008885-3418522649-1831038044-1853292631-2271478464", false, false, false, true, false},
+		{"S-1-5-80-0", false, false, false, true, false},
+		{"S-1-5-80", false, false, false, false, false},
+		{"S-1-16-8192", false, false, false, false, true},
+		{"S-1-16-8192-1", false, false, false, false, false},
+		{"S-1-1-0", false, false, false, false, false},
+		{"S-1-18-1", false, false, false, false, false},
+	}
+	for i, test := range tests {
+		sid, err := ParseSID(test.SID)
+		if err != nil {
+			t.Fatalf("test %d: %v", i+1, err)
+		}
+		assert.Equal(t, test.Builtin, sid.IsBuiltin(), "IsBuiltin not as expected for test %d", i+1)
+		assert.Equal(t, test.DomainRelative, sid.IsDomainRelative(), "IsDomainRelative not as expected for test %d", i+1)
+		assert.Equal(t, test.LogonSession, sid.IsLogonSession(), "IsLogonSession not as expected for test %d", i+1)
+		assert.Equal(t, test.Service, sid.IsServiceSID(), "IsServiceSID not as expected for test %d", i+1)
+		assert.Equal(t, test.IntegrityLevel, sid.IsIntegrityLevel(), "IsIntegrityLevel not as expected for test %d", i+1)
+	}
+}
+
+func Test_WellKnownSIDsParse(t *testing.T) {
+	for s, n := range wellKnownSIDNames {
+		sid, err := ParseSID(s)
+		if err != nil {
+			t.Errorf("well known SID %s (%s) does not parse: %v", s, n, err)
+			continue
+		}
+		assert.Equal(t, s, sid.String(), "well known SID does not round trip")
+	}
+}
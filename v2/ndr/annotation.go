@@ -0,0 +1,88 @@
+package ndr
+
+import "strings"
+
+// Notes describing what the bytes of an Annotation are
+const (
+	NoteHeader        = "header"               // The common or private header, the value is the header.
+	NotePadding       = "alignment padding"    // Alignment gap before a primitive.
+	NoteConformantMax = "conformant max count" // A max count moved to the beginning of the structure.
+	NoteOffset        = "offset"               // The offset of a varying array or string.
+	NoteActualCount   = "actual count"         // The actual count of a varying array or string, or the count of a pipe chunk.
+	NotePointer       = "pointer"              // A pointer, the value is the referent ID which is zero for a null pointer.
+	NoteReferent      = "deferred referent"    // Marks where the referent of a pointer starts, the value is the referent ID.
+	NoteDiscriminant  = "union discriminant"   // The first copy of the discriminant of a non-encapsulated union.
+	NoteValue         = "value"                // A value of a field.
+)
+
+// Annotation describes a range of the bytes read by a Decoder.
+type Annotation struct {
+	Offset int         // The offset of the bytes from the start of the byte stream.
+	Length int         // The number of bytes, which is zero for a deferred referent marker.
+	Path   string      // The path of the field being decoded, for example "KerbValidationInfo/EffectiveName/Value".
+	Note   string      // What the bytes are, see the Note constants.
+	Value  interface{} // The value of the bytes, if there is one.
+}
+
+// Annotate sets whether the Decoder records Annotations describing the bytes it reads, which can be used to produce
+// an annotated dump of a byte stream.
+func (dec *Decoder) Annotate(b bool) {
+	dec.annotate = b
+}
+
+// Annotations returns the Annotations of the bytes read, in the order they were read, when the Decoder has been set
+// to annotate.
+func (dec *Decoder) Annotations() []Annotation {
+	return dec.annotations
+}
+
+// position returns the offset of the next byte to be read from the start of the byte stream.
+func (dec *Decoder) position() int {
+	return dec.off
+}
+
+// tracing returns whether the Decoder is recording annotations or has a Tracer, and so needs the paths of fields.
+func (dec *Decoder) tracing() bool {
+	return dec.annotate || dec.tracer != nil
+}
+
+// note records an Annotation of the length bytes just read.
+// Values read while reading a string are not recorded individually, only their length is counted so that the
+// string can be recorded as a whole.
+// The Annotation is also passed to any Tracer set.
+func (dec *Decoder) note(note string, length int, value interface{}) {
+	if !dec.tracing() {
+		return
+	}
+	if note == NoteValue && dec.quiet > 0 {
+		dec.quietBytes += length
+		return
+	}
+	dec.record(dec.position()-length, length, strings.Join(dec.current, "/"), note, value)
+}
+
+func (dec *Decoder) record(offset, length int, path, note string, value interface{}) {
+	if dec.tracer != nil {
+		dec.trace(note, offset, length, path, value)
+	}
+	if !dec.annotate {
+		return
+	}
+	dec.annotations = append(dec.annotations, Annotation{
+		Offset: offset,
+		Length: length,
+		Path:   path,
+		Note:   note,
+		Value:  value,
+	})
+}
+
+// noteHeaders records Annotations of the headers and the pointer to the top level type that precede it.
+func (dec *Decoder) noteHeaders() {
+	if !dec.annotate {
+		return
+	}
+	dec.record(0, int(commonHeaderBytes), "CommonHeader", NoteHeader, dec.ch)
+	dec.record(int(commonHeaderBytes), 8, "PrivateHeader", NoteHeader, dec.ph)
+	dec.record(int(commonHeaderBytes)+8, SizePtr, "", NotePointer, nil)
+}
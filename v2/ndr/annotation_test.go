@@ -0,0 +1,72 @@
+package ndr
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Annotations(t *testing.T) {
+	b, _ := hex.DecodeString(TestHeader + "00040002" + "01000000" + "00040002" + "00040002" + "03000000" + "00040002" + "05000000" + "04000000" + "02000000")
+	dec := NewDecoder(bytes.NewReader(b))
+	dec.Annotate(true)
+	err := dec.Decode(new(testEmbeddingPointer))
+	if err != nil {
+		t.Fatalf("error decoding: %v", err)
+	}
+	a := "testEmbeddingPointer/A"
+	c := a + "/testEmbeddedPointer/C"
+	expected := []Annotation{
+		{0, 8, "CommonHeader", NoteHeader, dec.ch},
+		{8, 8, "PrivateHeader", NoteHeader, dec.ph},
+		{16, 4, "", NotePointer, nil},
+		{20, 4, a, NotePointer, uint32(0x02000400)},
+		{24, 4, "testEmbeddingPointer/B", NoteValue, uint32(1)},
+		{28, 0, a, NoteReferent, uint32(0x02000400)},
+		{28, 4, c, NotePointer, uint32(0x02000400)},
+		{32, 4, a + "/testEmbeddedPointer/D", NotePointer, uint32(0x02000400)},
+		{36, 4, a + "/testEmbeddedPointer/E", NoteValue, uint32(3)},
+		{40, 0, c, NoteReferent, uint32(0x02000400)},
+		{40, 4, c + "/testEmbeddedPointer2/F", NotePointer, uint32(0x02000400)},
+		{44, 4, c + "/testEmbeddedPointer2/G", NoteValue, uint32(5)},
+		{48, 0, c + "/testEmbeddedPointer2/F", NoteReferent, uint32(0x02000400)},
+		{48, 4, c + "/testEmbeddedPointer2/F", NoteValue, uint32(4)},
+		{52, 0, a + "/testEmbeddedPointer/D", NoteReferent, uint32(0x02000400)},
+		{52, 4, a + "/testEmbeddedPointer/D", NoteValue, uint32(2)},
+	}
+	assert.Equal(t, expected, dec.Annotations(), "annotations not as expected")
+}
+
+type testAnnotatedString struct {
+	A uint8
+	S string   `ndr:"conformant"`
+	V []uint16 `ndr:"varying"`
+}
+
+func Test_AnnotationsStringsAndPadding(t *testing.T) {
+	// max count(3):A(1):padding(3):offset(0):actual count(3):"ab\0":padding(2):offset(0):actual count(1):7
+	b, _ := hex.DecodeString(TestHeader + "03000000" + "01" + "000000" + "00000000" + "03000000" + "610062000000" + "0000" + "00000000" + "01000000" + "0700")
+	dec := NewDecoder(bytes.NewReader(b))
+	dec.Annotate(true)
+	v := new(testAnnotatedString)
+	err := dec.Decode(v)
+	if err != nil {
+		t.Fatalf("error decoding: %v", err)
+	}
+	assert.Equal(t, "ab", v.S, "string not as expected")
+	expected := []Annotation{
+		{20, 4, "testAnnotatedString/S", NoteConformantMax, uint32(3)},
+		{24, 1, "testAnnotatedString/A", NoteValue, uint8(1)},
+		{25, 3, "testAnnotatedString/S", NotePadding, nil},
+		{28, 4, "testAnnotatedString/S", NoteOffset, uint32(0)},
+		{32, 4, "testAnnotatedString/S", NoteActualCount, uint32(3)},
+		{36, 6, "testAnnotatedString/S", NoteValue, "ab"},
+		{42, 2, "testAnnotatedString/V", NotePadding, nil},
+		{44, 4, "testAnnotatedString/V", NoteOffset, uint32(0)},
+		{48, 4, "testAnnotatedString/V", NoteActualCount, uint32(1)},
+		{52, 2, "testAnnotatedString/V", NoteValue, uint16(7)},
+	}
+	assert.Equal(t, expected, dec.Annotations()[3:], "annotations not as expected")
+}
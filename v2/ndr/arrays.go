@@ -174,6 +174,18 @@ func (dec *Decoder) fillConformantArray(v reflect.Value, tag reflect.StructTag,
 func (dec *Decoder) fillUniDimensionalConformantArray(v reflect.Value, tag reflect.StructTag, def *[]deferedPtr) error {
 	m := dec.precedingMax()
 	n := int(m)
+	if v.Type().Elem().Kind() == reflect.Uint8 && reflect.TypeOf([]byte{}).ConvertibleTo(v.Type()) {
+		// Bytes are read as a whole rather than element by element
+		b, err := dec.readAliasedBytes(n)
+		if err != nil {
+			return fmt.Errorf("could not fill uni-dimensional conformant byte array: %v", err)
+		}
+		v.Set(reflect.ValueOf(b).Convert(v.Type()))
+		if dec.tracing() {
+			dec.note(NoteValue, n, b)
+		}
+		return nil
+	}
 	a := reflect.MakeSlice(v.Type(), n, n)
 	for i := 0; i < n; i++ {
 		err := dec.fill(a.Index(i), tag, def)
@@ -240,10 +252,12 @@ func (dec *Decoder) fillUniDimensionalVaryingArray(v reflect.Value, tag reflect.
 	if err != nil {
 		return fmt.Errorf("could not read offset of uni-dimensional varying array: %v", err)
 	}
+	dec.note(NoteOffset, SizeUint32, o)
 	s, err := dec.readUint32()
 	if err != nil {
 		return fmt.Errorf("could not establish actual count of uni-dimensional varying array: %v", err)
 	}
+	dec.note(NoteActualCount, SizeUint32, s)
 	t := v.Type()
 	// Total size of the array is the offset in the index being passed plus the actual count of elements being passed.
 	n := int(s + o)
@@ -271,11 +285,13 @@ func (dec *Decoder) fillMultiDimensionalVaryingArray(v reflect.Value, t reflect.
 		if err != nil {
 			return fmt.Errorf("could not read offset of dimension %d: %v", i+1, err)
 		}
+		dec.note(NoteOffset, SizeUint32, off)
 		o[i] = int(off)
 		s, err := dec.readUint32()
 		if err != nil {
 			return fmt.Errorf("could not read size of dimension %d: %v", i+1, err)
 		}
+		dec.note(NoteActualCount, SizeUint32, s)
 		l[i] = int(s) + int(off)
 	}
 	// Initialise size of slices
@@ -334,10 +350,12 @@ func (dec *Decoder) fillUniDimensionalConformantVaryingArray(v reflect.Value, ta
 	if err != nil {
 		return fmt.Errorf("could not read offset of uni-dimensional conformant varying array: %v", err)
 	}
+	dec.note(NoteOffset, SizeUint32, o)
 	s, err := dec.readUint32()
 	if err != nil {
 		return fmt.Errorf("could not establish actual count of uni-dimensional conformant varying array: %v", err)
 	}
+	dec.note(NoteActualCount, SizeUint32, s)
 	if m < o+s {
 		return errors.New("max count is less than the offset plus actual count")
 	}
@@ -370,11 +388,13 @@ func (dec *Decoder) fillMultiDimensionalConformantVaryingArray(v reflect.Value,
 		if err != nil {
 			return fmt.Errorf("could not read offset of dimension %d: %v", i+1, err)
 		}
+		dec.note(NoteOffset, SizeUint32, off)
 		o[i] = int(off)
 		s, err := dec.readUint32()
 		if err != nil {
 			return fmt.Errorf("could not read actual count of dimension %d: %v", i+1, err)
 		}
+		dec.note(NoteActualCount, SizeUint32, s)
 		if m[i] < int(s)+int(off) {
 			m[i] = int(s) + int(off)
 		}
@@ -411,3 +431,123 @@ func (dec *Decoder) fillMultiDimensionalConformantVaryingArray(v reflect.Value,
 	}
 	return nil
 }
+
+// sliceLengths returns the length of each of the first d dimensions of a slice. The length of each sub dimension is
+// taken from the first element of the dimension above and is zero if that dimension is empty.
+func sliceLengths(v reflect.Value, d int) []int {
+	l := make([]int, d, d)
+	for i := range l {
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			break
+		}
+		l[i] = v.Len()
+		if l[i] < 1 {
+			break
+		}
+		v = v.Index(0)
+	}
+	return l
+}
+
+// writeFixedArray establishes if the fixed array is uni or multi dimensional and then writes it.
+func (enc *Encoder) writeFixedArray(v reflect.Value, tag reflect.StructTag, def *[]deferredReferent) error {
+	l, t := parseDimensions(v)
+	if t.Kind() == reflect.String {
+		tag = reflect.StructTag(subStringArrayTag)
+	}
+	if len(l) < 1 {
+		return errors.New("could not establish dimensions of fixed array")
+	}
+	if len(l) == 1 {
+		err := enc.writeUniDimensionalArray(v, tag, def)
+		if err != nil {
+			return fmt.Errorf("could not write uni-dimensional fixed array: %v", err)
+		}
+		return nil
+	}
+	// Fixed array is multidimensional
+	ps := multiDimensionalIndexPermutations(l[:len(l)-1])
+	for _, p := range ps {
+		// Get current multi-dimensional index to write
+		a := v
+		for _, i := range p {
+			a = a.Index(i)
+		}
+		// write the last dimension array
+		err := enc.writeUniDimensionalArray(a, tag, def)
+		if err != nil {
+			return fmt.Errorf("could not write dimension %v of multi-dimensional fixed array: %v", p, err)
+		}
+	}
+	return nil
+}
+
+// writeUniDimensionalArray writes each of the elements of an array or slice.
+func (enc *Encoder) writeUniDimensionalArray(v reflect.Value, tag reflect.StructTag, def *[]deferredReferent) error {
+	for i := 0; i < v.Len(); i++ {
+		err := enc.write(v.Index(i), tag, def)
+		if err != nil {
+			return fmt.Errorf("could not write index %d of array: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// writeMultiDimensionalArray writes the elements of a multi-dimensional slice with the dimensions provided.
+// The elements are written in the same order as the decoder fills them.
+func (enc *Encoder) writeMultiDimensionalArray(v reflect.Value, l []int, tag reflect.StructTag, def *[]deferredReferent) error {
+	for _, n := range l {
+		if n < 1 {
+			// There are no elements to write
+			return nil
+		}
+	}
+	ps := multiDimensionalIndexPermutations(l)
+	for _, p := range ps {
+		// Get current multi-dimensional index to write
+		a := v
+		for _, i := range p {
+			if i >= a.Len() {
+				return fmt.Errorf("index %v of slice out of range, all sub dimensions must have the same length", p)
+			}
+			a = a.Index(i)
+		}
+		err := enc.write(a, tag, def)
+		if err != nil {
+			return fmt.Errorf("could not write index %v of slice: %v", p, err)
+		}
+	}
+	return nil
+}
+
+// writeConformantArray writes the elements of a uni or multi dimensional conformant slice.
+// The max count of each dimension is written at the beginning of the structure.
+func (enc *Encoder) writeConformantArray(v reflect.Value, tag reflect.StructTag, def *[]deferredReferent) error {
+	d, _ := sliceDimensions(v.Type())
+	if d > 1 {
+		return enc.writeMultiDimensionalArray(v, sliceLengths(v, d), tag, def)
+	}
+	return enc.writeUniDimensionalArray(v, tag, def)
+}
+
+// writeVaryingArray writes the offset and actual count of each dimension of a uni or multi dimensional varying slice
+// followed by the elements. The offset is always zero.
+func (enc *Encoder) writeVaryingArray(v reflect.Value, tag reflect.StructTag, def *[]deferredReferent) error {
+	d, _ := sliceDimensions(v.Type())
+	l := sliceLengths(v, d)
+	for _, n := range l {
+		enc.writeUint32(0)
+		enc.writeUint32(uint32(n))
+	}
+	if d > 1 {
+		return enc.writeMultiDimensionalArray(v, l, tag, def)
+	}
+	return enc.writeUniDimensionalArray(v, tag, def)
+}
+
+// writeConformantVaryingArray writes a uni or multi dimensional conformant varying slice.
+// The max count of each dimension is written at the beginning of the structure so the remainder is written as for a
+// varying array.
+func (enc *Encoder) writeConformantVaryingArray(v reflect.Value, tag reflect.StructTag, def *[]deferredReferent) error {
+	return enc.writeVaryingArray(v, tag, def)
+}
@@ -1,12 +1,14 @@
-// Package ndr provides the ability to unmarshal NDR encoded byte steams into Go data structures
+// Package ndr provides the ability to marshal Go data structures into, and unmarshal them from, NDR encoded byte streams
 package ndr
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
 	"reflect"
 	"strings"
+	"sync/atomic"
 )
 
 // Struct tag values
@@ -17,35 +19,132 @@ const (
 	TagPipe       = "pipe"
 )
 
-// Decoder unmarshals NDR byte stream data into a Go struct representation
+// Decoder unmarshals NDR byte stream data into a Go struct representation.
+// A Decoder is not safe for concurrent use, a Decode called while another is in progress returns an error. Unmarshal
+// and UnmarshalReader are safe for concurrent use.
 type Decoder struct {
-	r             *bufio.Reader // source of the data
-	size          int           // initial size of bytes in buffer
+	busy          int32         // set while decoding to detect concurrent use
+	r             *bufio.Reader // source of the data, when not decoding from a byte slice
+	buf           *bufio.Reader // the buffered reader kept for reuse by Reset
+	b             []byte        // source of the data, when decoding from a byte slice
+	off           int           // offset of the next byte to read, which is the count of bytes read from r
+	alias         bool          // whether RawBytes and conformant []byte arrays reference b rather than copies of it
 	ch            CommonHeader  // NDR common header
 	ph            PrivateHeader // NDR private header
 	conformantMax []uint32      // conformant max values that were moved to the beginning of the structure
 	s             interface{}   // pointer to the structure being populated
 	current       []string      // keeps track of the current field being populated
+
+	annotate        bool         // whether to record annotations of the bytes read
+	annotations     []Annotation // annotations of the bytes read
+	conformantPaths []string     // paths of the fields the conformant max values are for, when annotating or tracing
+	quiet           int          // values are not annotated individually while greater than zero
+	quietBytes      int          // the number of bytes of the values not annotated individually
+	tracer          Tracer       // receives events as the byte stream is decoded
 }
 
 type deferedPtr struct {
-	v   reflect.Value
-	tag reflect.StructTag
+	v    reflect.Value
+	tag  reflect.StructTag
+	id   uint32   // the referent ID of the pointer
+	path []string // the path of the pointer's field
 }
 
 // NewDecoder creates a new instance of a NDR Decoder.
 func NewDecoder(r io.Reader) *Decoder {
 	dec := new(Decoder)
-	dec.r = bufio.NewReader(r)
-	dec.r.Peek(int(commonHeaderBytes)) // For some reason an operation is needed on the buffer to initialise it so Buffered() != 0
-	dec.size = dec.r.Buffered()
+	dec.Reset(r)
 	return dec
 }
 
+// NewDecoderBytes creates a new instance of a NDR Decoder that reads directly from the byte slice provided rather than
+// through a buffer. The byte slice must not be modified while it is being decoded.
+func NewDecoderBytes(b []byte) *Decoder {
+	return &Decoder{b: b}
+}
+
+// AliasInput sets whether RawBytes fields and uni-dimensional conformant []byte arrays reference the byte slice of a
+// Decoder created with NewDecoderBytes rather than copies of it. This avoids allocating for them but the decoded values
+// then share their memory with the input, so modifying one modifies the other. Reads from an io.Reader are always
+// copies.
+func (dec *Decoder) AliasInput(b bool) {
+	dec.alias = b
+}
+
+// Reset discards the state of the Decoder and sets it to read from the io.Reader provided, reusing its buffer.
+// The settings of Annotate, AliasInput and SetTracer are cleared. It panics if the Decoder is decoding.
+func (dec *Decoder) Reset(r io.Reader) {
+	dec.reset()
+	if dec.buf == nil {
+		dec.buf = bufio.NewReader(r)
+	} else {
+		dec.buf.Reset(r)
+	}
+	dec.r = dec.buf
+	dec.b = nil
+}
+
+// ResetBytes discards the state of the Decoder and sets it to read directly from the byte slice provided, as a Decoder
+// created with NewDecoderBytes does. The settings of Annotate, AliasInput and SetTracer are cleared. It panics if the
+// Decoder is decoding.
+func (dec *Decoder) ResetBytes(b []byte) {
+	dec.reset()
+	if dec.buf != nil {
+		// Keep the buffer for a later Reset but not a reference to the io.Reader
+		dec.buf.Reset(nil)
+	}
+	dec.r = nil
+	dec.b = b
+}
+
+// reset clears the state and settings of the Decoder but keeps the capacity of its slices.
+func (dec *Decoder) reset() {
+	if atomic.LoadInt32(&dec.busy) != 0 {
+		panic("ndr: Decoder reset while decoding, a Decoder is not safe for concurrent use")
+	}
+	dec.off = 0
+	dec.alias = false
+	dec.annotate = false
+	dec.tracer = nil
+	dec.ch = CommonHeader{}
+	dec.ph = PrivateHeader{}
+	dec.s = nil
+	dec.clear()
+	dec.annotations = dec.annotations[:0]
+}
+
+// clear clears the state of a previous decode.
+func (dec *Decoder) clear() {
+	dec.conformantMax = dec.conformantMax[:0]
+	dec.conformantPaths = dec.conformantPaths[:0]
+	dec.current = dec.current[:0]
+	dec.quiet = 0
+	dec.quietBytes = 0
+}
+
+// acquire marks the Decoder as decoding, returning an error if it already is.
+func (dec *Decoder) acquire() error {
+	if !atomic.CompareAndSwapInt32(&dec.busy, 0, 1) {
+		return errors.New("decoder is already decoding, a Decoder is not safe for concurrent use")
+	}
+	dec.clear()
+	return nil
+}
+
+func (dec *Decoder) release() {
+	atomic.StoreInt32(&dec.busy, 0)
+}
+
 // Decode unmarshals the NDR encoded bytes into the pointer of a struct provided.
 func (dec *Decoder) Decode(s interface{}) error {
+	err := dec.acquire()
+	if err != nil {
+		return err
+	}
+	defer dec.release()
 	dec.s = s
-	err := dec.readCommonHeader()
+	dec.annotations = dec.annotations[:0]
+	err = dec.readCommonHeader()
 	if err != nil {
 		return err
 	}
@@ -53,10 +152,11 @@ func (dec *Decoder) Decode(s interface{}) error {
 	if err != nil {
 		return err
 	}
-	_, err = dec.r.Discard(4) //The next 4 bytes are an RPC unique pointer referent. We just skip these.
+	err = dec.discard(4) //The next 4 bytes are an RPC unique pointer referent. We just skip these.
 	if err != nil {
 		return Errorf("unable to process byte stream: %v", err)
 	}
+	dec.noteHeaders()
 
 	return dec.process(s, reflect.StructTag(""))
 }
@@ -76,7 +176,11 @@ func (dec *Decoder) process(s interface{}, tag reflect.StructTag) error {
 	}
 	// Read any deferred referents associated with pointers
 	for _, p := range localDef {
+		c := dec.current
+		dec.current = p.path
+		dec.note(NoteReferent, 0, p.id)
 		err = dec.process(p.v, p.tag)
+		dec.current = c
 		if err != nil {
 			return fmt.Errorf("could not decode deferred referent: %v", err)
 		}
@@ -87,7 +191,12 @@ func (dec *Decoder) process(s interface{}, tag reflect.StructTag) error {
 // scanConformantArrays scans the structure for embedded conformant fields and captures the maximum element counts for
 // dimensions of the array that are moved to the beginning of the structure.
 func (dec *Decoder) scanConformantArrays(s interface{}, tag reflect.StructTag) error {
-	err := dec.conformantScan(s, tag)
+	dec.conformantPaths = dec.conformantPaths[:0]
+	var path string
+	if dec.tracing() {
+		path = strings.Join(dec.current, "/")
+	}
+	err := dec.conformantScan(s, tag, path)
 	if err != nil {
 		return fmt.Errorf("failed to scan for embedded conformant arrays: %v", err)
 	}
@@ -96,12 +205,23 @@ func (dec *Decoder) scanConformantArrays(s interface{}, tag reflect.StructTag) e
 		if err != nil {
 			return fmt.Errorf("could not read preceding conformant max count index %d: %v", i, err)
 		}
+		if dec.tracing() && i < len(dec.conformantPaths) {
+			dec.record(dec.position()-SizeUint32, SizeUint32, dec.conformantPaths[i], NoteConformantMax, dec.conformantMax[i])
+		}
 	}
 	return nil
 }
 
+// addConformantMax adds a conformant max value to be read for the field at the path provided.
+func (dec *Decoder) addConformantMax(path string) {
+	dec.conformantMax = append(dec.conformantMax, uint32(0))
+	if dec.tracing() {
+		dec.conformantPaths = append(dec.conformantPaths, path)
+	}
+}
+
 // conformantScan inspects the structure's fields for whether they are conformant.
-func (dec *Decoder) conformantScan(s interface{}, tag reflect.StructTag) error {
+func (dec *Decoder) conformantScan(s interface{}, tag reflect.StructTag, path string) error {
 	ndrTag := parseTags(tag)
 	if ndrTag.HasValue(TagPointer) {
 		return nil
@@ -109,8 +229,19 @@ func (dec *Decoder) conformantScan(s interface{}, tag reflect.StructTag) error {
 	v := getReflectValue(s)
 	switch v.Kind() {
 	case reflect.Struct:
+		// Paths, only needed for annotations and tracing, are formed in the same way as when filling the struct
+		if dec.tracing() {
+			if path != "" {
+				path += "/"
+			}
+			path += v.Type().Name()
+		}
 		for i := 0; i < v.NumField(); i++ {
-			err := dec.conformantScan(v.Field(i), v.Type().Field(i).Tag)
+			fieldPath := path
+			if dec.tracing() {
+				fieldPath += "/" + v.Type().Field(i).Name
+			}
+			err := dec.conformantScan(v.Field(i), v.Type().Field(i).Tag, fieldPath)
 			if err != nil {
 				return err
 			}
@@ -119,18 +250,18 @@ func (dec *Decoder) conformantScan(s interface{}, tag reflect.StructTag) error {
 		if !ndrTag.HasValue(TagConformant) {
 			break
 		}
-		dec.conformantMax = append(dec.conformantMax, uint32(0))
+		dec.addConformantMax(path)
 	case reflect.Slice:
 		if !ndrTag.HasValue(TagConformant) {
 			break
 		}
 		d, t := sliceDimensions(v.Type())
 		for i := 0; i < d; i++ {
-			dec.conformantMax = append(dec.conformantMax, uint32(0))
+			dec.addConformantMax(path)
 		}
 		// For string arrays there is a common max for the strings within the array.
 		if t.Kind() == reflect.String {
-			dec.conformantMax = append(dec.conformantMax, uint32(0))
+			dec.addConformantMax(path)
 		}
 	}
 	return nil
@@ -144,10 +275,13 @@ func (dec *Decoder) isPointer(v reflect.Value, tag reflect.StructTag, def *[]def
 		if err != nil {
 			return true, fmt.Errorf("could not read pointer: %v", err)
 		}
+		dec.note(NotePointer, SizePtr, p)
 		ndrTag.delete(TagPointer)
 		if p != 0 {
 			// if pointer is not zero add to the deferred items at end of stream
-			*def = append(*def, deferedPtr{v, ndrTag.StructTag()})
+			path := make([]string, len(dec.current))
+			copy(path, dec.current)
+			*def = append(*def, deferedPtr{v: v, tag: ndrTag.StructTag(), id: p, path: path})
 		}
 		return true, nil
 	}
@@ -189,12 +323,14 @@ func (dec *Decoder) fill(s interface{}, tag reflect.StructTag, localDef *[]defer
 		for i := 0; i < v.NumField(); i++ {
 			fieldName := v.Type().Field(i).Name
 			dec.current = append(dec.current, fieldName) //Track the current field being filled
-			//fmt.Fprintf(os.Stderr, "DEBUG Decoding: %s\n", strings.Join(dec.current, "/"))
 			structTag := v.Type().Field(i).Tag
 			ndrTag := parseTags(structTag)
 
 			// Union handling
 			if !unionTag.IsValid() {
+				if dec.tracer != nil {
+					dec.tracer.EnterField(strings.Join(dec.current, "/"))
+				}
 				// Is this field a union tag?
 				unionTag = dec.isUnion(v.Field(i), structTag)
 			} else {
@@ -203,7 +339,10 @@ func (dec *Decoder) fill(s interface{}, tag reflect.StructTag, localDef *[]defer
 					unionField, err = unionSelectedField(v, unionTag)
 					if err != nil {
 						return fmt.Errorf("could not determine selected union value field for %s with discriminat"+
-							" tag %s: %v", v.Type().Name(), unionTag, err)
+							" tag %v: %v", v.Type().Name(), unionTag, err)
+					}
+					if dec.tracer != nil {
+						dec.tracer.UnionArm(strings.Join(dec.current[:len(dec.current)-1], "/"), unionField)
 					}
 				}
 				if ndrTag.HasValue(TagUnionField) && fieldName != unionField {
@@ -211,6 +350,9 @@ func (dec *Decoder) fill(s interface{}, tag reflect.StructTag, localDef *[]defer
 					dec.current = dec.current[:len(dec.current)-1] //This field has been skipped so remove it from the current field tracker
 					continue
 				}
+				if dec.tracer != nil {
+					dec.tracer.EnterField(strings.Join(dec.current, "/"))
+				}
 			}
 
 			// Check if field is a pointer
@@ -237,6 +379,9 @@ func (dec *Decoder) fill(s interface{}, tag reflect.StructTag, localDef *[]defer
 					return fmt.Errorf("could not fill struct field(%s): %v", strings.Join(dec.current, "/"), err)
 				}
 			}
+			if dec.tracer != nil {
+				dec.tracer.LeaveField(strings.Join(dec.current, "/"))
+			}
 			dec.current = dec.current[:len(dec.current)-1] //This field has been filled so remove it from the current field tracker
 		}
 		dec.current = dec.current[:len(dec.current)-1] //This field has been filled so remove it from the current field tracker
@@ -245,85 +390,92 @@ func (dec *Decoder) fill(s interface{}, tag reflect.StructTag, localDef *[]defer
 		if err != nil {
 			return fmt.Errorf("could not fill %s: %v", v.Type().Name(), err)
 		}
-		v.Set(reflect.ValueOf(i))
+		v.SetBool(i)
 	case reflect.Uint8:
 		i, err := dec.readUint8()
 		if err != nil {
 			return fmt.Errorf("could not fill %s: %v", v.Type().Name(), err)
 		}
-		v.Set(reflect.ValueOf(i))
+		v.SetUint(uint64(i))
 	case reflect.Uint16:
 		i, err := dec.readUint16()
 		if err != nil {
 			return fmt.Errorf("could not fill %s: %v", v.Type().Name(), err)
 		}
-		v.Set(reflect.ValueOf(i))
+		v.SetUint(uint64(i))
 	case reflect.Uint32:
 		i, err := dec.readUint32()
 		if err != nil {
 			return fmt.Errorf("could not fill %s: %v", v.Type().Name(), err)
 		}
-		v.Set(reflect.ValueOf(i))
+		v.SetUint(uint64(i))
 	case reflect.Uint64:
 		i, err := dec.readUint64()
 		if err != nil {
 			return fmt.Errorf("could not fill %s: %v", v.Type().Name(), err)
 		}
-		v.Set(reflect.ValueOf(i))
+		v.SetUint(uint64(i))
 	case reflect.Int8:
 		i, err := dec.readInt8()
 		if err != nil {
 			return fmt.Errorf("could not fill %s: %v", v.Type().Name(), err)
 		}
-		v.Set(reflect.ValueOf(i))
+		v.SetInt(int64(i))
 	case reflect.Int16:
 		i, err := dec.readInt16()
 		if err != nil {
 			return fmt.Errorf("could not fill %s: %v", v.Type().Name(), err)
 		}
-		v.Set(reflect.ValueOf(i))
+		v.SetInt(int64(i))
 	case reflect.Int32:
 		i, err := dec.readInt32()
 		if err != nil {
 			return fmt.Errorf("could not fill %s: %v", v.Type().Name(), err)
 		}
-		v.Set(reflect.ValueOf(i))
+		v.SetInt(int64(i))
 	case reflect.Int64:
 		i, err := dec.readInt64()
 		if err != nil {
 			return fmt.Errorf("could not fill %s: %v", v.Type().Name(), err)
 		}
-		v.Set(reflect.ValueOf(i))
+		v.SetInt(int64(i))
 	case reflect.String:
 		ndrTag := parseTags(tag)
 		conformant := ndrTag.HasValue(TagConformant)
 		// strings are always varying so this is assumed without an explicit tag
 		var s string
 		var err error
+		// The characters are annotated as a whole once the string has been read
+		dec.quiet++
+		dec.quietBytes = 0
 		if conformant {
 			s, err = dec.readConformantVaryingString(localDef)
-			if err != nil {
-				return fmt.Errorf("could not fill with conformant varying string: %v", err)
-			}
 		} else {
 			s, err = dec.readVaryingString(localDef)
-			if err != nil {
-				return fmt.Errorf("could not fill with varying string: %v", err)
+		}
+		dec.quiet--
+		if err != nil {
+			if conformant {
+				return fmt.Errorf("could not fill with conformant varying string: %v", err)
 			}
+			return fmt.Errorf("could not fill with varying string: %v", err)
+		}
+		v.SetString(s)
+		if dec.tracing() {
+			dec.note(NoteValue, dec.quietBytes, s)
 		}
-		v.Set(reflect.ValueOf(s))
 	case reflect.Float32:
 		i, err := dec.readFloat32()
 		if err != nil {
 			return fmt.Errorf("could not fill %v: %v", v.Type().Name(), err)
 		}
-		v.Set(reflect.ValueOf(i))
+		v.SetFloat(float64(i))
 	case reflect.Float64:
 		i, err := dec.readFloat64()
 		if err != nil {
 			return fmt.Errorf("could not fill %v: %v", v.Type().Name(), err)
 		}
-		v.Set(reflect.ValueOf(i))
+		v.SetFloat(float64(i))
 	case reflect.Array:
 		err := dec.fillFixedArray(v, tag, localDef)
 		if err != nil {
@@ -378,16 +530,92 @@ func (dec *Decoder) fill(s interface{}, tag reflect.StructTag, localDef *[]defer
 	default:
 		return fmt.Errorf("unsupported type")
 	}
+	// The value is only boxed when it will be recorded, as this is on the path of every primitive decoded.
+	if dec.tracing() && v.Kind() >= reflect.Bool && v.Kind() <= reflect.Float64 {
+		dec.note(NoteValue, int(v.Type().Size()), v.Interface())
+	}
 	return nil
 }
 
-// readBytes returns a number of bytes from the NDR byte stream.
+// next returns the next n bytes of the NDR byte stream. These reference the input byte slice or the buffer of the
+// io.Reader so must not be retained or modified.
+func (dec *Decoder) next(n int) ([]byte, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("error reading bytes from stream: invalid count %d", n)
+	}
+	if dec.r == nil {
+		if n > len(dec.b)-dec.off {
+			return nil, fmt.Errorf("error reading bytes from stream: %v", io.ErrUnexpectedEOF)
+		}
+		b := dec.b[dec.off : dec.off+n : dec.off+n]
+		dec.off += n
+		return b, nil
+	}
+	if n > dec.r.Size() {
+		return dec.readBytes(n)
+	}
+	// Peek waits for as many reads of the io.Reader as are needed to buffer n bytes
+	b, err := dec.r.Peek(n)
+	if err != nil {
+		return nil, fmt.Errorf("error reading bytes from stream: %v", unexpectedEOF(err))
+	}
+	dec.r.Discard(n)
+	dec.off += n
+	return b, nil
+}
+
+// readBytes returns a copy of a number of bytes from the NDR byte stream.
 func (dec *Decoder) readBytes(n int) ([]byte, error) {
+	if dec.r == nil {
+		b, err := dec.next(n)
+		if err != nil {
+			return nil, err
+		}
+		c := make([]byte, n, n)
+		copy(c, b)
+		return c, nil
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("error reading bytes from stream: invalid count %d", n)
+	}
 	//TODO make this take an int64 as input to allow for larger values on all systems?
 	b := make([]byte, n, n)
-	m, err := dec.r.Read(b)
-	if err != nil || m != n {
-		return b, fmt.Errorf("error reading bytes from stream: %v", err)
+	m, err := io.ReadFull(dec.r, b)
+	dec.off += m
+	if err != nil {
+		return nil, fmt.Errorf("error reading bytes from stream: %v", unexpectedEOF(err))
 	}
 	return b, nil
 }
+
+// readAliasedBytes returns a number of bytes from the NDR byte stream that reference the input byte slice if the
+// Decoder has been set to alias the input, otherwise a copy.
+func (dec *Decoder) readAliasedBytes(n int) ([]byte, error) {
+	if dec.alias && dec.r == nil {
+		return dec.next(n)
+	}
+	return dec.readBytes(n)
+}
+
+// discard skips the next n bytes of the NDR byte stream.
+func (dec *Decoder) discard(n int) error {
+	if dec.r == nil {
+		if n > len(dec.b)-dec.off {
+			dec.off = len(dec.b)
+			return io.ErrUnexpectedEOF
+		}
+		dec.off += n
+		return nil
+	}
+	m, err := dec.r.Discard(n)
+	dec.off += m
+	return unexpectedEOF(err)
+}
+
+// unexpectedEOF returns io.ErrUnexpectedEOF in place of io.EOF as the byte stream has ended part way through.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -3,7 +3,10 @@ package ndr
 import (
 	"bytes"
 	"encoding/hex"
+	"io"
+	"reflect"
 	"testing"
+	"testing/iotest"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -93,6 +96,26 @@ func TestBasicDecode(t *testing.T) {
 	assert.Equal(t, uint32(29780581), ft.B, "Value of field B not as expected %d")
 }
 
+type testNamedUint32 uint32
+
+type testNamedTypes struct {
+	A testNamedUint32
+	B testNamedUint32
+}
+
+func TestDecodeNamedTypes(t *testing.T) {
+	hexStr := "01100800cccccccca00400000000000000000200d186660f656ac601"
+	b, _ := hex.DecodeString(hexStr)
+	ft := new(testNamedTypes)
+	dec := NewDecoder(bytes.NewReader(b))
+	err := dec.Decode(ft)
+	if err != nil {
+		t.Fatalf("error decoding: %v", err)
+	}
+	assert.Equal(t, testNamedUint32(258377425), ft.A, "Value of field A not as expected")
+	assert.Equal(t, testNamedUint32(29780581), ft.B, "Value of field B not as expected")
+}
+
 func TestBasicDecodeOverRun(t *testing.T) {
 	hexStr := "01100800cccccccca00400000000000000000200d186660f"
 	b, _ := hex.DecodeString(hexStr)
@@ -135,3 +158,229 @@ func Test_EmbeddedPointers(t *testing.T) {
 	assert.Equal(t, uint32(4), ft.A.C.F)
 	assert.Equal(t, uint32(5), ft.A.C.G)
 }
+
+type testLargeStruct struct {
+	A uint8
+	B []uint16 `ndr:"conformant"`
+	C uint8
+	D uint64
+	E string `ndr:"pointer,conformant,varying"`
+}
+
+func TestNewDecoderBytes(t *testing.T) {
+	s := testEncodeStruct{
+		Bool:       true,
+		Int8:       -1,
+		Int16:      -2,
+		Int32:      -3,
+		Int64:      -4,
+		Float32:    1.5,
+		Float64:    -2.25,
+		Inner:      testEncodeInner{A: 1, B: "inner", C: []uint64{1, 2}},
+		Inners:     []testEncodeInner{{A: 3, B: "a"}},
+		EmptySlice: []uint32{},
+		Strings:    [][]string{{"a", "bb"}},
+		Fixed:      [2][2]string{{"w", "x"}, {"y", "z"}},
+		RawSize:    3,
+		Raw:        testEncodeRawBytes{7, 8, 9},
+		Union:      testUnionNonEncapsulated{Tag: 2, Value2: 5},
+	}
+	// Larger than the bufio buffer so that alignment depends on the offset being tracked beyond it
+	l := testLargeStruct{A: 1, B: make([]uint16, 3001), C: 2, D: 3, E: "large"}
+	for i := range l.B {
+		l.B[i] = uint16(i)
+	}
+	var tests = []struct {
+		v   interface{}
+		new func() interface{}
+	}{
+		{s, func() interface{} { return new(testEncodeStruct) }},
+		{l, func() interface{} { return new(testLargeStruct) }},
+	}
+	for i, test := range tests {
+		var buf bytes.Buffer
+		err := NewEncoder(&buf).Encode(test.v)
+		if err != nil {
+			t.Fatalf("error encoding test %d: %v", i+1, err)
+		}
+		d := test.new()
+		err = NewDecoderBytes(buf.Bytes()).Decode(d)
+		if err != nil {
+			t.Fatalf("error decoding test %d: %v", i+1, err)
+		}
+		assert.Equal(t, test.v, reflect.ValueOf(d).Elem().Interface(), "decoded value not as expected for test %d", i+1)
+	}
+}
+
+func TestNewDecoderBytesOverRun(t *testing.T) {
+	b, _ := hex.DecodeString("01100800cccccccca00400000000000000000200d186660f")
+	err := NewDecoderBytes(b).Decode(new(SimpleTest))
+	assert.Error(t, err, "expected error for trying to read more than the bytes we have")
+}
+
+type testConformantRawBytesStruct struct {
+	Size uint32
+	Raw  testConformantRawBytes `ndr:"pointer,conformant"`
+}
+
+type testConformantRawBytes []byte
+
+func (b testConformantRawBytes) Size(s interface{}) int {
+	return int(s.(testConformantRawBytesStruct).Size)
+}
+
+func TestDecoder_ConformantRawBytes(t *testing.T) {
+	// Raw bytes referenced by a size_is pointer have the same layout as a pointer to a conformant byte array
+	s := testConformantRawBytesStruct{Size: 3, Raw: testConformantRawBytes{1, 2, 3}}
+	var buf, expected bytes.Buffer
+	err := NewEncoder(&buf).Encode(s)
+	if err != nil {
+		t.Fatalf("error encoding: %v", err)
+	}
+	err = NewEncoder(&expected).Encode(struct {
+		Size  uint32
+		Bytes []byte `ndr:"pointer,conformant"`
+	}{3, []byte{1, 2, 3}})
+	if err != nil {
+		t.Fatalf("error encoding: %v", err)
+	}
+	assert.Equal(t, expected.Bytes(), buf.Bytes(), "encoded conformant raw bytes not as expected")
+	d := new(testConformantRawBytesStruct)
+	err = NewDecoderBytes(buf.Bytes()).Decode(d)
+	if err != nil {
+		t.Fatalf("error decoding: %v", err)
+	}
+	assert.Equal(t, s, *d, "decoded value not as expected")
+
+	// The size must match the max count, which follows the headers, the referent ID, Size and Raw's referent ID
+	b := append([]byte{}, buf.Bytes()...)
+	b[28]++
+	err = NewDecoderBytes(b).Decode(new(testConformantRawBytesStruct))
+	assert.Error(t, err, "expected error for a max count that does not match the size")
+}
+
+type testAliasStruct struct {
+	RawSize uint32
+	Raw     testAliasRawBytes
+	Bytes   []byte `ndr:"pointer,conformant"`
+}
+
+type testAliasRawBytes []byte
+
+func (b testAliasRawBytes) Size(s interface{}) int {
+	return int(s.(testAliasStruct).RawSize)
+}
+
+func TestDecoder_AliasInput(t *testing.T) {
+	s := testAliasStruct{RawSize: 2, Raw: testAliasRawBytes{1, 2}, Bytes: []byte{3, 4, 5}}
+	var buf bytes.Buffer
+	err := NewEncoder(&buf).Encode(s)
+	if err != nil {
+		t.Fatalf("error encoding: %v", err)
+	}
+	for i, alias := range []bool{false, true} {
+		b := append([]byte{}, buf.Bytes()...)
+		dec := NewDecoderBytes(b)
+		dec.AliasInput(alias)
+		d := new(testAliasStruct)
+		err = dec.Decode(d)
+		if err != nil {
+			t.Fatalf("error decoding test %d: %v", i+1, err)
+		}
+		assert.Equal(t, s, *d, "decoded value not as expected for test %d", i+1)
+		for j := range b {
+			b[j] = 0xff
+		}
+		if alias {
+			assert.Equal(t, testAliasRawBytes{0xff, 0xff}, d.Raw, "raw bytes do not alias the input for test %d", i+1)
+			assert.Equal(t, []byte{0xff, 0xff, 0xff}, d.Bytes, "byte array does not alias the input for test %d", i+1)
+			// Appending must not overwrite the input that follows the value at offset 26
+			d.Raw = append(d.Raw, 0)
+			assert.Equal(t, byte(0xff), b[26], "append to aliased bytes modified the input for test %d", i+1)
+		} else {
+			assert.Equal(t, s, *d, "decoded value modified with input for test %d", i+1)
+		}
+	}
+}
+
+// chunkReader returns a reader delivering the bytes in chunks of the size provided, as a network connection might.
+func chunkReader(b []byte, n int) io.Reader {
+	var rs []io.Reader
+	for len(b) > n {
+		rs = append(rs, bytes.NewReader(b[:n]))
+		b = b[n:]
+	}
+	rs = append(rs, bytes.NewReader(b))
+	return io.MultiReader(rs...)
+}
+
+func TestDecodeReaders(t *testing.T) {
+	s := testEncodeStruct{
+		Bool:     true,
+		Int8:     -1,
+		Int16:    -2,
+		Int32:    -3,
+		Int64:    -4,
+		Float32:  1.5,
+		Float64:  -2.25,
+		Inner:    testEncodeInner{A: 1, B: "inner", C: []uint64{1, 2}},
+		InnerPtr: testEncodeInner{A: 2, B: "", C: []uint64{3}},
+		Strings:  [][]string{{"a", "bb", "ccc"}},
+		Fixed:    [2][2]string{{"w", "x"}, {"y", "z"}},
+		RawSize:  3,
+		Raw:      testEncodeRawBytes{7, 8, 9},
+		Union:    testUnionNonEncapsulated{Tag: 1, Value1: 5},
+	}
+	// Larger than the bufio buffer so that it is refilled part way through
+	l := testLargeStruct{A: 1, B: make([]uint16, 3001), C: 2, D: 3, E: "large"}
+	for i := range l.B {
+		l.B[i] = uint16(i)
+	}
+	readers := []struct {
+		name string
+		r    func([]byte) io.Reader
+	}{
+		{"OneByteReader", func(b []byte) io.Reader { return iotest.OneByteReader(bytes.NewReader(b)) }},
+		{"HalfReader", func(b []byte) io.Reader { return iotest.HalfReader(bytes.NewReader(b)) }},
+		{"DataErrReader", func(b []byte) io.Reader { return iotest.DataErrReader(bytes.NewReader(b)) }},
+		{"MultiReader", func(b []byte) io.Reader { return chunkReader(b, 7) }},
+	}
+	var tests = []struct {
+		v   interface{}
+		new func() interface{}
+	}{
+		{s, func() interface{} { return new(testEncodeStruct) }},
+		{l, func() interface{} { return new(testLargeStruct) }},
+	}
+	for i, test := range tests {
+		var buf bytes.Buffer
+		err := NewEncoder(&buf).Encode(test.v)
+		if err != nil {
+			t.Fatalf("error encoding test %d: %v", i+1, err)
+		}
+		sliceDec := NewDecoderBytes(buf.Bytes())
+		sliceDec.Annotate(true)
+		err = sliceDec.Decode(test.new())
+		if err != nil {
+			t.Fatalf("error decoding test %d from slice: %v", i+1, err)
+		}
+		for _, r := range readers {
+			d := test.new()
+			dec := NewDecoder(r.r(buf.Bytes()))
+			dec.Annotate(true)
+			err = dec.Decode(d)
+			if err != nil {
+				t.Errorf("error decoding test %d with %s: %v", i+1, r.name, err)
+				continue
+			}
+			assert.Equal(t, test.v, reflect.ValueOf(d).Elem().Interface(), "decoded value not as expected for test %d with %s", i+1, r.name)
+			assert.Equal(t, sliceDec.Annotations(), dec.Annotations(), "annotations not as expected for test %d with %s", i+1, r.name)
+		}
+	}
+}
+
+func TestDecodeReadersOverRun(t *testing.T) {
+	b, _ := hex.DecodeString("01100800cccccccca00400000000000000000200d186660f")
+	err := NewDecoder(iotest.OneByteReader(bytes.NewReader(b))).Decode(new(SimpleTest))
+	assert.Error(t, err, "expected error for trying to read more than the bytes we have")
+}
@@ -0,0 +1,375 @@
+package ndr
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// TagNoTerminator is a struct tag value, used only when encoding, for strings that are written without a null
+// terminator, as the Buffer of an RPC_UNICODE_STRING is.
+const TagNoTerminator = "noTerminator"
+
+// TagSizeIs is a struct tag key, used only when encoding, naming the integer field of the enclosing struct, optionally
+// followed by a divisor, that holds the conformant max count of a string, as the IDL size_is attribute does.
+// For example `ndr:"pointer,conformant,varying,sizeIs:MaximumLength/2"`.
+// The max count written is never less than the number of characters written.
+const TagSizeIs = "sizeIs"
+
+// tagMaxCount is the struct tag key holding the max count resolved from a TagSizeIs tag.
+const tagMaxCount = "maxCount"
+
+// referentStart is the first value used for the referent IDs of pointers. This is the value used by Windows.
+const referentStart uint32 = 0x00020000
+
+// Encoder marshals Go data structures into an NDR byte stream.
+// The structure is marshalled in the same way the Decoder unmarshals it, driven by the same struct tags.
+// Strings are marshalled as null terminated arrays of UTF-16 characters, unless tagged with TagNoTerminator.
+type Encoder struct {
+	w        io.Writer // destination of the data
+	buf      []byte    // the NDR byte stream being built
+	referent uint32    // the last referent ID assigned to a pointer
+	counting bool      // only the number of pointers is being established
+	current  []string  // keeps track of the current field being marshalled
+}
+
+// deferredReferent is a pointer's referent that is written after the structure containing the pointer.
+// As Windows does, referent IDs are assigned to pointers in depth first order so the IDs of the pointers within the
+// referent follow on from the ID of the pointer to it.
+type deferredReferent struct {
+	deferedPtr
+	referent uint32
+}
+
+// NewEncoder creates a new instance of a NDR Encoder.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode marshals the struct, or pointer to a struct, provided into NDR encoded bytes and writes them to the
+// Encoder's writer.
+func (enc *Encoder) Encode(s interface{}) error {
+	v := reflect.ValueOf(s)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("cannot encode %s, a struct is required", v.Kind())
+	}
+	enc.buf = enc.buf[:0]
+	enc.current = enc.current[:0]
+	enc.writeCommonHeader()
+	enc.writePrivateHeader()
+	// The top level type is marshalled as the referent of an RPC unique pointer.
+	enc.referent = referentStart
+	enc.writeUint32(enc.referent)
+	err := enc.process(v, reflect.StructTag(""))
+	if err != nil {
+		return err
+	}
+	// The object buffer length includes padding to a multiple of 8 bytes.
+	enc.ensureAlignment(SizeUint64)
+	binary.LittleEndian.PutUint32(enc.buf[commonHeaderBytes:], uint32(len(enc.buf)-int(commonHeaderBytes)*2))
+	_, err = enc.w.Write(enc.buf)
+	if err != nil {
+		return fmt.Errorf("could not write NDR byte stream: %v", err)
+	}
+	return nil
+}
+
+func (enc *Encoder) process(v reflect.Value, tag reflect.StructTag) error {
+	// The max counts of embedded conformant fields are moved to the beginning of the structure
+	// http://pubs.opengroup.org/onlinepubs/9629399/chap14.htm#tagfcjh_37
+	for _, m := range conformantMaxCounts(v, tag) {
+		enc.writeUint32(m)
+	}
+	// Recursively write the struct fields
+	var localDef []deferredReferent
+	err := enc.write(v, tag, &localDef)
+	if err != nil {
+		return fmt.Errorf("could not encode: %v", err)
+	}
+	// Write any deferred referents associated with pointers
+	for _, p := range localDef {
+		if enc.counting {
+			err = enc.process(p.v, p.tag)
+		} else {
+			r := enc.referent
+			enc.referent = p.referent
+			err = enc.process(p.v, p.tag)
+			enc.referent = r
+		}
+		if err != nil {
+			return fmt.Errorf("could not encode deferred referent: %v", err)
+		}
+	}
+	return nil
+}
+
+// conformantMaxCounts returns the maximum element counts of the embedded conformant fields that are moved to the
+// beginning of the structure. The fields are found in the same way as the Decoder's conformantScan.
+func conformantMaxCounts(v reflect.Value, tag reflect.StructTag) (m []uint32) {
+	ndrTag := parseTags(tag)
+	if ndrTag.HasValue(TagPointer) {
+		return
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			// An invalid sizeIs tag is reported when the field is written.
+			t, _ := resolveSizeIs(v, v.Type().Field(i).Tag)
+			m = append(m, conformantMaxCounts(v.Field(i), t)...)
+		}
+	case reflect.String:
+		if !ndrTag.HasValue(TagConformant) {
+			break
+		}
+		n := uint64(len(stringUnits(v.String(), ndrTag)))
+		if c, err := strconv.ParseUint(ndrTag.Map[tagMaxCount], 10, 32); err == nil && c > n {
+			n = c
+		}
+		m = append(m, uint32(n))
+	case reflect.Slice:
+		if !ndrTag.HasValue(TagConformant) {
+			break
+		}
+		d, t := sliceDimensions(v.Type())
+		for _, l := range sliceLengths(v, d) {
+			m = append(m, uint32(l))
+		}
+		// For string arrays there is a common max for the strings within the array.
+		if t.Kind() == reflect.String {
+			m = append(m, uint32(maxStringLength(v)))
+		}
+	}
+	return
+}
+
+// resolveSizeIs returns the tag of a field of the struct with any TagSizeIs value replaced by the max count it refers
+// to, so the max count is available when the field is written as a deferred referent.
+func resolveSizeIs(s reflect.Value, tag reflect.StructTag) (reflect.StructTag, error) {
+	ndrTag := parseTags(tag)
+	f, ok := ndrTag.Map[TagSizeIs]
+	if !ok {
+		return tag, nil
+	}
+	d := uint64(1)
+	if i := strings.Index(f, "/"); i >= 0 {
+		n, err := strconv.ParseUint(f[i+1:], 10, 32)
+		if err != nil || n == 0 {
+			return tag, fmt.Errorf("%s tag value %s not valid", TagSizeIs, f)
+		}
+		d = n
+		f = f[:i]
+	}
+	sf := s.FieldByName(f)
+	var c uint64
+	switch sf.Kind() {
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		c = sf.Uint()
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if sf.Int() > 0 {
+			c = uint64(sf.Int())
+		}
+	default:
+		return tag, fmt.Errorf("%s tag value %s does not refer to an integer field", TagSizeIs, f)
+	}
+	ndrTag.delete(TagSizeIs)
+	ndrTag.set(tagMaxCount, strconv.FormatUint(c/d, 10))
+	return ndrTag.StructTag(), nil
+}
+
+// isPointer writes the referent ID of a pointer and defers writing the referent. A zero value is written as a null
+// pointer other than for strings, which are always written, and non-nil slices, which are written even if empty.
+func (enc *Encoder) isPointer(v reflect.Value, tag reflect.StructTag, def *[]deferredReferent) (bool, error) {
+	ndrTag := parseTags(tag)
+	if !ndrTag.HasValue(TagPointer) {
+		return false, nil
+	}
+	var null bool
+	switch v.Kind() {
+	case reflect.String:
+	case reflect.Slice, reflect.Map, reflect.Ptr, reflect.Interface:
+		null = v.IsNil()
+	default:
+		null = v.IsZero()
+	}
+	if null {
+		enc.writeUint32(0)
+		return true, nil
+	}
+	enc.referent += SizePtr
+	enc.writeUint32(enc.referent)
+	ndrTag.delete(TagPointer)
+	p := deferredReferent{deferedPtr{v: v, tag: ndrTag.StructTag()}, enc.referent}
+	if !enc.counting {
+		// Reserve the referent IDs of the pointers within the referent.
+		n, err := countPointers(p.v, p.tag)
+		if err != nil {
+			return true, err
+		}
+		enc.referent += n * SizePtr
+	}
+	*def = append(*def, p)
+	return true, nil
+}
+
+// countPointers returns the number of non-null pointers that will be written when encoding the value.
+func countPointers(v reflect.Value, tag reflect.StructTag) (uint32, error) {
+	enc := &Encoder{counting: true}
+	err := enc.process(v, tag)
+	return enc.referent / SizePtr, err
+}
+
+// write marshals the value into the NDR byte stream.
+func (enc *Encoder) write(v reflect.Value, tag reflect.StructTag, localDef *[]deferredReferent) error {
+	// Pointer so defer writing the referent
+	ptr, err := enc.isPointer(v, tag, localDef)
+	if err != nil {
+		return fmt.Errorf("could not process struct field(%s): %v", strings.Join(enc.current, "/"), err)
+	}
+	if ptr {
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		enc.current = append(enc.current, v.Type().Name()) //Track the current field being written
+		// in case struct is a union, track this and the selected union field
+		var unionTag reflect.Value
+		var unionField string // field to write if struct is a union
+		for i := 0; i < v.NumField(); i++ {
+			fieldName := v.Type().Field(i).Name
+			enc.current = append(enc.current, fieldName) //Track the current field being written
+			structTag, err := resolveSizeIs(v, v.Type().Field(i).Tag)
+			if err != nil {
+				return fmt.Errorf("could not process struct field(%s): %v", strings.Join(enc.current, "/"), err)
+			}
+			ndrTag := parseTags(structTag)
+
+			// Union handling
+			if !unionTag.IsValid() {
+				// Is this field a union tag?
+				unionTag = enc.isUnion(v.Field(i), structTag)
+			} else {
+				// What is the selected field value of the union if we don't already know
+				if unionField == "" {
+					unionField, err = unionSelectedField(v, unionTag)
+					if err != nil {
+						return fmt.Errorf("could not determine selected union value field for %s with discriminat"+
+							" tag %v: %v", v.Type().Name(), unionTag, err)
+					}
+				}
+				if ndrTag.HasValue(TagUnionField) && fieldName != unionField {
+					// is a union and this field has not been selected so will skip it.
+					enc.current = enc.current[:len(enc.current)-1]
+					continue
+				}
+			}
+
+			if v.Field(i).Type().Implements(reflect.TypeOf(new(RawBytes)).Elem()) &&
+				v.Field(i).Type().Kind() == reflect.Slice && v.Field(i).Type().Elem().Kind() == reflect.Uint8 {
+				//field is for rawbytes
+				ptr, err := enc.isPointer(v.Field(i), structTag, localDef)
+				if err != nil {
+					return fmt.Errorf("could not process struct field(%s): %v", strings.Join(enc.current, "/"), err)
+				}
+				if !ptr {
+					enc.writeRawBytes(v.Field(i))
+				}
+			} else {
+				err := enc.write(v.Field(i), structTag, localDef)
+				if err != nil {
+					return fmt.Errorf("could not write struct field(%s): %v", strings.Join(enc.current, "/"), err)
+				}
+			}
+			enc.current = enc.current[:len(enc.current)-1] //This field has been written so remove it from the current field tracker
+		}
+		enc.current = enc.current[:len(enc.current)-1]
+	case reflect.Bool:
+		enc.writeBool(v.Bool())
+	case reflect.Uint8:
+		enc.writeUint8(uint8(v.Uint()))
+	case reflect.Uint16:
+		enc.writeUint16(uint16(v.Uint()))
+	case reflect.Uint32:
+		enc.writeUint32(uint32(v.Uint()))
+	case reflect.Uint64:
+		enc.writeUint64(v.Uint())
+	case reflect.Int8:
+		enc.writeUint8(uint8(v.Int()))
+	case reflect.Int16:
+		enc.writeUint16(uint16(v.Int()))
+	case reflect.Int32:
+		enc.writeUint32(uint32(v.Int()))
+	case reflect.Int64:
+		enc.writeUint64(uint64(v.Int()))
+	case reflect.String:
+		ndrTag := parseTags(tag)
+		// strings are always varying so this is assumed without an explicit tag
+		a := stringUnits(v.String(), ndrTag)
+		if ndrTag.HasValue(TagConformant) {
+			enc.writeConformantVaryingString(a)
+		} else {
+			enc.writeVaryingString(a)
+		}
+	case reflect.Float32:
+		enc.writeFloat32(float32(v.Float()))
+	case reflect.Float64:
+		enc.writeFloat64(v.Float())
+	case reflect.Array:
+		err := enc.writeFixedArray(v, tag, localDef)
+		if err != nil {
+			return err
+		}
+	case reflect.Slice:
+		if v.Type().Implements(reflect.TypeOf(new(RawBytes)).Elem()) && v.Type().Elem().Kind() == reflect.Uint8 {
+			//field is for rawbytes
+			enc.writeRawBytes(v)
+			break
+		}
+		ndrTag := parseTags(tag)
+		conformant := ndrTag.HasValue(TagConformant)
+		varying := ndrTag.HasValue(TagVarying)
+		if ndrTag.HasValue(TagPipe) {
+			err := enc.writePipe(v, tag)
+			if err != nil {
+				return err
+			}
+			break
+		}
+		_, t := sliceDimensions(v.Type())
+		if t.Kind() == reflect.String && !ndrTag.HasValue(subStringArrayValue) {
+			// String array
+			err := enc.writeStringsArray(v, localDef)
+			if err != nil {
+				return err
+			}
+			break
+		}
+		// varying is assumed as fixed arrays use the Go array type rather than slice
+		if conformant && varying {
+			err := enc.writeConformantVaryingArray(v, tag, localDef)
+			if err != nil {
+				return err
+			}
+		} else if !conformant && varying {
+			err := enc.writeVaryingArray(v, tag, localDef)
+			if err != nil {
+				return err
+			}
+		} else {
+			//default to conformant and not varying
+			err := enc.writeConformantArray(v, tag, localDef)
+			if err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported type %s", v.Kind())
+	}
+	return nil
+}
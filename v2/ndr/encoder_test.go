@@ -0,0 +1,187 @@
+package ndr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testEncodeStr is TestStr as a varying string: offset(0):actual count(13):data
+const testEncodeStr = "000000000d000000" + TestStrUTF16Hex
+
+// testEncodeUint32s returns the hex encoding of the uint32 values 1 to n.
+func testEncodeUint32s(n int) string {
+	b := make([]byte, 4*n)
+	for i := 0; i < n; i++ {
+		binary.LittleEndian.PutUint32(b[4*i:], uint32(i+1))
+	}
+	return hex.EncodeToString(b)
+}
+
+// Test_EncodeRoundTrip decodes each test vector, encodes the result and checks the bytes are the same as the vector.
+func Test_EncodeRoundTrip(t *testing.T) {
+	var tests = []struct {
+		Hex string
+		V   interface{}
+	}{
+		{"d186660f656ac601", new(SimpleTest)},
+		// Referent IDs are assigned depth first, the referents are written breadth first.
+		{"04000200" + "01000000" + "08000200" + "10000200" + "03000000" + "0c000200" + "05000000" + "04000000" + "02000000", new(testEmbeddingPointer)},
+		{"01000000020000000300000004000000", new(StructWithArray)},
+		{testEncodeUint32s(12), new(StructWithMultiDimArray)},
+		{"0400000001000000020000000300000004000000", new(StructWithConformantSlice)},
+		{"02000000" + "03000000" + "02000000" + testEncodeUint32s(12), new(StructWithMultiDimensionalConformantSlice)},
+		{"000000000400000001000000020000000300000004000000", new(StructWithVaryingSlice)},
+		{"0000000002000000" + "0000000003000000" + "0000000002000000" + testEncodeUint32s(12), new(StructWithMultiDimensionalVaryingSlice)},
+		{"04000000000000000400000001000000020000000300000004000000", new(StructWithConformantVaryingSlice)},
+		{"02000000" + "03000000" + "02000000" + "0000000002000000" + "0000000003000000" + "0000000002000000" + testEncodeUint32s(12), new(StructWithMultiDimensionalConformantVaryingSlice)},
+		{testEncodeStr, new(TestStructWithVaryingString)},
+		{"0d000000" + testEncodeStr, new(TestStructWithConformantVaryingString)},
+		{"04000000" + "0d000000" + "0000000004000000" + testEncodeStr + "0000" + testEncodeStr + "0000" + testEncodeStr + "0000" + testEncodeStr, new(TestStructWithConformantVaryingStringUniArray)},
+		{"0000000004000000" + testEncodeStr + "0000" + testEncodeStr + "0000" + testEncodeStr + "0000" + testEncodeStr, new(TestStructWithNonConformantStringUniArray)},
+		{testEncodeStr + "0000" + testEncodeStr + "0000" + testEncodeStr + "0000" + testEncodeStr, new(TestStructWithFixedStringUniArray)},
+		{testUnionSelected1Enc, new(testUnionEncapsulated)},
+		{testUnionSelected2Enc, new(testUnionEncapsulated)},
+		{testUnionSelected1NonEnc, new(testUnionNonEncapsulated)},
+		{testUnionSelected2NonEnc, new(testUnionNonEncapsulated)},
+		// The pipe is encoded as a single chunk.
+		{"07000000" + "01000000020000000300000004000000" + "010000000200000003000000" + "00000000", new(structWithPipe)},
+	}
+	for i, test := range tests {
+		b, _ := hex.DecodeString(TestHeader + test.Hex)
+		err := NewDecoder(bytes.NewReader(b)).Decode(test.V)
+		if err != nil {
+			t.Fatalf("error decoding test %d: %v", i, err)
+		}
+		var buf bytes.Buffer
+		err = NewEncoder(&buf).Encode(test.V)
+		if err != nil {
+			t.Fatalf("error encoding test %d: %v", i, err)
+		}
+		e := buf.Bytes()
+		if len(e) < 20+len(test.Hex)/2 {
+			t.Errorf("encoded bytes shorter than expected for test %d: %x", i, e)
+			continue
+		}
+		assert.Equal(t, "01100800cccccccc", hex.EncodeToString(e[:8]), "common header not as expected for test %d", i)
+		assert.Equal(t, 0, (len(e)-16)%8, "object buffer not padded for test %d", i)
+		assert.Equal(t, uint32(len(e)-16), binary.LittleEndian.Uint32(e[8:12]), "object buffer length not as expected for test %d", i)
+		assert.Equal(t, "00000200"+test.Hex, hex.EncodeToString(e[16:20+len(test.Hex)/2]), "encoded bytes not as expected for test %d", i)
+		assert.Equal(t, make([]byte, len(e)-20-len(test.Hex)/2), e[20+len(test.Hex)/2:], "padding not as expected for test %d", i)
+	}
+}
+
+type testEncodeRawBytes []byte
+
+func (b testEncodeRawBytes) Size(s interface{}) int {
+	return int(s.(testEncodeStruct).RawSize)
+}
+
+type testEncodeInner struct {
+	A uint16
+	B string   `ndr:"pointer,conformant,varying"`
+	C []uint64 `ndr:"pointer,conformant"`
+}
+
+type testEncodeStruct struct {
+	Bool       bool
+	Int8       int8
+	Int16      int16
+	Int32      int32
+	Int64      int64
+	Float32    float32
+	Float64    float64
+	Inner      testEncodeInner
+	InnerPtr   testEncodeInner   `ndr:"pointer"`
+	NullPtr    testEncodeInner   `ndr:"pointer"`
+	Inners     []testEncodeInner `ndr:"pointer,conformant"`
+	NilSlice   []uint32          `ndr:"pointer,conformant"`
+	EmptySlice []uint32          `ndr:"pointer,conformant"`
+	Strings    [][]string        `ndr:"conformant,varying"`
+	Fixed      [2][2]string
+	RawSize    uint32
+	Raw        testEncodeRawBytes
+	Union      testUnionNonEncapsulated
+}
+
+func Test_EncodeDecode(t *testing.T) {
+	s := testEncodeStruct{
+		Bool:       true,
+		Int8:       -1,
+		Int16:      -2,
+		Int32:      -3,
+		Int64:      -4,
+		Float32:    1.5,
+		Float64:    -2.25,
+		Inner:      testEncodeInner{A: 1, B: "inner", C: []uint64{1, 2}},
+		InnerPtr:   testEncodeInner{A: 2, B: "", C: []uint64{3}},
+		Inners:     []testEncodeInner{{A: 3, B: "a"}, {A: 4, B: "bc", C: []uint64{}}},
+		EmptySlice: []uint32{},
+		Strings:    [][]string{{"a", "bb", "ccc"}, {"dddd", "", "f"}},
+		Fixed:      [2][2]string{{"w", "x"}, {"y", "z"}},
+		RawSize:    3,
+		Raw:        testEncodeRawBytes{7, 8, 9},
+		Union:      testUnionNonEncapsulated{Tag: 2, Value2: 5},
+	}
+	var buf bytes.Buffer
+	err := NewEncoder(&buf).Encode(s)
+	if err != nil {
+		t.Fatalf("error encoding: %v", err)
+	}
+	d := new(testEncodeStruct)
+	err = NewDecoder(bytes.NewReader(buf.Bytes())).Decode(d)
+	if err != nil {
+		t.Fatalf("error decoding: %v", err)
+	}
+	assert.Equal(t, s, *d, "decoded value not as expected")
+}
+
+func Test_EncodeNotStruct(t *testing.T) {
+	var buf bytes.Buffer
+	err := NewEncoder(&buf).Encode(uint32(1))
+	assert.Error(t, err, "expected error encoding a value that is not a struct")
+	assert.Equal(t, 0, buf.Len(), "no bytes should be written on error")
+}
+
+type testEncodeCountedString struct {
+	Length        uint16
+	MaximumLength uint16
+	Value         string `ndr:"pointer,conformant,varying,noTerminator,sizeIs:MaximumLength/2"`
+}
+
+type testEncodeBadSizeIs struct {
+	Value string `ndr:"pointer,conformant,varying,sizeIs:Missing"`
+}
+
+func Test_EncodeStringTags(t *testing.T) {
+	var tests = []struct {
+		In  testEncodeCountedString
+		Hex string
+	}{
+		{testEncodeCountedString{4, 4, "ab"}, "040004000400020002000000000000000200000061006200"},
+		{testEncodeCountedString{4, 8, "ab"}, "040008000400020004000000000000000200000061006200"},
+		// The max count is not less than the characters written.
+		{testEncodeCountedString{4, 2, "ab"}, "040002000400020002000000000000000200000061006200"},
+	}
+	for i, test := range tests {
+		var buf bytes.Buffer
+		err := NewEncoder(&buf).Encode(test.In)
+		if err != nil {
+			t.Fatalf("error encoding test %d: %v", i, err)
+		}
+		b := buf.Bytes()
+		assert.Equal(t, test.Hex, hex.EncodeToString(b[len(TestHeader)/2:len(TestHeader)/2+len(test.Hex)/2]), "encoding not as expected for test %d", i)
+		var s testEncodeCountedString
+		err = NewDecoder(bytes.NewReader(b)).Decode(&s)
+		if err != nil {
+			t.Fatalf("error decoding test %d: %v", i, err)
+		}
+		assert.Equal(t, test.In, s, "decoded value not as expected for test %d", i)
+	}
+	var buf bytes.Buffer
+	err := NewEncoder(&buf).Encode(testEncodeBadSizeIs{"ab"})
+	assert.Error(t, err, "expected error encoding with a sizeIs tag referring to a missing field")
+}
@@ -54,7 +54,7 @@ type PrivateHeader struct {
 
 func (dec *Decoder) readCommonHeader() error {
 	// Version
-	vb, err := dec.r.ReadByte()
+	vb, err := dec.readUint8()
 	if err != nil {
 		return Malformed{EText: "could not read first byte of common header for version"}
 	}
@@ -63,7 +63,7 @@ func (dec *Decoder) readCommonHeader() error {
 		return Malformed{EText: fmt.Sprintf("byte stream does not indicate a RPC Type serialization of version %v", protocolVersion)}
 	}
 	// Read Endianness & Character Encoding
-	eb, err := dec.r.ReadByte()
+	eb, err := dec.readUint8()
 	if err != nil {
 		return Malformed{EText: "could not read second byte of common header for endianness"}
 	}
@@ -100,10 +100,11 @@ func (dec *Decoder) readCommonHeader() error {
 
 func (dec *Decoder) readPrivateHeader() error {
 	// The next 8 bytes after the common header comprise the RPC type marshalling private header for constructed types.
-	err := binary.Read(dec.r, dec.ch.Endianness, &dec.ph.ObjectBufferLength)
+	b, err := dec.next(SizeUint32)
 	if err != nil {
 		return Malformed{EText: "could not read private header object buffer length"}
 	}
+	dec.ph.ObjectBufferLength = dec.ch.Endianness.Uint32(b)
 	if dec.ph.ObjectBufferLength%8 != 0 {
 		return Malformed{EText: "object buffer length not a multiple of 8"}
 	}
@@ -114,3 +115,17 @@ func (dec *Decoder) readPrivateHeader() error {
 	}
 	return nil
 }
+
+// writeCommonHeader writes the common header for a little endian byte stream with ASCII character encoding.
+func (enc *Encoder) writeCommonHeader() {
+	enc.buf = append(enc.buf, protocolVersion, littleEndian<<4|ascii)
+	var b [2]byte
+	binary.LittleEndian.PutUint16(b[:], commonHeaderBytes)
+	enc.buf = append(enc.buf, b[0], b[1], 0xcc, 0xcc, 0xcc, 0xcc)
+}
+
+// writePrivateHeader writes the private header with an object buffer length of zero which is set once the length of
+// the serialized type is known.
+func (enc *Encoder) writePrivateHeader() {
+	enc.buf = append(enc.buf, make([]byte, 8)...)
+}
@@ -10,6 +10,7 @@ func (dec *Decoder) fillPipe(v reflect.Value, tag reflect.StructTag) error {
 	if err != nil {
 		return err
 	}
+	dec.note(NoteActualCount, SizeUint32, s)
 	a := reflect.MakeSlice(v.Type(), 0, 0)
 	c := reflect.MakeSlice(v.Type(), int(s), int(s))
 	for s != 0 {
@@ -23,9 +24,25 @@ func (dec *Decoder) fillPipe(v reflect.Value, tag reflect.StructTag) error {
 		if err != nil {
 			return err
 		}
+		dec.note(NoteActualCount, SizeUint32, s)
 		a = reflect.AppendSlice(a, c)
 		c = reflect.MakeSlice(v.Type(), int(s), int(s))
 	}
 	v.Set(a)
 	return nil
 }
+
+// writePipe writes the elements of the slice as a single chunk followed by the empty chunk that ends the pipe.
+func (enc *Encoder) writePipe(v reflect.Value, tag reflect.StructTag) error {
+	if v.Len() > 0 {
+		enc.writeUint32(uint32(v.Len()))
+		for i := 0; i < v.Len(); i++ {
+			err := enc.write(v.Index(i), tag, &[]deferredReferent{})
+			if err != nil {
+				return fmt.Errorf("could not write element %d of pipe: %v", i, err)
+			}
+		}
+	}
+	enc.writeUint32(0)
+	return nil
+}
@@ -1,7 +1,6 @@
 package ndr
 
 import (
-	"bytes"
 	"encoding/binary"
 	"math"
 )
@@ -78,17 +77,17 @@ func (dec *Decoder) readChar() (rune, error) {
 
 // readUint8 reads bytes representing a 8bit unsigned integer.
 func (dec *Decoder) readUint8() (uint8, error) {
-	b, err := dec.r.ReadByte()
+	b, err := dec.next(SizeUint8)
 	if err != nil {
 		return uint8(0), err
 	}
-	return uint8(b), nil
+	return uint8(b[0]), nil
 }
 
 // readUint16 reads bytes representing a 16bit unsigned integer.
 func (dec *Decoder) readUint16() (uint16, error) {
 	dec.ensureAlignment(SizeUint16)
-	b, err := dec.readBytes(SizeUint16)
+	b, err := dec.next(SizeUint16)
 	if err != nil {
 		return uint16(0), err
 	}
@@ -98,7 +97,7 @@ func (dec *Decoder) readUint16() (uint16, error) {
 // readUint32 reads bytes representing a 32bit unsigned integer.
 func (dec *Decoder) readUint32() (uint32, error) {
 	dec.ensureAlignment(SizeUint32)
-	b, err := dec.readBytes(SizeUint32)
+	b, err := dec.next(SizeUint32)
 	if err != nil {
 		return uint32(0), err
 	}
@@ -108,77 +107,53 @@ func (dec *Decoder) readUint32() (uint32, error) {
 // readUint32 reads bytes representing a 32bit unsigned integer.
 func (dec *Decoder) readUint64() (uint64, error) {
 	dec.ensureAlignment(SizeUint64)
-	b, err := dec.readBytes(SizeUint64)
+	b, err := dec.next(SizeUint64)
 	if err != nil {
 		return uint64(0), err
 	}
 	return dec.ch.Endianness.Uint64(b), nil
 }
 
+// readInt8 reads bytes representing a 8bit signed integer.
 func (dec *Decoder) readInt8() (int8, error) {
-	dec.ensureAlignment(SizeUint8)
-	b, err := dec.readBytes(SizeUint8)
-	if err != nil {
-		return 0, err
-	}
-	var i int8
-	buf := bytes.NewReader(b)
-	err = binary.Read(buf, dec.ch.Endianness, &i)
+	i, err := dec.readUint8()
 	if err != nil {
 		return 0, err
 	}
-	return i, nil
+	return int8(i), nil
 }
 
+// readInt16 reads bytes representing a 16bit signed integer.
 func (dec *Decoder) readInt16() (int16, error) {
-	dec.ensureAlignment(SizeUint16)
-	b, err := dec.readBytes(SizeUint16)
+	i, err := dec.readUint16()
 	if err != nil {
 		return 0, err
 	}
-	var i int16
-	buf := bytes.NewReader(b)
-	err = binary.Read(buf, dec.ch.Endianness, &i)
-	if err != nil {
-		return 0, err
-	}
-	return i, nil
+	return int16(i), nil
 }
 
+// readInt32 reads bytes representing a 32bit signed integer.
 func (dec *Decoder) readInt32() (int32, error) {
-	dec.ensureAlignment(SizeUint32)
-	b, err := dec.readBytes(SizeUint32)
+	i, err := dec.readUint32()
 	if err != nil {
 		return 0, err
 	}
-	var i int32
-	buf := bytes.NewReader(b)
-	err = binary.Read(buf, dec.ch.Endianness, &i)
-	if err != nil {
-		return 0, err
-	}
-	return i, nil
+	return int32(i), nil
 }
 
+// readInt64 reads bytes representing a 64bit signed integer.
 func (dec *Decoder) readInt64() (int64, error) {
-	dec.ensureAlignment(SizeUint64)
-	b, err := dec.readBytes(SizeUint64)
+	i, err := dec.readUint64()
 	if err != nil {
 		return 0, err
 	}
-	var i int64
-	buf := bytes.NewReader(b)
-	err = binary.Read(buf, dec.ch.Endianness, &i)
-	if err != nil {
-		return 0, err
-	}
-	return i, nil
+	return int64(i), nil
 }
 
 // https://en.wikipedia.org/wiki/IEEE_754-1985
 func (dec *Decoder) readFloat32() (f float32, err error) {
 	dec.ensureAlignment(SizeSingle)
-	b, err := dec.readBytes(SizeSingle)
+	b, err := dec.next(SizeSingle)
 	if err != nil {
 		return
 	}
@@ -189,7 +164,7 @@ func (dec *Decoder) readFloat32() (f float32, err error) {
 
 func (dec *Decoder) readFloat64() (f float64, err error) {
 	dec.ensureAlignment(SizeDouble)
-	b, err := dec.readBytes(SizeDouble)
+	b, err := dec.next(SizeDouble)
 	if err != nil {
 		return
 	}
@@ -204,8 +179,62 @@ func (dec *Decoder) readFloat64() (f float64, err error) {
 // stream. Where necessary, an alignment gap, consisting of octets of unspecified value, precedes the representation
 // of a primitive. The gap is of the smallest size sufficient to align the primitive.
 func (dec *Decoder) ensureAlignment(n int) {
-	p := dec.size - dec.r.Buffered()
+	p := dec.position()
 	if s := p % n; s != 0 {
-		dec.r.Discard(n - s)
+		dec.discard(n - s)
+		dec.note(NotePadding, n-s, nil)
+	}
+}
+
+// writeBool writes a byte representing a boolean.
+func (enc *Encoder) writeBool(b bool) {
+	if b {
+		enc.writeUint8(1)
+		return
+	}
+	enc.writeUint8(0)
+}
+
+// writeUint8 writes a 8bit unsigned integer.
+func (enc *Encoder) writeUint8(i uint8) {
+	enc.buf = append(enc.buf, i)
+}
+
+// writeUint16 writes a 16bit unsigned integer.
+func (enc *Encoder) writeUint16(i uint16) {
+	enc.ensureAlignment(SizeUint16)
+	var b [SizeUint16]byte
+	binary.LittleEndian.PutUint16(b[:], i)
+	enc.buf = append(enc.buf, b[:]...)
+}
+
+// writeUint32 writes a 32bit unsigned integer.
+func (enc *Encoder) writeUint32(i uint32) {
+	enc.ensureAlignment(SizeUint32)
+	var b [SizeUint32]byte
+	binary.LittleEndian.PutUint32(b[:], i)
+	enc.buf = append(enc.buf, b[:]...)
+}
+
+// writeUint64 writes a 64bit unsigned integer.
+func (enc *Encoder) writeUint64(i uint64) {
+	enc.ensureAlignment(SizeUint64)
+	var b [SizeUint64]byte
+	binary.LittleEndian.PutUint64(b[:], i)
+	enc.buf = append(enc.buf, b[:]...)
+}
+
+func (enc *Encoder) writeFloat32(f float32) {
+	enc.writeUint32(math.Float32bits(f))
+}
+
+func (enc *Encoder) writeFloat64(f float64) {
+	enc.writeUint64(math.Float64bits(f))
+}
+
+// ensureAlignment writes the alignment gap, as zero octets, needed for a primitive of n octets to be aligned.
+func (enc *Encoder) ensureAlignment(n int) {
+	if s := len(enc.buf) % n; s != 0 {
+		enc.buf = append(enc.buf, make([]byte, n-s)...)
 	}
 }
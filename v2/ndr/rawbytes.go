@@ -14,7 +14,9 @@ const (
 	sizeMethod = "Size"
 )
 
-// RawBytes interface should be implemented if reading just a number of bytes from the NDR stream
+// RawBytes interface should be implemented if reading just a number of bytes from the NDR stream.
+// A RawBytes field with the conformant tag, for example `ndr:"pointer,conformant"` for a size_is pointer, is preceded
+// by a max count which must equal the size.
 type RawBytes interface {
 	Size(interface{}) int
 }
@@ -38,7 +40,7 @@ func addSizeToTag(parent reflect.Value, v reflect.Value, tag reflect.StructTag)
 		return tag, err
 	}
 	ndrTag := parseTags(tag)
-	ndrTag.Map["size"] = strconv.Itoa(size)
+	ndrTag.set("size", strconv.Itoa(size))
 	return ndrTag.StructTag(), nil
 }
 
@@ -52,10 +54,27 @@ func (dec *Decoder) readRawBytes(v reflect.Value, tag reflect.StructTag) error {
 	if err != nil {
 		return fmt.Errorf("size not valid: %v", err)
 	}
-	b, err := dec.readBytes(size)
+	// Conformant raw bytes, such as the referent of a size_is pointer, are preceded by a max count which was read by
+	// the conformant scan. It must agree with the size from the parent struct.
+	if ndrTag.HasValue(TagConformant) {
+		if len(dec.conformantMax) < 1 {
+			return errors.New("conformant max count not available for raw bytes")
+		}
+		if m := dec.precedingMax(); int(m) != size {
+			return fmt.Errorf("conformant max count %d does not match the size %d", m, size)
+		}
+	}
+	b, err := dec.readAliasedBytes(size)
 	if err != nil {
 		return err
 	}
 	v.Set(reflect.ValueOf(b).Convert(v.Type()))
+	if dec.tracing() {
+		dec.note(NoteValue, size, b)
+	}
 	return nil
 }
+
+func (enc *Encoder) writeRawBytes(v reflect.Value) {
+	enc.buf = append(enc.buf, v.Bytes()...)
+}
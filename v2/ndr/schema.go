@@ -0,0 +1,487 @@
+package ndr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Kind is the kind of NDR type a Type describes.
+type Kind int
+
+// Kinds of Type
+const (
+	KindBool Kind = iota + 1
+	KindUint8
+	KindUint16
+	KindUint32
+	KindUint64
+	KindInt8
+	KindInt16
+	KindInt32
+	KindInt64
+	KindFloat32
+	KindFloat64
+	KindString
+	KindArray
+	KindStruct
+	KindUnion
+)
+
+var kindNames = []string{"", "bool", "uint8", "uint16", "uint32", "uint64", "int8", "int16", "int32", "int64",
+	"float32", "float64", "string", "array", "struct", "union"}
+
+// String returns the name of the Kind, which for primitive kinds is the name used in schema text.
+func (k Kind) String() string {
+	if k > 0 && int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return "Kind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// size returns the number of bytes of a primitive kind.
+func (k Kind) size() int {
+	switch k {
+	case KindBool, KindUint8, KindInt8:
+		return SizeUint8
+	case KindUint16, KindInt16:
+		return SizeUint16
+	case KindUint32, KindInt32, KindFloat32:
+		return SizeUint32
+	case KindUint64, KindInt64, KindFloat64:
+		return SizeUint64
+	}
+	return 0
+}
+
+// Type describes an NDR type so that a byte stream can be decoded, with Decoder.DecodeTree, without a Go struct
+// representation of it. Types can be built directly or parsed from schema text with ParseSchema.
+type Type struct {
+	Kind         Kind
+	Name         string  // The name of a struct or union.
+	Fields       []Field // The fields of a struct or the arms of a union.
+	Elem         *Type   // The element type of an array.
+	Len          int     // The length of a fixed array. Zero for an array that is conformant and/or varying.
+	Discriminant Field   // The field holding the discriminant of a union.
+	Encapsulated bool    // Whether a union is encapsulated, in which case the discriminant is only encoded once.
+}
+
+// Field is a field of a struct or an arm of a union.
+// The Tag holds the same values used in ndr struct tags, for example "pointer,conformant,varying".
+// An array with no Len that is not tagged as varying is conformant.
+type Field struct {
+	Name  string
+	Type  *Type    // The type of the field. May be nil for a union arm without a value.
+	Tag   string   // ndr tag values
+	Cases []uint64 // The discriminant values that select a union arm. An arm without cases is the default arm.
+}
+
+// Schema is a set of named struct and union types.
+type Schema struct {
+	types map[string]*Type
+}
+
+// Type returns the struct or union type of the name provided and false if the schema has no such type.
+func (s *Schema) Type(name string) (*Type, bool) {
+	t, ok := s.types[name]
+	return t, ok
+}
+
+// ParseSchema parses IDL like text describing struct and union types into a Schema.
+// Types may be used before they are defined. For example:
+//
+//	struct RPC_UNICODE_STRING {
+//		uint16 Length;
+//		uint16 MaximumLength;
+//		[pointer,conformant,varying] string Value;
+//	};
+//
+//	struct RPC_SID {
+//		uint8 Revision;
+//		uint8 SubAuthorityCount;
+//		uint8 IdentifierAuthority[6];
+//		[conformant] uint32 SubAuthority[];
+//	};
+//
+//	[encapsulated] union VALUE switch (uint16 Type) {
+//		case 1: int64 Int64;
+//		case 3: RPC_UNICODE_STRING String;
+//		default: ;
+//	};
+//
+// Field types are bool, uint8, uint16, uint32, uint64, int8, int16, int32, int64, float32, float64, string or the
+// name of a struct or union. A type followed by "*" is a pointer, the same as the pointer attribute.
+// A field name followed by "[n]" is a fixed array of n elements and by "[]" a conformant and/or varying array.
+// The attributes in square brackets before a field are the ndr tag values pointer, conformant and varying.
+// Comments start with "//" and continue to the end of the line.
+func ParseSchema(text string) (*Schema, error) {
+	toks, err := schemaTokens(text)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse schema: %v", err)
+	}
+	p := schemaParser{toks: toks, types: make(map[string]*Type)}
+	for !p.done() {
+		err = p.definition()
+		if err != nil {
+			return nil, fmt.Errorf("could not parse schema: %v", err)
+		}
+	}
+	for name, t := range p.types {
+		if t.Kind == 0 {
+			return nil, fmt.Errorf("could not parse schema: type %s is used but not defined", name)
+		}
+	}
+	return &Schema{types: p.types}, nil
+}
+
+// schemaToken is a token of schema text along with the line it is on.
+type schemaToken struct {
+	s    string
+	line int
+}
+
+// schemaTokens splits schema text into identifiers, numbers and punctuation.
+func schemaTokens(text string) ([]schemaToken, error) {
+	var toks []schemaToken
+	line := 1
+	r := []rune(text)
+	for i := 0; i < len(r); i++ {
+		c := r[i]
+		switch {
+		case c == '\n':
+			line++
+		case unicode.IsSpace(c):
+		case c == '/' && i+1 < len(r) && r[i+1] == '/':
+			for i < len(r) && r[i] != '\n' {
+				i++
+			}
+			i--
+		case strings.ContainsRune("{}[]();:,*", c):
+			toks = append(toks, schemaToken{string(c), line})
+		case c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c):
+			j := i
+			for j < len(r) && (r[j] == '_' || unicode.IsLetter(r[j]) || unicode.IsDigit(r[j])) {
+				j++
+			}
+			toks = append(toks, schemaToken{string(r[i:j]), line})
+			i = j - 1
+		default:
+			return nil, fmt.Errorf("unexpected character %q on line %d", c, line)
+		}
+	}
+	return toks, nil
+}
+
+type schemaParser struct {
+	toks  []schemaToken
+	pos   int
+	types map[string]*Type
+}
+
+func (p *schemaParser) done() bool {
+	return p.pos >= len(p.toks)
+}
+
+// peek returns the next token without consuming it.
+func (p *schemaParser) peek() string {
+	if p.done() {
+		return ""
+	}
+	return p.toks[p.pos].s
+}
+
+// next consumes and returns the next token.
+func (p *schemaParser) next() (string, error) {
+	if p.done() {
+		return "", p.errorf("unexpected end of schema")
+	}
+	p.pos++
+	return p.toks[p.pos-1].s, nil
+}
+
+// expect consumes the next token which must be the one provided.
+func (p *schemaParser) expect(s string) error {
+	t, err := p.next()
+	if err != nil {
+		return err
+	}
+	if t != s {
+		p.pos--
+		return p.errorf("expected %q but found %q", s, t)
+	}
+	return nil
+}
+
+// ident consumes the next token which must be an identifier.
+func (p *schemaParser) ident() (string, error) {
+	t, err := p.next()
+	if err != nil {
+		return "", err
+	}
+	r := []rune(t)
+	if r[0] != '_' && !unicode.IsLetter(r[0]) {
+		p.pos--
+		return "", p.errorf("expected a name but found %q", t)
+	}
+	return t, nil
+}
+
+func (p *schemaParser) errorf(format string, a ...interface{}) error {
+	line := 0
+	if len(p.toks) > 0 {
+		i := p.pos
+		if i >= len(p.toks) {
+			i = len(p.toks) - 1
+		}
+		line = p.toks[i].line
+	}
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, a...))
+}
+
+// named returns the struct or union type of the name provided, creating it to be defined later if it has not been
+// seen before.
+func (p *schemaParser) named(name string) *Type {
+	t, ok := p.types[name]
+	if !ok {
+		t = &Type{Name: name}
+		p.types[name] = t
+	}
+	return t
+}
+
+// attributes consumes the attributes in square brackets, if there are any.
+func (p *schemaParser) attributes() ([]string, error) {
+	var a []string
+	if p.peek() != "[" {
+		return a, nil
+	}
+	p.pos++
+	for {
+		s, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		a = append(a, s)
+		t, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		if t == "]" {
+			return a, nil
+		}
+		if t != "," {
+			p.pos--
+			return nil, p.errorf("expected \",\" or \"]\" but found %q", t)
+		}
+	}
+}
+
+// definition parses a struct or union definition.
+func (p *schemaParser) definition() error {
+	attrs, err := p.attributes()
+	if err != nil {
+		return err
+	}
+	kw, err := p.next()
+	if err != nil {
+		return err
+	}
+	if kw != "struct" && kw != "union" {
+		p.pos--
+		return p.errorf("expected struct or union but found %q", kw)
+	}
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	if _, ok := primitiveKind(name); ok {
+		return p.errorf("%s is the name of a primitive type", name)
+	}
+	t := p.named(name)
+	if t.Kind != 0 {
+		return p.errorf("type %s is defined more than once", name)
+	}
+	if kw == "struct" {
+		if len(attrs) > 0 {
+			return p.errorf("struct %s cannot have attributes", name)
+		}
+		t.Kind = KindStruct
+		err = p.structBody(t)
+	} else {
+		for _, a := range attrs {
+			if a != TagEncapsulated {
+				return p.errorf("union %s has unknown attribute %s", name, a)
+			}
+			t.Encapsulated = true
+		}
+		t.Kind = KindUnion
+		err = p.unionBody(t)
+	}
+	if err != nil {
+		return err
+	}
+	if p.peek() == ";" {
+		p.pos++
+	}
+	return nil
+}
+
+func (p *schemaParser) structBody(t *Type) error {
+	err := p.expect("{")
+	if err != nil {
+		return err
+	}
+	for p.peek() != "}" {
+		f, err := p.field()
+		if err != nil {
+			return err
+		}
+		t.Fields = append(t.Fields, f)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *schemaParser) unionBody(t *Type) error {
+	err := p.expect("switch")
+	if err != nil {
+		return err
+	}
+	err = p.expect("(")
+	if err != nil {
+		return err
+	}
+	n, err := p.ident()
+	if err != nil {
+		return err
+	}
+	k, ok := primitiveKind(n)
+	if !ok || k == KindString || k == KindFloat32 || k == KindFloat64 {
+		return p.errorf("union %s discriminant must be an integer or bool type", t.Name)
+	}
+	t.Discriminant.Type = &Type{Kind: k}
+	t.Discriminant.Name, err = p.ident()
+	if err != nil {
+		return err
+	}
+	err = p.expect(")")
+	if err != nil {
+		return err
+	}
+	err = p.expect("{")
+	if err != nil {
+		return err
+	}
+	for p.peek() != "}" {
+		var cases []uint64
+		var isDefault bool
+		for p.peek() == "case" || p.peek() == "default" {
+			kw, _ := p.next()
+			if kw == "default" {
+				isDefault = true
+			} else {
+				s, err := p.next()
+				if err != nil {
+					return err
+				}
+				c, err := strconv.ParseUint(s, 0, 64)
+				if err != nil {
+					p.pos--
+					return p.errorf("invalid case value %q", s)
+				}
+				cases = append(cases, c)
+			}
+			err = p.expect(":")
+			if err != nil {
+				return err
+			}
+		}
+		if len(cases) == 0 && !isDefault {
+			return p.errorf("expected case or default but found %q", p.peek())
+		}
+		if isDefault {
+			// The default arm has no cases
+			cases = nil
+		}
+		var f Field
+		if p.peek() == ";" {
+			p.pos++
+		} else {
+			f, err = p.field()
+			if err != nil {
+				return err
+			}
+		}
+		f.Cases = cases
+		t.Fields = append(t.Fields, f)
+	}
+	p.pos++
+	return nil
+}
+
+// field parses a field of a struct or the value of a union arm.
+func (p *schemaParser) field() (f Field, err error) {
+	attrs, err := p.attributes()
+	if err != nil {
+		return
+	}
+	for _, a := range attrs {
+		if a != TagPointer && a != TagConformant && a != TagVarying {
+			err = p.errorf("unknown attribute %s", a)
+			return
+		}
+	}
+	tn, err := p.ident()
+	if err != nil {
+		return
+	}
+	if k, ok := primitiveKind(tn); ok {
+		f.Type = &Type{Kind: k}
+	} else {
+		f.Type = p.named(tn)
+	}
+	if p.peek() == "*" {
+		p.pos++
+		attrs = append(attrs, TagPointer)
+	}
+	f.Name, err = p.ident()
+	if err != nil {
+		return
+	}
+	if p.peek() == "[" {
+		p.pos++
+		a := &Type{Kind: KindArray, Elem: f.Type}
+		if p.peek() != "]" {
+			var s string
+			s, err = p.next()
+			if err != nil {
+				return
+			}
+			a.Len, err = strconv.Atoi(s)
+			if err != nil || a.Len < 1 {
+				p.pos--
+				err = p.errorf("invalid array length %q", s)
+				return
+			}
+		}
+		err = p.expect("]")
+		if err != nil {
+			return
+		}
+		f.Type = a
+	}
+	f.Tag = strings.Join(attrs, ",")
+	err = p.expect(";")
+	return
+}
+
+// primitiveKind returns the Kind of the primitive type name provided and false if it is not a primitive type.
+func primitiveKind(name string) (Kind, bool) {
+	for k := KindBool; k <= KindString; k++ {
+		if kindNames[k] == name {
+			return k, true
+		}
+	}
+	return 0, false
+}
@@ -3,6 +3,7 @@ package ndr
 import (
 	"fmt"
 	"reflect"
+	"unicode/utf16"
 )
 
 const (
@@ -68,3 +69,53 @@ func (dec *Decoder) readStringsArray(v reflect.Value, tag reflect.StructTag, def
 	}
 	return nil
 }
+
+// stringToUint16Slice returns the null terminated UTF-16 representation of a string.
+func stringToUint16Slice(s string) []uint16 {
+	return append(utf16.Encode([]rune(s)), 0)
+}
+
+// stringUnits returns the UTF-16 representation of a string to be written, which is null terminated unless the
+// noTerminator tag value is present.
+func stringUnits(s string, ndrTag tags) []uint16 {
+	if ndrTag.HasValue(TagNoTerminator) {
+		return utf16.Encode([]rune(s))
+	}
+	return stringToUint16Slice(s)
+}
+
+// maxStringLength returns the longest length of the null terminated UTF-16 representations of the strings in a
+// string or array of strings.
+func maxStringLength(v reflect.Value) (n int) {
+	if v.Kind() == reflect.String {
+		return len(stringToUint16Slice(v.String()))
+	}
+	for i := 0; i < v.Len(); i++ {
+		if m := maxStringLength(v.Index(i)); m > n {
+			n = m
+		}
+	}
+	return
+}
+
+func (enc *Encoder) writeVaryingString(a []uint16) {
+	enc.writeUint32(0) // offset
+	enc.writeUint32(uint32(len(a)))
+	for _, c := range a {
+		enc.writeUint16(c)
+	}
+}
+
+func (enc *Encoder) writeConformantVaryingString(a []uint16) {
+	// The max count is written at the beginning of the structure.
+	enc.writeVaryingString(a)
+}
+
+func (enc *Encoder) writeStringsArray(v reflect.Value, def *[]deferredReferent) error {
+	// Any max counts, including the common max for the strings, are written at the beginning of the structure.
+	err := enc.writeVaryingArray(v, reflect.StructTag(subStringArrayTag), def)
+	if err != nil {
+		return fmt.Errorf("could not write string array: %v", err)
+	}
+	return nil
+}
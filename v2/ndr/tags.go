@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
 )
 
 const ndrNameSpace = "ndr"
@@ -13,9 +14,36 @@ type tags struct {
 	Map    map[string]string
 }
 
+// maxCachedTags limits the size of the tag cache. Tags carrying sizes computed while decoding or encoding, such as those
+// of raw bytes, are not bounded in number so once the cache is full further tags are parsed each time.
+const maxCachedTags = 4096
+
+// tagCache holds the tags parsed from each struct tag, as they are parsed for every field decoded or encoded.
+var tagCache = struct {
+	sync.RWMutex
+	m map[reflect.StructTag]tags
+}{m: make(map[reflect.StructTag]tags)}
+
 // parse the struct field tags and extract the ndr related ones.
 // format of tag ndr:"value,key:value1,value2"
+// The tags returned are shared with other callers so must only be modified using the set and delete methods.
 func parseTags(st reflect.StructTag) tags {
+	tagCache.RLock()
+	t, ok := tagCache.m[st]
+	tagCache.RUnlock()
+	if ok {
+		return t
+	}
+	t = parseTagsUncached(st)
+	tagCache.Lock()
+	if len(tagCache.m) < maxCachedTags {
+		tagCache.m[st] = t
+	}
+	tagCache.Unlock()
+	return t
+}
+
+func parseTagsUncached(st reflect.StructTag) tags {
 	s := st.Get(ndrNameSpace)
 	t := tags{
 		Values: []string{},
@@ -50,13 +78,32 @@ func (t *tags) StructTag() reflect.StructTag {
 	return reflect.StructTag(s)
 }
 
+// delete removes the value or key from the tags. New slices and maps are made as the tags may be shared.
 func (t *tags) delete(s string) {
-	for i, x := range t.Values {
-		if x == s {
-			t.Values = append(t.Values[:i], t.Values[i+1:]...)
+	values := make([]string, 0, len(t.Values))
+	for _, x := range t.Values {
+		if x != s {
+			values = append(values, x)
+		}
+	}
+	t.Values = values
+	m := make(map[string]string, len(t.Map))
+	for k, v := range t.Map {
+		if k != s {
+			m[k] = v
 		}
 	}
-	delete(t.Map, s)
+	t.Map = m
+}
+
+// set sets the value of the key in the tags. A new map is made as the tags may be shared.
+func (t *tags) set(key, value string) {
+	m := make(map[string]string, len(t.Map)+1)
+	for k, v := range t.Map {
+		m[k] = v
+	}
+	m[key] = value
+	t.Map = m
 }
 
 func (t *tags) HasValue(s string) bool {
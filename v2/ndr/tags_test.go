@@ -35,3 +35,16 @@ func TestParseTags(t *testing.T) {
 	assert.Equal(t, []string{}, tg3.Values, "Values not as expected for test %d", 3)
 	assert.Equal(t, make(map[string]string), tg3.Map, "Map not as expected for test %d", 3)
 }
+
+func TestParseTags_Shared(t *testing.T) {
+	tag := reflect.StructTag(`ndr:"pointer,conformant,size:3"`)
+	tg := parseTags(tag)
+	tg.delete(TagPointer)
+	tg.set("size", "4")
+	assert.Equal(t, []string{"conformant"}, tg.Values, "Values not as expected after delete")
+	assert.Equal(t, map[string]string{"size": "4"}, tg.Map, "Map not as expected after set")
+	// The cached tags are not modified
+	tg = parseTags(tag)
+	assert.Equal(t, []string{"pointer", "conformant"}, tg.Values, "cached Values modified")
+	assert.Equal(t, map[string]string{"size": "3"}, tg.Map, "cached Map modified")
+}
@@ -0,0 +1,50 @@
+package ndr
+
+// Tracer receives events as a Decoder decodes a byte stream into a struct, which can be used to build dumps, coverage
+// maps or performance counters. Paths are those of the field being decoded in the same form as those of Annotations,
+// for example "KerbValidationInfo/EffectiveName/RPCUnicodeString/Value". Offsets are from the start of the byte stream.
+type Tracer interface {
+	// EnterField is called before a field of a struct is decoded.
+	EnterField(path string)
+	// LeaveField is called once a field of a struct has been decoded. The referents of any pointers within the field
+	// are decoded after the structure containing it.
+	LeaveField(path string)
+	// Primitive is called when a primitive value is read. This includes the offsets and actual counts of varying
+	// arrays, the counts of pipe chunks and the first copy of a union discriminant, which is discarded so its value is
+	// nil. Strings and raw bytes are reported as a whole rather than as the individual characters or bytes.
+	Primitive(path string, offset, size int, value interface{})
+	// Padding is called when an alignment gap is skipped.
+	Padding(path string, offset, size int)
+	// PointerDeferred is called when a pointer is read, the referent ID is zero for a null pointer which has no
+	// referent.
+	PointerDeferred(path string, offset int, referentID uint32)
+	// PointerResolved is called when decoding the deferred referent of a pointer begins.
+	PointerResolved(path string, offset int, referentID uint32)
+	// UnionArm is called when the discriminant of a union selects the field to decode.
+	UnionArm(path string, field string)
+	// ConformantMax is called when a conformant max count moved to the beginning of a structure is read.
+	ConformantMax(path string, offset int, max uint32)
+}
+
+// SetTracer sets a Tracer to receive events as the Decoder decodes. A nil Tracer removes any Tracer set.
+func (dec *Decoder) SetTracer(t Tracer) {
+	dec.tracer = t
+}
+
+// trace passes the event described by the note to the Tracer.
+func (dec *Decoder) trace(note string, offset, length int, path string, value interface{}) {
+	switch note {
+	case NotePadding:
+		dec.tracer.Padding(path, offset, length)
+	case NoteConformantMax:
+		dec.tracer.ConformantMax(path, offset, value.(uint32))
+	case NotePointer:
+		if id, ok := value.(uint32); ok {
+			dec.tracer.PointerDeferred(path, offset, id)
+		}
+	case NoteReferent:
+		dec.tracer.PointerResolved(path, offset, value.(uint32))
+	case NoteOffset, NoteActualCount, NoteDiscriminant, NoteValue:
+		dec.tracer.Primitive(path, offset, length, value)
+	}
+}
@@ -0,0 +1,128 @@
+package ndr
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testTracer records the events it receives.
+type testTracer struct {
+	events []string
+}
+
+func (t *testTracer) EnterField(path string) {
+	t.events = append(t.events, "enter "+path)
+}
+
+func (t *testTracer) LeaveField(path string) {
+	t.events = append(t.events, "leave "+path)
+}
+
+func (t *testTracer) Primitive(path string, offset, size int, value interface{}) {
+	t.events = append(t.events, fmt.Sprintf("primitive %s %d %d %v", path, offset, size, value))
+}
+
+func (t *testTracer) Padding(path string, offset, size int) {
+	t.events = append(t.events, fmt.Sprintf("padding %s %d %d", path, offset, size))
+}
+
+func (t *testTracer) PointerDeferred(path string, offset int, referentID uint32) {
+	t.events = append(t.events, fmt.Sprintf("deferred %s %d 0x%x", path, offset, referentID))
+}
+
+func (t *testTracer) PointerResolved(path string, offset int, referentID uint32) {
+	t.events = append(t.events, fmt.Sprintf("resolved %s %d 0x%x", path, offset, referentID))
+}
+
+func (t *testTracer) UnionArm(path string, field string) {
+	t.events = append(t.events, "arm "+path+" "+field)
+}
+
+func (t *testTracer) ConformantMax(path string, offset int, max uint32) {
+	t.events = append(t.events, fmt.Sprintf("max %s %d %d", path, offset, max))
+}
+
+func TestTracer(t *testing.T) {
+	var tests = []struct {
+		hex      string
+		v        interface{}
+		expected []string
+	}{
+		{
+			testUnionSelected2NonEnc,
+			new(testUnionNonEncapsulated),
+			[]string{
+				"enter testUnionNonEncapsulated/Tag",
+				"primitive testUnionNonEncapsulated/Tag 20 4 <nil>",
+				"primitive testUnionNonEncapsulated/Tag 24 4 2",
+				"leave testUnionNonEncapsulated/Tag",
+				"arm testUnionNonEncapsulated Value2",
+				"enter testUnionNonEncapsulated/Value2",
+				"primitive testUnionNonEncapsulated/Value2 28 2 2",
+				"leave testUnionNonEncapsulated/Value2",
+			},
+		},
+		{
+			"03000000" + "01" + "000000" + "00000000" + "03000000" + "610062000000" + "0000" + "00000000" + "01000000" + "0700",
+			new(testAnnotatedString),
+			[]string{
+				"max testAnnotatedString/S 20 3",
+				"enter testAnnotatedString/A",
+				"primitive testAnnotatedString/A 24 1 1",
+				"leave testAnnotatedString/A",
+				"enter testAnnotatedString/S",
+				"padding testAnnotatedString/S 25 3",
+				"primitive testAnnotatedString/S 28 4 0",
+				"primitive testAnnotatedString/S 32 4 3",
+				"primitive testAnnotatedString/S 36 6 ab",
+				"leave testAnnotatedString/S",
+				"enter testAnnotatedString/V",
+				"padding testAnnotatedString/V 42 2",
+				"primitive testAnnotatedString/V 44 4 0",
+				"primitive testAnnotatedString/V 48 4 1",
+				"primitive testAnnotatedString/V 52 2 7",
+				"leave testAnnotatedString/V",
+			},
+		},
+		{
+			"00040002" + "01000000" + "00000000" + "00040002" + "03000000" + "02000000",
+			new(testEmbeddingPointer),
+			[]string{
+				"enter testEmbeddingPointer/A",
+				"deferred testEmbeddingPointer/A 20 0x2000400",
+				"leave testEmbeddingPointer/A",
+				"enter testEmbeddingPointer/B",
+				"primitive testEmbeddingPointer/B 24 4 1",
+				"leave testEmbeddingPointer/B",
+				"resolved testEmbeddingPointer/A 28 0x2000400",
+				"enter testEmbeddingPointer/A/testEmbeddedPointer/C",
+				"deferred testEmbeddingPointer/A/testEmbeddedPointer/C 28 0x0",
+				"leave testEmbeddingPointer/A/testEmbeddedPointer/C",
+				"enter testEmbeddingPointer/A/testEmbeddedPointer/D",
+				"deferred testEmbeddingPointer/A/testEmbeddedPointer/D 32 0x2000400",
+				"leave testEmbeddingPointer/A/testEmbeddedPointer/D",
+				"enter testEmbeddingPointer/A/testEmbeddedPointer/E",
+				"primitive testEmbeddingPointer/A/testEmbeddedPointer/E 36 4 3",
+				"leave testEmbeddingPointer/A/testEmbeddedPointer/E",
+				"resolved testEmbeddingPointer/A/testEmbeddedPointer/D 40 0x2000400",
+				"primitive testEmbeddingPointer/A/testEmbeddedPointer/D 40 4 2",
+			},
+		},
+	}
+	for i, test := range tests {
+		b, _ := hex.DecodeString(TestHeader + test.hex)
+		dec := NewDecoder(bytes.NewReader(b))
+		tr := new(testTracer)
+		dec.SetTracer(tr)
+		err := dec.Decode(test.v)
+		if err != nil {
+			t.Fatalf("error decoding test %d: %v", i+1, err)
+		}
+		assert.Equal(t, test.expected, tr.events, "events not as expected for test %d", i+1)
+		assert.Nil(t, dec.Annotations(), "annotations recorded without being set for test %d", i+1)
+	}
+}
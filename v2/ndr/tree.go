@@ -0,0 +1,373 @@
+package ndr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// deferredNode is the referent of a pointer in a tree that is read after the structure containing the pointer.
+type deferredNode struct {
+	t   *Type
+	tag string
+	set func(interface{})
+}
+
+// DecodeTree unmarshals the NDR encoded bytes according to the struct or union Type provided, rather than into a Go
+// struct, so that a byte stream can be inspected without a Go representation of it.
+// Structs and unions are decoded into maps keyed by field name, with a union's map also holding its discriminant.
+// Arrays are decoded into []interface{}, strings into strings and other primitives into the Go type of the same
+// size. The value of a null pointer is nil.
+func (dec *Decoder) DecodeTree(t *Type) (map[string]interface{}, error) {
+	if t == nil || (t.Kind != KindStruct && t.Kind != KindUnion) {
+		return nil, errors.New("a struct or union type is required to decode a tree")
+	}
+	err := dec.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer dec.release()
+	err = dec.readCommonHeader()
+	if err != nil {
+		return nil, err
+	}
+	err = dec.readPrivateHeader()
+	if err != nil {
+		return nil, err
+	}
+	err = dec.discard(4) //The next 4 bytes are an RPC unique pointer referent. We just skip these.
+	if err != nil {
+		return nil, Errorf("unable to process byte stream: %v", err)
+	}
+	var m map[string]interface{}
+	err = dec.processTree(t, "", func(v interface{}) { m, _ = v.(map[string]interface{}) })
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// DecodeJSON unmarshals the NDR encoded bytes according to the struct or union Type provided, as DecodeTree does, and
+// returns the tree marshaled as JSON.
+func (dec *Decoder) DecodeJSON(t *Type) ([]byte, error) {
+	m, err := dec.DecodeTree(t)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(m)
+}
+
+func (dec *Decoder) processTree(t *Type, tag string, set func(interface{})) error {
+	// Scan for conformant fields as their max counts are moved to the beginning
+	dec.conformantTreeScan(t, fieldTags(tag))
+	for i := range dec.conformantMax {
+		var err error
+		dec.conformantMax[i], err = dec.readUint32()
+		if err != nil {
+			return fmt.Errorf("could not read preceding conformant max count index %d: %v", i, err)
+		}
+	}
+	// Recursively read the values
+	var localDef []deferredNode
+	err := dec.readNode(t, tag, set, &localDef)
+	if err != nil {
+		return Errorf("could not decode: %v", err)
+	}
+	// Read any deferred referents associated with pointers
+	for _, p := range localDef {
+		err = dec.processTree(p.t, p.tag, p.set)
+		if err != nil {
+			return fmt.Errorf("could not decode deferred referent: %v", err)
+		}
+	}
+	return nil
+}
+
+// fieldTags returns the tags of the ndr tag values of a Field.
+func fieldTags(tag string) tags {
+	return parseTags(reflect.StructTag(ndrNameSpace + `:"` + tag + `"`))
+}
+
+// treeArrayForm returns whether an array is conformant and whether it is varying. Arrays without a length that are not
+// varying are conformant and arrays of strings without a length are always varying.
+func treeArrayForm(t *Type, ndrTag tags) (conformant, varying bool) {
+	conformant = ndrTag.HasValue(TagConformant)
+	varying = ndrTag.HasValue(TagVarying)
+	if t.Len == 0 {
+		conformant = conformant || !varying
+		varying = varying || t.Elem.Kind == KindString
+	}
+	return
+}
+
+// conformantTreeScan inspects the type for the max counts of conformant fields that are moved to the beginning of the
+// structure.
+func (dec *Decoder) conformantTreeScan(t *Type, ndrTag tags) {
+	if ndrTag.HasValue(TagPointer) {
+		return
+	}
+	switch t.Kind {
+	case KindStruct:
+		for _, f := range t.Fields {
+			dec.conformantTreeScan(f.Type, fieldTags(f.Tag))
+		}
+	case KindString:
+		if ndrTag.HasValue(TagConformant) {
+			dec.conformantMax = append(dec.conformantMax, uint32(0))
+		}
+	case KindArray:
+		if conformant, _ := treeArrayForm(t, ndrTag); !conformant {
+			break
+		}
+		dec.conformantMax = append(dec.conformantMax, uint32(0))
+		// For string arrays there is a common max for the strings within the array.
+		if t.Elem.Kind == KindString {
+			dec.conformantMax = append(dec.conformantMax, uint32(0))
+		}
+	}
+}
+
+// checkTreeMax returns an error if there is no conformant max value to read off.
+func (dec *Decoder) checkTreeMax() error {
+	if len(dec.conformantMax) < 1 {
+		return errors.New("no conformant max count precedes the structure, conformant arrays within arrays are not supported")
+	}
+	return nil
+}
+
+// treeMax reads off the next conformant max value.
+func (dec *Decoder) treeMax() (uint32, error) {
+	err := dec.checkTreeMax()
+	if err != nil {
+		return 0, err
+	}
+	return dec.precedingMax(), nil
+}
+
+// readNode reads a value of the type provided and passes it to set. The referents of pointers are added to the
+// deferred items to be read after the structure.
+func (dec *Decoder) readNode(t *Type, tag string, set func(interface{}), def *[]deferredNode) error {
+	if t == nil {
+		return errors.New("field has no type")
+	}
+	ndrTag := fieldTags(tag)
+	if ndrTag.HasValue(TagPointer) {
+		p, err := dec.readUint32()
+		if err != nil {
+			return fmt.Errorf("could not read pointer: %v", err)
+		}
+		ndrTag.delete(TagPointer)
+		if p != 0 {
+			*def = append(*def, deferredNode{t, strings.Join(ndrTag.Values, ","), set})
+		} else {
+			set(nil)
+		}
+		return nil
+	}
+	switch t.Kind {
+	case KindStruct:
+		m := make(map[string]interface{})
+		for _, f := range t.Fields {
+			name := f.Name
+			err := dec.readNode(f.Type, f.Tag, func(v interface{}) { m[name] = v }, def)
+			if err != nil {
+				return fmt.Errorf("could not read field %s of %s: %v", f.Name, t.Name, err)
+			}
+		}
+		set(m)
+	case KindUnion:
+		return dec.readUnionNode(t, set, def)
+	case KindArray:
+		return dec.readArrayNode(t, ndrTag, set, def)
+	case KindString:
+		var s string
+		var err error
+		var d []deferedPtr
+		if ndrTag.HasValue(TagConformant) {
+			err = dec.checkTreeMax()
+			if err != nil {
+				return err
+			}
+			s, err = dec.readConformantVaryingString(&d)
+		} else {
+			s, err = dec.readVaryingString(&d)
+		}
+		if err != nil {
+			return fmt.Errorf("could not read string: %v", err)
+		}
+		set(s)
+	default:
+		v, err := dec.readPrimitive(t.Kind)
+		if err != nil {
+			return err
+		}
+		set(v)
+	}
+	return nil
+}
+
+// readPrimitive reads a value of a primitive kind.
+func (dec *Decoder) readPrimitive(k Kind) (v interface{}, err error) {
+	switch k {
+	case KindBool:
+		v, err = dec.readBool()
+	case KindUint8:
+		v, err = dec.readUint8()
+	case KindUint16:
+		v, err = dec.readUint16()
+	case KindUint32:
+		v, err = dec.readUint32()
+	case KindUint64:
+		v, err = dec.readUint64()
+	case KindInt8:
+		v, err = dec.readInt8()
+	case KindInt16:
+		v, err = dec.readInt16()
+	case KindInt32:
+		v, err = dec.readInt32()
+	case KindInt64:
+		v, err = dec.readInt64()
+	case KindFloat32:
+		v, err = dec.readFloat32()
+	case KindFloat64:
+		v, err = dec.readFloat64()
+	default:
+		return nil, fmt.Errorf("unsupported kind %s", k)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %v", k, err)
+	}
+	return
+}
+
+// readUnionNode reads the discriminant of a union followed by the value of the arm it selects.
+func (dec *Decoder) readUnionNode(t *Type, set func(interface{}), def *[]deferredNode) error {
+	d := t.Discriminant
+	if d.Type == nil {
+		return fmt.Errorf("union %s has no discriminant type", t.Name)
+	}
+	// For a non-encapsulated union the discriminant is marshalled twice, the first copy is skipped.
+	if !t.Encapsulated {
+		dec.discard(d.Type.Kind.size())
+	}
+	v, err := dec.readPrimitive(d.Type.Kind)
+	if err != nil {
+		return fmt.Errorf("could not read discriminant of union %s: %v", t.Name, err)
+	}
+	m := map[string]interface{}{d.Name: v}
+	arm, err := unionArm(t, v)
+	if err != nil {
+		return err
+	}
+	if arm.Type != nil {
+		err = dec.readNode(arm.Type, arm.Tag, func(v interface{}) { m[arm.Name] = v }, def)
+		if err != nil {
+			return fmt.Errorf("could not read field %s of union %s: %v", arm.Name, t.Name, err)
+		}
+	}
+	set(m)
+	return nil
+}
+
+// unionArm returns the arm of the union selected by the discriminant value.
+func unionArm(t *Type, d interface{}) (Field, error) {
+	var u uint64
+	switch x := d.(type) {
+	case bool:
+		if x {
+			u = 1
+		}
+	case uint8:
+		u = uint64(x)
+	case uint16:
+		u = uint64(x)
+	case uint32:
+		u = uint64(x)
+	case uint64:
+		u = x
+	case int8:
+		u = uint64(x)
+	case int16:
+		u = uint64(x)
+	case int32:
+		u = uint64(x)
+	case int64:
+		u = uint64(x)
+	}
+	var dflt *Field
+	for i, f := range t.Fields {
+		if len(f.Cases) == 0 {
+			dflt = &t.Fields[i]
+		}
+		for _, c := range f.Cases {
+			if c == u {
+				return f, nil
+			}
+		}
+	}
+	if dflt != nil {
+		return *dflt, nil
+	}
+	return Field{}, fmt.Errorf("union %s has no arm for discriminant %v", t.Name, d)
+}
+
+// readArrayNode reads a fixed, conformant, varying or conformant varying array.
+func (dec *Decoder) readArrayNode(t *Type, ndrTag tags, set func(interface{}), def *[]deferredNode) error {
+	if t.Elem == nil {
+		return errors.New("array has no element type")
+	}
+	conformant, varying := treeArrayForm(t, ndrTag)
+	n := uint32(t.Len)
+	if conformant {
+		m, err := dec.treeMax()
+		if err != nil {
+			return err
+		}
+		if t.Elem.Kind == KindString {
+			// The common max of the strings
+			if _, err = dec.treeMax(); err != nil {
+				return err
+			}
+		}
+		n = m
+	}
+	if varying {
+		o, err := dec.readUint32()
+		if err != nil {
+			return fmt.Errorf("could not read offset of varying array: %v", err)
+		}
+		s, err := dec.readUint32()
+		if err != nil {
+			return fmt.Errorf("could not read actual count of varying array: %v", err)
+		}
+		if conformant && uint64(n) < uint64(o)+uint64(s) {
+			return errors.New("max count is less than the offset plus actual count")
+		}
+		n = s
+	}
+	// The count is read from the byte stream so is not trusted. Each element takes at least a byte, so when decoding
+	// from a byte slice a count larger than the bytes remaining is not valid. Otherwise the slice grows only as
+	// elements are read.
+	if dec.r == nil && int64(n) > int64(len(dec.b)-dec.off) {
+		return fmt.Errorf("array count %d is more than the %d bytes remaining", n, len(dec.b)-dec.off)
+	}
+	var a []interface{}
+	if dec.r == nil {
+		a = make([]interface{}, 0, n)
+	}
+	for i := 0; i < int(n); i++ {
+		i := i
+		a = append(a, nil)
+		err := dec.readNode(t.Elem, "", func(v interface{}) { a[i] = v }, def)
+		if err != nil {
+			return fmt.Errorf("could not read index %d of array: %v", i, err)
+		}
+	}
+	if a == nil {
+		a = []interface{}{}
+	}
+	set(a)
+	return nil
+}
@@ -0,0 +1,276 @@
+package ndr
+
+import (
+	"bytes"
+	"encoding/hex"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testTreeSchema = `
+// A schema matching the testTree Go structs
+struct testTree {
+	uint32 A;
+	testTreeString Name;
+	testTreeSID* SID;
+	[pointer] testTreeSID Null;
+	[pointer,conformant] uint16 IDs[];
+	[varying] uint32 V[];
+	bool Flag;
+	int64 I;
+	[conformant] string Strings[];
+};
+
+struct testTreeString {
+	uint16 Length;
+	uint16 MaximumLength;
+	[pointer,conformant,varying] string Value;
+};
+
+struct testTreeSID {
+	uint8 Revision;
+	uint8 SubAuthorityCount;
+	uint8 IdentifierAuthority[6];
+	[conformant] uint32 SubAuthority[];
+};
+`
+
+type testTree struct {
+	A       uint32
+	Name    testTreeString
+	SID     testTreeSID `ndr:"pointer"`
+	Null    testTreeSID `ndr:"pointer"`
+	IDs     []uint16    `ndr:"pointer,conformant"`
+	V       []uint32    `ndr:"varying"`
+	Flag    bool
+	I       int64
+	Strings []string `ndr:"conformant"`
+}
+
+type testTreeString struct {
+	Length        uint16
+	MaximumLength uint16
+	Value         string `ndr:"pointer,conformant,varying"`
+}
+
+type testTreeSID struct {
+	Revision            uint8
+	SubAuthorityCount   uint8
+	IdentifierAuthority [6]uint8
+	SubAuthority        []uint32 `ndr:"conformant"`
+}
+
+func Test_DecodeTree(t *testing.T) {
+	v := testTree{
+		A:       1,
+		Name:    testTreeString{Length: 8, MaximumLength: 10, Value: "test"},
+		SID:     testTreeSID{Revision: 1, SubAuthorityCount: 2, IdentifierAuthority: [6]uint8{0, 0, 0, 0, 0, 5}, SubAuthority: []uint32{21, 1105}},
+		IDs:     []uint16{513, 1200, 1300},
+		V:       []uint32{7, 8},
+		Flag:    true,
+		I:       -2,
+		Strings: []string{"a", "bc"},
+	}
+	var buf bytes.Buffer
+	err := NewEncoder(&buf).Encode(v)
+	if err != nil {
+		t.Fatalf("error encoding: %v", err)
+	}
+	s, err := ParseSchema(testTreeSchema)
+	if err != nil {
+		t.Fatalf("error parsing schema: %v", err)
+	}
+	typ, ok := s.Type("testTree")
+	if !ok {
+		t.Fatal("testTree type not in schema")
+	}
+	m, err := NewDecoder(bytes.NewReader(buf.Bytes())).DecodeTree(typ)
+	if err != nil {
+		t.Fatalf("error decoding tree: %v", err)
+	}
+	expected := map[string]interface{}{
+		"A":    uint32(1),
+		"Name": map[string]interface{}{"Length": uint16(8), "MaximumLength": uint16(10), "Value": "test"},
+		"SID": map[string]interface{}{
+			"Revision":            uint8(1),
+			"SubAuthorityCount":   uint8(2),
+			"IdentifierAuthority": []interface{}{uint8(0), uint8(0), uint8(0), uint8(0), uint8(0), uint8(5)},
+			"SubAuthority":        []interface{}{uint32(21), uint32(1105)},
+		},
+		"Null":    nil,
+		"IDs":     []interface{}{uint16(513), uint16(1200), uint16(1300)},
+		"V":       []interface{}{uint32(7), uint32(8)},
+		"Flag":    true,
+		"I":       int64(-2),
+		"Strings": []interface{}{"a", "bc"},
+	}
+	assert.Equal(t, expected, m, "tree not as expected")
+
+	j, err := NewDecoder(bytes.NewReader(buf.Bytes())).DecodeJSON(typ)
+	if err != nil {
+		t.Fatalf("error decoding JSON: %v", err)
+	}
+	assert.Equal(t, `{"A":1,"Flag":true,"I":-2,"IDs":[513,1200,1300],"Name":{"Length":8,"MaximumLength":10,"Value":"test"},`+
+		`"Null":null,"SID":{"IdentifierAuthority":[0,0,0,0,0,5],"Revision":1,"SubAuthority":[21,1105],"SubAuthorityCount":2},`+
+		`"Strings":["a","bc"],"V":[7,8]}`, string(j), "JSON not as expected")
+}
+
+func Test_DecodeTreeEmbeddedPointers(t *testing.T) {
+	s, err := ParseSchema(`
+struct testEmbeddingPointer {
+	testEmbeddedPointer* A;
+	uint32 B;
+}
+struct testEmbeddedPointer {
+	testEmbeddedPointer2* C;
+	uint32* D;
+	uint32 E;
+}
+struct testEmbeddedPointer2 {
+	uint32* F;
+	uint32 G;
+}`)
+	if err != nil {
+		t.Fatalf("error parsing schema: %v", err)
+	}
+	typ, _ := s.Type("testEmbeddingPointer")
+	b, _ := hex.DecodeString(TestHeader + "00040002" + "01000000" + "00040002" + "00040002" + "03000000" + "00040002" + "05000000" + "04000000" + "02000000")
+	m, err := NewDecoder(bytes.NewReader(b)).DecodeTree(typ)
+	if err != nil {
+		t.Fatalf("error decoding tree: %v", err)
+	}
+	expected := map[string]interface{}{
+		"A": map[string]interface{}{
+			"C": map[string]interface{}{"F": uint32(4), "G": uint32(5)},
+			"D": uint32(2),
+			"E": uint32(3),
+		},
+		"B": uint32(1),
+	}
+	assert.Equal(t, expected, m, "tree not as expected")
+}
+
+func Test_DecodeTreeUnion(t *testing.T) {
+	var tests = []struct {
+		Hex          string
+		Encapsulated bool
+		Expected     map[string]interface{}
+	}{
+		{testUnionSelected1Enc, true, map[string]interface{}{"Tag": uint32(1), "Value1": uint8(1)}},
+		{testUnionSelected2Enc, true, map[string]interface{}{"Tag": uint32(2), "Value2": uint16(2)}},
+		{testUnionSelected1NonEnc, false, map[string]interface{}{"Tag": uint32(1), "Value1": uint8(1)}},
+		{testUnionSelected2NonEnc, false, map[string]interface{}{"Tag": uint32(2), "Value2": uint16(2)}},
+		{"03000000", true, map[string]interface{}{"Tag": uint32(3)}},
+	}
+	for i, test := range tests {
+		typ := &Type{
+			Kind:         KindUnion,
+			Name:         "testUnion",
+			Discriminant: Field{Name: "Tag", Type: &Type{Kind: KindUint32}},
+			Encapsulated: test.Encapsulated,
+			Fields: []Field{
+				{Name: "Value1", Type: &Type{Kind: KindUint8}, Cases: []uint64{1}},
+				{Name: "Value2", Type: &Type{Kind: KindUint16}, Cases: []uint64{2}},
+				{},
+			},
+		}
+		b, _ := hex.DecodeString(TestHeader + test.Hex)
+		m, err := NewDecoder(bytes.NewReader(b)).DecodeTree(typ)
+		if err != nil {
+			t.Fatalf("error decoding tree for test %d: %v", i, err)
+		}
+		assert.Equal(t, test.Expected, m, "tree not as expected for test %d", i)
+	}
+}
+
+func Test_ParseSchemaUnion(t *testing.T) {
+	s, err := ParseSchema(`
+[encapsulated] union testUnion switch (uint32 Tag) {
+	case 1: uint8 Value1;
+	case 2:
+	case 0x10: uint16 Value2;
+	default: ;
+};`)
+	if err != nil {
+		t.Fatalf("error parsing schema: %v", err)
+	}
+	typ, ok := s.Type("testUnion")
+	if !ok {
+		t.Fatal("testUnion type not in schema")
+	}
+	assert.Equal(t, KindUnion, typ.Kind, "kind not as expected")
+	assert.True(t, typ.Encapsulated, "union should be encapsulated")
+	assert.Equal(t, Field{Name: "Tag", Type: &Type{Kind: KindUint32}}, typ.Discriminant, "discriminant not as expected")
+	assert.Equal(t, []Field{
+		{Name: "Value1", Type: &Type{Kind: KindUint8}, Cases: []uint64{1}},
+		{Name: "Value2", Type: &Type{Kind: KindUint16}, Cases: []uint64{2, 16}},
+		{},
+	}, typ.Fields, "arms not as expected")
+}
+
+func Test_ParseSchemaErrors(t *testing.T) {
+	var tests = []string{
+		"struct A { B b; };",
+		"struct A { uint32 a; }; struct A { uint32 b; };",
+		"struct A { uint32 a }",
+		"struct A { [unique] uint32 a; }",
+		"struct A { uint32 a[0]; }",
+		"struct uint32 { uint32 a; }",
+		"union A switch (string s) { case 1: uint32 a; }",
+		"union A switch (uint16 s) { uint32 a; }",
+		"struct A { uint32 a; } %",
+		"typedef A;",
+	}
+	for i, test := range tests {
+		_, err := ParseSchema(test)
+		assert.Error(t, err, "expected error for test %d", i)
+	}
+}
+
+func Test_DecodeTreeNotStruct(t *testing.T) {
+	b, _ := hex.DecodeString(TestHeader + "01000000")
+	_, err := NewDecoder(bytes.NewReader(b)).DecodeTree(&Type{Kind: KindUint32})
+	assert.Error(t, err, "expected error for a primitive type")
+}
+
+func Test_DecodeTreeArrayCounts(t *testing.T) {
+	s, err := ParseSchema(`
+struct testCounts {
+	uint32 N;
+	[conformant] uint32 A[];
+};
+struct testVaryingCounts {
+	uint32 N;
+	[conformant,varying] uint32 A[];
+};`)
+	if err != nil {
+		t.Fatalf("error parsing schema: %v", err)
+	}
+	header := "01100800cccccccc180000000000000000000200"
+	counts, _ := s.Type("testCounts")
+	varying, _ := s.Type("testVaryingCounts")
+	var tests = []struct {
+		Name string
+		Type *Type
+		Hex  string
+	}{
+		// A max count far larger than the input
+		{"max count", counts, header + "f0ffffff" + "02000000" + "0100000002000000"},
+		// An offset plus actual count that overflows 32 bits
+		{"offset overflow", varying, header + "02000000" + "02000000" + "ffffffff" + "02000000" + "0100000002000000"},
+	}
+	for _, test := range tests {
+		b, _ := hex.DecodeString(test.Hex)
+		for _, dec := range []*Decoder{NewDecoderBytes(b), NewDecoder(bytes.NewReader(b))} {
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			_, err := dec.DecodeTree(test.Type)
+			runtime.ReadMemStats(&after)
+			assert.Error(t, err, "expected error for test %s", test.Name)
+			assert.True(t, after.TotalAlloc-before.TotalAlloc < 1<<20, "%d bytes allocated for test %s",
+				after.TotalAlloc-before.TotalAlloc, test.Name)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package ndr
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"reflect"
@@ -33,7 +34,30 @@ func (dec *Decoder) isUnion(field reflect.Value, tag reflect.StructTag) (r refle
 	// field or parameter, which is referenced by the switch_is construct, in the procedure argument list; and once as
 	// the first part of the union representation.
 	if !ndrTag.HasValue(TagEncapsulated) {
-		dec.r.Discard(int(r.Type().Size()))
+		dec.discard(int(r.Type().Size()))
+		dec.note(NoteDiscriminant, int(r.Type().Size()), nil)
+	}
+	return
+}
+
+func (enc *Encoder) isUnion(field reflect.Value, tag reflect.StructTag) (r reflect.Value) {
+	ndrTag := parseTags(tag)
+	if !ndrTag.HasValue(TagUnionTag) {
+		return
+	}
+	r = field
+	// For a non-encapsulated union the discriminant is written twice. As for the decoder the first copy is not aligned.
+	if !ndrTag.HasValue(TagEncapsulated) {
+		var i uint64
+		switch field.Kind() {
+		case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			i = uint64(field.Int())
+		default:
+			i = field.Uint()
+		}
+		var b [SizeUint64]byte
+		binary.LittleEndian.PutUint64(b[:], i)
+		enc.buf = append(enc.buf, b[:field.Type().Size()]...)
 	}
 	return
 }
@@ -51,7 +75,7 @@ func unionSelectedField(union, discriminant reflect.Value) (string, error) {
 	}
 	f := sf.Call(args)
 	if f[0].Kind() != reflect.String || f[0].String() == "" {
-		return "", fmt.Errorf("the union select function did not return a string for the name of the field to fill")
+		return "", fmt.Errorf("the union select function did not return the name of a field to fill for discriminant %v", discriminant)
 	}
 	return f[0].String(), nil
 }
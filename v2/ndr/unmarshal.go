@@ -0,0 +1,38 @@
+package ndr
+
+import (
+	"io"
+	"sync"
+)
+
+// decoderPool holds Decoders, with their buffers, for reuse by Unmarshal and UnmarshalReader.
+var decoderPool = sync.Pool{
+	New: func() interface{} { return new(Decoder) },
+}
+
+// Unmarshal decodes the NDR encoded bytes into the pointer of a struct provided.
+// Decoders and their buffers are pooled and reused, so it is safe for concurrent use and suited to decoding many byte
+// streams. Byte fields of the struct are copies, they do not reference the byte slice provided.
+func Unmarshal(b []byte, s interface{}) error {
+	dec := decoderPool.Get().(*Decoder)
+	dec.ResetBytes(b)
+	err := dec.Decode(s)
+	putDecoder(dec)
+	return err
+}
+
+// UnmarshalReader decodes the NDR encoded bytes read from the io.Reader into the pointer of a struct provided.
+// Like Unmarshal it is safe for concurrent use. The io.Reader may be read beyond the end of the NDR byte stream.
+func UnmarshalReader(r io.Reader, s interface{}) error {
+	dec := decoderPool.Get().(*Decoder)
+	dec.Reset(r)
+	err := dec.Decode(s)
+	putDecoder(dec)
+	return err
+}
+
+// putDecoder returns a Decoder to the pool, without references to the input or the value decoded.
+func putDecoder(dec *Decoder) {
+	dec.ResetBytes(nil)
+	decoderPool.Put(dec)
+}
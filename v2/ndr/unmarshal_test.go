@@ -0,0 +1,141 @@
+package ndr
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testUnmarshalVectors(t testing.TB) (s testEncodeStruct, b []byte) {
+	s = testEncodeStruct{
+		Bool:     true,
+		Int8:     -1,
+		Int16:    -2,
+		Int32:    -3,
+		Int64:    -4,
+		Float32:  1.5,
+		Float64:  -2.25,
+		Inner:    testEncodeInner{A: 1, B: "inner", C: []uint64{1, 2}},
+		InnerPtr: testEncodeInner{A: 2, B: "", C: []uint64{3}},
+		Strings:  [][]string{{"a", "bb", "ccc"}},
+		Fixed:    [2][2]string{{"w", "x"}, {"y", "z"}},
+		RawSize:  3,
+		Raw:      testEncodeRawBytes{7, 8, 9},
+		Union:    testUnionNonEncapsulated{Tag: 2, Value2: 5},
+	}
+	var buf bytes.Buffer
+	err := NewEncoder(&buf).Encode(s)
+	if err != nil {
+		t.Fatalf("error encoding: %v", err)
+	}
+	return s, buf.Bytes()
+}
+
+func TestDecoder_Reset(t *testing.T) {
+	s, b := testUnmarshalVectors(t)
+	// A failed decode leaves conformant max counts and the field path part way through
+	dec := NewDecoder(bytes.NewReader(b[:100]))
+	dec.Annotate(true)
+	err := dec.Decode(new(testEncodeStruct))
+	assert.Error(t, err, "expected error decoding truncated byte stream")
+	for i, reset := range []func(){
+		func() { dec.Reset(bytes.NewReader(b)) },
+		func() { dec.ResetBytes(b) },
+		func() { dec.Reset(bytes.NewReader(b)) },
+	} {
+		reset()
+		d := new(testEncodeStruct)
+		err = dec.Decode(d)
+		if err != nil {
+			t.Fatalf("error decoding after reset %d: %v", i+1, err)
+		}
+		assert.Equal(t, s, *d, "decoded value not as expected after reset %d", i+1)
+		assert.Empty(t, dec.Annotations(), "annotations recorded after reset %d", i+1)
+	}
+	// A Decoder decoding a different type after a reset
+	h, _ := hex.DecodeString("01100800cccccccca00400000000000000000200d186660f656ac601")
+	dec.ResetBytes(h)
+	st := new(SimpleTest)
+	err = dec.Decode(st)
+	if err != nil {
+		t.Fatalf("error decoding after reset: %v", err)
+	}
+	assert.Equal(t, SimpleTest{A: 258377425, B: 29780581}, *st, "decoded value not as expected")
+}
+
+func TestDecoder_ConcurrentUse(t *testing.T) {
+	_, b := testUnmarshalVectors(t)
+	dec := NewDecoderBytes(b)
+	// Simulate a decode in progress
+	err := dec.acquire()
+	if err != nil {
+		t.Fatalf("error acquiring decoder: %v", err)
+	}
+	err = dec.Decode(new(testEncodeStruct))
+	assert.Error(t, err, "expected error for concurrent use of a decoder")
+	assert.Panics(t, func() { dec.Reset(bytes.NewReader(b)) }, "expected panic resetting a decoder in use")
+	assert.Panics(t, func() { dec.ResetBytes(b) }, "expected panic resetting a decoder in use")
+	dec.release()
+	err = dec.Decode(new(testEncodeStruct))
+	assert.NoError(t, err, "unexpected error once the decoder is released")
+}
+
+func TestUnmarshal(t *testing.T) {
+	s, b := testUnmarshalVectors(t)
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			d := new(testEncodeStruct)
+			err := Unmarshal(b, d)
+			if err == nil && !assert.ObjectsAreEqual(s, *d) {
+				err = fmt.Errorf("Unmarshal value not as expected: %+v", *d)
+			}
+			errs <- err
+		}()
+		go func() {
+			defer wg.Done()
+			d := new(testEncodeStruct)
+			err := UnmarshalReader(bytes.NewReader(b), d)
+			if err == nil && !assert.ObjectsAreEqual(s, *d) {
+				err = fmt.Errorf("UnmarshalReader value not as expected: %+v", *d)
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err, "error unmarshaling concurrently")
+	}
+	// Byte fields do not reference the input
+	d := new(testEncodeStruct)
+	c := append([]byte{}, b...)
+	err := Unmarshal(c, d)
+	if err != nil {
+		t.Fatalf("error unmarshaling: %v", err)
+	}
+	for i := range c {
+		c[i] = 0
+	}
+	assert.Equal(t, s, *d, "decoded value modified with input")
+	assert.Error(t, Unmarshal(b[:50], new(testEncodeStruct)), "expected error unmarshaling truncated byte stream")
+}
+
+func BenchmarkUnmarshal(b *testing.B) {
+	_, v := testUnmarshalVectors(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		err := Unmarshal(v, new(testEncodeStruct))
+		if err != nil {
+			b.Fatalf("error unmarshaling: %v", err)
+		}
+	}
+}
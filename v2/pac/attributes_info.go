@@ -0,0 +1,62 @@
+package pac
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Flags of AttributesInfo
+const (
+	PACWasRequested       uint32 = 0x00000001 // The client requested the PAC.
+	PACWasGivenImplicitly uint32 = 0x00000002 // The client did not request or decline a PAC and it was given implicitly.
+)
+
+// AttributesInfo implements https://msdn.microsoft.com/en-us/library/mt844289.aspx
+type AttributesInfo struct {
+	FlagsLength uint32   // The number of bits in the Flags
+	Flags       []uint32 // The flags, with the first 32 bits held in the first element
+}
+
+// Unmarshal bytes into the AttributesInfo struct.
+func (a *AttributesInfo) Unmarshal(b []byte) error {
+	if len(b) < 4 {
+		return errors.New("error unmarshaling AttributesInfo: too few bytes")
+	}
+	a.FlagsLength = binary.LittleEndian.Uint32(b[0:4])
+	n := (uint64(a.FlagsLength) + 31) / 32
+	if n*4 > uint64(len(b)-4) {
+		return fmt.Errorf("error unmarshaling AttributesInfo: too few bytes for %d bits of flags", a.FlagsLength)
+	}
+	a.Flags = make([]uint32, n, n)
+	for i := range a.Flags {
+		a.Flags[i] = binary.LittleEndian.Uint32(b[4+4*i : 8+4*i])
+	}
+	return nil
+}
+
+// Marshal returns the bytes of the AttributesInfo.
+func (a *AttributesInfo) Marshal() []byte {
+	b := make([]byte, 4+4*len(a.Flags))
+	binary.LittleEndian.PutUint32(b[0:4], a.FlagsLength)
+	for i, f := range a.Flags {
+		binary.LittleEndian.PutUint32(b[4+4*i:8+4*i], f)
+	}
+	return b
+}
+
+// HasFlag returns true if the flag, one of the AttributesInfo flag constants, is set.
+func (a *AttributesInfo) HasFlag(f uint32) bool {
+	return len(a.Flags) > 0 && a.Flags[0]&f != 0
+}
+
+// AttributesInfo returns the AttributesInfo of the PAC's attributes buffer.
+func (p *PACType) AttributesInfo() (a AttributesInfo, err error) {
+	b, ok := p.Buffer(InfoTypeAttributesInfo)
+	if !ok {
+		err = errors.New("PAC does not contain an attributes buffer")
+		return
+	}
+	err = a.Unmarshal(b)
+	return
+}
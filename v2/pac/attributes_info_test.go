@@ -0,0 +1,35 @@
+package pac
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testAttributesInfo = "0200000001000000"
+
+func TestAttributesInfo_Unmarshal(t *testing.T) {
+	b, _ := hex.DecodeString(testAttributesInfo)
+	var p PACType
+	err := p.Unmarshal(layoutPAC([]pacBuffer{{InfoTypeAttributesInfo, b}}))
+	if err != nil {
+		t.Fatalf("error unmarshaling PAC: %v", err)
+	}
+	a, err := p.AttributesInfo()
+	if err != nil {
+		t.Fatalf("error getting AttributesInfo: %v", err)
+	}
+	assert.Equal(t, uint32(2), a.FlagsLength, "FlagsLength not as expected")
+	assert.Equal(t, []uint32{PACWasRequested}, a.Flags, "Flags not as expected")
+	assert.True(t, a.HasFlag(PACWasRequested), "PACWasRequested should be set")
+	assert.False(t, a.HasFlag(PACWasGivenImplicitly), "PACWasGivenImplicitly should not be set")
+	assert.Equal(t, testAttributesInfo, hex.EncodeToString(a.Marshal()), "marshaled bytes not as expected")
+
+	err = a.Unmarshal(b[:6])
+	assert.Error(t, err, "too few bytes for the flags should return an error")
+	err = a.Unmarshal([]byte{0x21, 0, 0, 0, 1, 0, 0, 0})
+	assert.Error(t, err, "too few bytes for 33 bits of flags should return an error")
+	err = a.Unmarshal(b[:2])
+	assert.Error(t, err, "too few bytes should return an error")
+}
@@ -0,0 +1,231 @@
+package pac
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jcmturner/rpc/v2/mstypes"
+	"github.com/jcmturner/rpc/v2/ndr"
+)
+
+// Default values used by the Builder
+const (
+	DefaultPrimaryGroupID     uint32 = 513                       // The RID of the Domain Users group
+	DefaultUserAccountControl        = mstypes.UserNormalAccount // USER_NORMAL_ACCOUNT
+)
+
+// fileTimeNever is the FileTime used for times that never occur, such as the logoff time of an account that does not
+// expire.
+var fileTimeNever = mstypes.FileTime{LowDateTime: 0xffffffff, HighDateTime: 0x7fffffff}
+
+// Builder describes a PAC for a user, such as one issued by a test KDC, from which the PAC can be built.
+// The logon information and client information buffers are always built, the other buffers are built when the fields
+// they are made from are set.
+type Builder struct {
+	UserSID            mstypes.RPCSID                 // The SID of the user, the SID of the user's domain followed by the user's RID.
+	UserName           string                         // The account name of the user.
+	FullName           string                         // The full name of the user.
+	LogonDomainName    string                         // The NetBIOS name of the user's domain.
+	LogonServer        string                         // The NetBIOS name of the KDC.
+	AuthTime           time.Time                      // The authentication time of the ticket the PAC is for.
+	PrimaryGroupID     uint32                         // The RID of the user's primary group, DefaultPrimaryGroupID if zero.
+	Groups             []mstypes.GroupMembership      // The RIDs, within the user's domain, of the groups the user is a member of.
+	ExtraSIDs          []mstypes.KerbSidAndAttributes // The SIDs of other groups the user is a member of.
+	UserAccountControl mstypes.UserAccountControl     // The account's UserAccountControl, DefaultUserAccountControl if zero.
+
+	UPN        string // The UPN of the user. The UPN and DNS information buffer is built if this is set.
+	DNSDomain  string // The DNS name of the user's domain.
+	UPNFlags   uint32 // Flags of the UPN and DNS information. If UPNExtendedInfo is set the SamName and SID are included.
+	Attributes uint32 // The flags of the attributes buffer, which is built if any are set.
+	Requestor  bool   // Whether to build the requestor buffer holding the user's SID.
+
+	ClientClaims *mstypes.ClaimsSet // The claims of the user, built into the client claims buffer.
+	DeviceClaims *mstypes.ClaimsSet // The claims of the device, built into the device claims buffer.
+	// The size from which the encoded claims are compressed, mstypes.DefaultClaimsCompressionThreshold if zero.
+	// Compression is disabled if it is negative.
+	ClaimsCompressionThreshold int
+
+	SignatureType       uint32 // The type of the signatures. The signature buffers are built if this is set.
+	ServerKey           []byte // The key of the service the ticket is for, used for the server checksum.
+	KDCKey              []byte // The key of the KDC, used for the KDC checksum.
+	ExtendedKDCChecksum bool   // Whether to build the extended KDC checksum buffer.
+}
+
+// pacBuffer is a buffer to be laid out in a PAC.
+type pacBuffer struct {
+	Type uint32
+	Data []byte
+}
+
+// LogonInfo returns the KerbValidationInfo of the user described by the Builder.
+func (b *Builder) LogonInfo() (KerbValidationInfo, error) {
+	domain, err := b.UserSID.DomainSID()
+	if err != nil {
+		return KerbValidationInfo{}, fmt.Errorf("could not get domain of user SID: %v", err)
+	}
+	rid, err := b.UserSID.RID()
+	if err != nil {
+		return KerbValidationInfo{}, fmt.Errorf("could not get RID of user SID: %v", err)
+	}
+	k := KerbValidationInfo{
+		LogOnTime:          mstypes.GetFileTime(b.AuthTime),
+		LogOffTime:         fileTimeNever,
+		KickOffTime:        fileTimeNever,
+		PasswordMustChange: fileTimeNever,
+		EffectiveName:      mstypes.NewRPCUnicodeString(b.UserName),
+		FullName:           mstypes.NewRPCUnicodeString(b.FullName),
+		UserID:             rid,
+		PrimaryGroupID:     b.PrimaryGroupID,
+		GroupCount:         uint32(len(b.Groups)),
+		GroupIDs:           b.Groups,
+		LogonServer:        mstypes.NewRPCUnicodeString(b.LogonServer),
+		LogonDomainName:    mstypes.NewRPCUnicodeString(b.LogonDomainName),
+		LogonDomainID:      domain,
+		UserAccountControl: b.UserAccountControl,
+		SIDCount:           uint32(len(b.ExtraSIDs)),
+		ExtraSIDs:          b.ExtraSIDs,
+	}
+	if k.PrimaryGroupID == 0 {
+		k.PrimaryGroupID = DefaultPrimaryGroupID
+	}
+	if k.UserAccountControl == 0 {
+		k.UserAccountControl = DefaultUserAccountControl
+	}
+	if len(k.ExtraSIDs) > 0 {
+		k.UserFlags |= UserFlagExtraSIDs
+	}
+	return k, nil
+}
+
+// Build returns the bytes of the PAC described by the Builder, to be used as the AuthorizationData of an
+// AD-WIN2K-PAC element. The buffers are in the order Windows places them, with the signature buffers last.
+func (b *Builder) Build() ([]byte, error) {
+	k, err := b.LogonInfo()
+	if err != nil {
+		return nil, fmt.Errorf("could not build PAC: %v", err)
+	}
+	logon, err := k.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("could not build PAC: %v", err)
+	}
+	c := ClientInfo{ClientID: mstypes.GetFileTime(b.AuthTime), Name: b.UserName}
+	bufs := []pacBuffer{
+		{InfoTypeLogonInfo, logon},
+		{InfoTypeClientInfo, c.Marshal()},
+	}
+	if b.UPN != "" {
+		u := UPNDNSInfo{
+			UPN:       b.UPN,
+			DNSDomain: b.DNSDomain,
+			Flags:     b.UPNFlags,
+			SamName:   b.UserName,
+			SID:       b.UserSID,
+		}
+		bufs = append(bufs, pacBuffer{InfoTypeUPNDNSInfo, u.Marshal()})
+	}
+	claims := []struct {
+		t uint32
+		c *mstypes.ClaimsSet
+	}{
+		{InfoTypeClientClaimsInfo, b.ClientClaims},
+		{InfoTypeDeviceClaimsInfo, b.DeviceClaims},
+	}
+	threshold := b.ClaimsCompressionThreshold
+	if threshold == 0 {
+		threshold = mstypes.DefaultClaimsCompressionThreshold
+	}
+	for _, cl := range claims {
+		if cl.c == nil {
+			continue
+		}
+		m, err := mstypes.NewClaimsSetMetadataThreshold(*cl.c, threshold)
+		if err != nil {
+			return nil, fmt.Errorf("could not build PAC claims: %v", err)
+		}
+		var buf bytes.Buffer
+		err = ndr.NewEncoder(&buf).Encode(m)
+		if err != nil {
+			return nil, fmt.Errorf("could not build PAC claims: %v", err)
+		}
+		bufs = append(bufs, pacBuffer{cl.t, buf.Bytes()})
+	}
+	if b.Attributes != 0 {
+		a := AttributesInfo{FlagsLength: 2, Flags: []uint32{b.Attributes}}
+		bufs = append(bufs, pacBuffer{InfoTypeAttributesInfo, a.Marshal()})
+	}
+	if b.Requestor {
+		r := Requestor{SID: b.UserSID}
+		bufs = append(bufs, pacBuffer{InfoTypeRequestor, r.Marshal()})
+	}
+	if b.SignatureType == 0 {
+		return layoutPAC(bufs), nil
+	}
+	return b.sign(bufs)
+}
+
+// sign adds the signature buffers to the PAC and computes the signatures as specified in MS-PAC section 2.8.
+func (b *Builder) sign(bufs []pacBuffer) ([]byte, error) {
+	if b.ServerKey == nil || b.KDCKey == nil {
+		return nil, errors.New("could not sign PAC: both the server and KDC keys are required")
+	}
+	l, err := signatureLength(b.SignatureType)
+	if err != nil {
+		return nil, fmt.Errorf("could not sign PAC: %v", err)
+	}
+	s := SignatureData{SignatureType: b.SignatureType, Signature: make([]byte, l)}
+	bufs = append(bufs, pacBuffer{InfoTypeServerChecksum, s.Marshal()}, pacBuffer{InfoTypeKDCChecksum, s.Marshal()})
+	if b.ExtendedKDCChecksum {
+		bufs = append(bufs, pacBuffer{InfoTypeExtendedKDCChecksum, s.Marshal()})
+	}
+	data := layoutPAC(bufs)
+	var p PACType
+	err = p.Unmarshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("could not sign PAC: %v", err)
+	}
+	offsets := make(map[uint32]uint64)
+	for _, buf := range p.Buffers {
+		offsets[buf.ULType] = buf.Offset + 4
+	}
+	// The extended KDC checksum is computed first, over the PAC with all the signatures zero, and written into the PAC.
+	// The server checksum then covers it, with only the server and KDC signatures zero, and the KDC checksum is over
+	// the server signature.
+	if b.ExtendedKDCChecksum {
+		full, err := Checksum(b.SignatureType, b.KDCKey, data)
+		if err != nil {
+			return nil, fmt.Errorf("could not compute extended KDC checksum: %v", err)
+		}
+		copy(data[offsets[InfoTypeExtendedKDCChecksum]:], full)
+	}
+	server, err := Checksum(b.SignatureType, b.ServerKey, data)
+	if err != nil {
+		return nil, fmt.Errorf("could not compute server checksum: %v", err)
+	}
+	copy(data[offsets[InfoTypeServerChecksum]:], server)
+	kdc, err := Checksum(b.SignatureType, b.KDCKey, server)
+	if err != nil {
+		return nil, fmt.Errorf("could not compute KDC checksum: %v", err)
+	}
+	copy(data[offsets[InfoTypeKDCChecksum]:], kdc)
+	return data, nil
+}
+
+// layoutPAC returns the bytes of a PAC with the buffers in the order provided, each starting on an 8 byte boundary
+// after the buffer table.
+func layoutPAC(bufs []pacBuffer) []byte {
+	b := make([]byte, pacTypeHeaderBytes+infoBufferBytes*len(bufs))
+	binary.LittleEndian.PutUint32(b[0:4], uint32(len(bufs)))
+	binary.LittleEndian.PutUint32(b[4:8], pacTypeVersion)
+	for i, buf := range bufs {
+		b = padTo8(b)
+		o := pacTypeHeaderBytes + i*infoBufferBytes
+		binary.LittleEndian.PutUint32(b[o:o+4], buf.Type)
+		binary.LittleEndian.PutUint32(b[o+4:o+8], uint32(len(buf.Data)))
+		binary.LittleEndian.PutUint64(b[o+8:o+16], uint64(len(b)))
+		b = append(b, buf.Data...)
+	}
+	return padTo8(b)
+}
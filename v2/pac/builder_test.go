@@ -0,0 +1,197 @@
+package pac
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/jcmturner/rpc/v2/mstypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func testBuilder(t *testing.T) Builder {
+	sid, err := mstypes.ParseSID("S-1-5-21-3167651404-3865080224-2280184895-1105")
+	if err != nil {
+		t.Fatalf("error parsing SID: %v", err)
+	}
+	extra, _ := mstypes.ParseSID("S-1-18-1")
+	serverKey := make([]byte, 32)
+	kdcKey := make([]byte, 32)
+	for i := range serverKey {
+		serverKey[i] = byte(i)
+		kdcKey[i] = byte(0xff - i)
+	}
+	return Builder{
+		UserSID:         sid,
+		UserName:        "testuser1",
+		FullName:        "Test1 User1",
+		LogonDomainName: "TEST",
+		LogonServer:     "ADDC",
+		AuthTime:        time.Date(2017, 6, 9, 10, 0, 0, 0, time.UTC),
+		Groups:          []mstypes.GroupMembership{{RelativeID: 513, Attributes: 7}, {RelativeID: 1108, Attributes: 7}},
+		ExtraSIDs:       []mstypes.KerbSidAndAttributes{{SID: extra, Attributes: 7}},
+		UPN:             "testuser1@test.gokrb5",
+		DNSDomain:       "TEST.GOKRB5",
+		UPNFlags:        UPNExtendedInfo,
+		Attributes:      PACWasRequested,
+		Requestor:       true,
+		ClientClaims: &mstypes.ClaimsSet{
+			ClaimsArrayCount: 1,
+			ClaimsArrays: []mstypes.ClaimsArray{{
+				ClaimsSourceType: mstypes.ClaimsSourceTypeAD,
+				ClaimsCount:      1,
+				ClaimEntries: []mstypes.ClaimEntry{{
+					ID:         "ad://ext/department",
+					Type:       mstypes.ClaimTypeIDString,
+					TypeString: mstypes.ClaimTypeString{ValueCount: 1, Value: []mstypes.LPWSTR{{Value: "Engineering"}}},
+				}},
+			}},
+		},
+		SignatureType:       SignatureTypeHMACSHA196AES256,
+		ServerKey:           serverKey,
+		KDCKey:              kdcKey,
+		ExtendedKDCChecksum: true,
+	}
+}
+
+func TestBuilder_Build(t *testing.T) {
+	b := testBuilder(t)
+	data, err := b.Build()
+	if err != nil {
+		t.Fatalf("error building PAC: %v", err)
+	}
+	var p PACType
+	err = p.Unmarshal(data)
+	if err != nil {
+		t.Fatalf("error unmarshaling PAC: %v", err)
+	}
+	var types []uint32
+	for _, buf := range p.Buffers {
+		types = append(types, buf.ULType)
+	}
+	assert.Equal(t, []uint32{InfoTypeLogonInfo, InfoTypeClientInfo, InfoTypeUPNDNSInfo, InfoTypeClientClaimsInfo,
+		InfoTypeAttributesInfo, InfoTypeRequestor, InfoTypeServerChecksum, InfoTypeKDCChecksum, InfoTypeExtendedKDCChecksum},
+		types, "buffer types not as expected")
+
+	k, err := p.LogonInfo()
+	if err != nil {
+		t.Fatalf("error getting logon info: %v", err)
+	}
+	assert.Equal(t, "testuser1", k.EffectiveName.String(), "EffectiveName not as expected")
+	assert.Equal(t, uint16(18), k.EffectiveName.Length, "EffectiveName length not as expected")
+	assert.Equal(t, "TEST", k.LogonDomainName.String(), "LogonDomainName not as expected")
+	assert.Equal(t, DefaultPrimaryGroupID, k.PrimaryGroupID, "PrimaryGroupID not as expected")
+	assert.Equal(t, b.AuthTime, k.LogOnTime.Time(), "LogOnTime not as expected")
+	g, err := k.GroupMembershipSIDs()
+	if err != nil {
+		t.Fatalf("error getting group membership SIDs: %v", err)
+	}
+	assert.Equal(t, []string{
+		"S-1-5-21-3167651404-3865080224-2280184895-513",
+		"S-1-5-21-3167651404-3865080224-2280184895-1108",
+		"S-1-18-1",
+	}, g, "group membership SIDs not as expected")
+
+	c, err := p.ClientInfo()
+	if err != nil {
+		t.Fatalf("error getting client info: %v", err)
+	}
+	assert.NoError(t, c.Validate(b.AuthTime, "testuser1"), "client info should be valid")
+	u, err := p.UPNDNSInfo()
+	if err != nil {
+		t.Fatalf("error getting UPN and DNS info: %v", err)
+	}
+	assert.Equal(t, "testuser1@test.gokrb5", u.UPN, "UPN not as expected")
+	assert.Equal(t, "testuser1", u.SamName, "SamName not as expected")
+	assert.Equal(t, b.UserSID.String(), u.SID.String(), "UPN and DNS info SID not as expected")
+	m, err := p.ClientClaims()
+	if err != nil {
+		t.Fatalf("error getting client claims: %v", err)
+	}
+	cl, err := m.Claims()
+	if err != nil {
+		t.Fatalf("error getting claims: %v", err)
+	}
+	s, _ := cl.Strings("ad://ext/department")
+	assert.Equal(t, []string{"Engineering"}, s, "claim values not as expected")
+	a, err := p.AttributesInfo()
+	if err != nil {
+		t.Fatalf("error getting attributes info: %v", err)
+	}
+	assert.True(t, a.HasFlag(PACWasRequested), "PACWasRequested should be set")
+	assert.NoError(t, p.ValidateRequestor(), "requestor should be valid")
+
+	assert.NoError(t, p.VerifyServerChecksum(b.ServerKey), "server checksum should verify")
+	assert.NoError(t, p.VerifyKDCChecksum(b.KDCKey), "KDC checksum should verify")
+	assert.NoError(t, p.VerifyExtendedKDCChecksum(b.KDCKey), "extended KDC checksum should verify")
+	assert.Error(t, p.VerifyServerChecksum(b.KDCKey), "server checksum should not verify with the KDC key")
+}
+
+func TestBuilder_BuildMinimal(t *testing.T) {
+	sid, _ := mstypes.ParseSID("S-1-5-21-3167651404-3865080224-2280184895-1105")
+	b := Builder{UserSID: sid, UserName: "testuser1"}
+	data, err := b.Build()
+	if err != nil {
+		t.Fatalf("error building PAC: %v", err)
+	}
+	var p PACType
+	err = p.Unmarshal(data)
+	if err != nil {
+		t.Fatalf("error unmarshaling PAC: %v", err)
+	}
+	assert.Equal(t, uint32(2), p.CBuffers, "number of buffers not as expected")
+	k, err := p.LogonInfo()
+	if err != nil {
+		t.Fatalf("error getting logon info: %v", err)
+	}
+	assert.Equal(t, DefaultUserAccountControl, k.UserAccountControl, "UserAccountControl not as expected")
+	assert.Equal(t, mstypes.UserFlags(0), k.UserFlags, "UserFlags not as expected")
+	_, err = p.ServerChecksum()
+	assert.Error(t, err, "unsigned PAC should not have a server checksum")
+}
+
+func TestBuilder_BuildErrors(t *testing.T) {
+	b := testBuilder(t)
+	b.KDCKey = nil
+	_, err := b.Build()
+	assert.Error(t, err, "missing KDC key should return an error")
+	b = testBuilder(t)
+	b.SignatureType = SignatureTypeHMACSHA196AES128
+	_, err = b.Build()
+	assert.Error(t, err, "key of the wrong length should return an error")
+	b = testBuilder(t)
+	b.UserSID = mstypes.RPCSID{}
+	_, err = b.Build()
+	assert.Error(t, err, "invalid user SID should return an error")
+}
+
+func TestBuilder_BuildSignatures(t *testing.T) {
+	// The expected signatures were computed over the PAC built with a separate implementation of the RFC 3962 checksum,
+	// signing in the order a KDC does: the extended KDC checksum with all the signatures zero, then the server checksum
+	// with the extended KDC signature in place, then the KDC checksum over the server signature.
+	b := testBuilder(t)
+	data, err := b.Build()
+	if err != nil {
+		t.Fatalf("error building PAC: %v", err)
+	}
+	var p PACType
+	err = p.Unmarshal(data)
+	if err != nil {
+		t.Fatalf("error unmarshaling PAC: %v", err)
+	}
+	var tests = []struct {
+		Signature func() (SignatureData, error)
+		Expected  string
+	}{
+		{p.ExtendedKDCChecksum, "90992390fb7ec6f5f1b43280"},
+		{p.ServerChecksum, "e8828c33ddb61ee4421fced2"},
+		{p.KDCChecksum, "1c5598b47383c116aed45d2e"},
+	}
+	for i, test := range tests {
+		s, err := test.Signature()
+		if err != nil {
+			t.Fatalf("error getting signature for test %d: %v", i, err)
+		}
+		assert.Equal(t, test.Expected, hex.EncodeToString(s.Signature), "signature not as expected for test %d", i)
+	}
+}
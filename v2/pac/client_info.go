@@ -0,0 +1,84 @@
+package pac
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/jcmturner/rpc/v2/mstypes"
+)
+
+// ClientInfo implements https://msdn.microsoft.com/en-us/library/cc237951.aspx
+type ClientInfo struct {
+	ClientID   mstypes.FileTime // The authentication time of the ticket the PAC is in
+	NameLength uint16           // Length of the Name in bytes
+	Name       string           // The client's name without the realm
+}
+
+// Unmarshal bytes into the ClientInfo struct.
+func (k *ClientInfo) Unmarshal(b []byte) (err error) {
+	r := mstypes.NewReader(bytes.NewReader(b))
+	k.ClientID, err = r.FileTime()
+	if err != nil {
+		return fmt.Errorf("error unmarshaling ClientInfo: %v", err)
+	}
+	k.NameLength, err = r.Uint16()
+	if err != nil {
+		return fmt.Errorf("error unmarshaling ClientInfo: %v", err)
+	}
+	if int(k.NameLength) > len(b)-mstypes.SizeUint64-mstypes.SizeUint16 {
+		return fmt.Errorf("error unmarshaling ClientInfo: name of %d bytes extends beyond the buffer", k.NameLength)
+	}
+	k.Name, err = r.UTF16String(int(k.NameLength))
+	if err != nil {
+		return fmt.Errorf("error unmarshaling ClientInfo: %v", err)
+	}
+	return nil
+}
+
+// Marshal returns the bytes of the ClientInfo. The NameLength is that of the Name.
+func (k *ClientInfo) Marshal() []byte {
+	n := utf16.Encode([]rune(k.Name))
+	b := make([]byte, mstypes.SizeUint64+mstypes.SizeUint16, mstypes.SizeUint64+mstypes.SizeUint16+2*len(n))
+	binary.LittleEndian.PutUint32(b[0:4], k.ClientID.LowDateTime)
+	binary.LittleEndian.PutUint32(b[4:8], k.ClientID.HighDateTime)
+	binary.LittleEndian.PutUint16(b[8:10], uint16(2*len(n)))
+	return appendUTF16(b, n)
+}
+
+// appendUTF16 appends the little-endian bytes of the UTF-16 code units.
+func appendUTF16(b []byte, u []uint16) []byte {
+	for _, c := range u {
+		b = append(b, byte(c), byte(c>>8))
+	}
+	return b
+}
+
+// Validate checks the ClientInfo matches the authentication time and client name, without the realm, of the ticket
+// the PAC is in, as specified in MS-PAC section 2.7.
+// The authentication time is compared to the second, the precision of Kerberos times, and the name without regard
+// to case.
+func (k *ClientInfo) Validate(authTime time.Time, clientName string) error {
+	if !k.ClientID.Time().Truncate(time.Second).Equal(authTime.Truncate(time.Second)) {
+		return fmt.Errorf("client info time %v does not match the ticket's authentication time %v", k.ClientID.Time(), authTime)
+	}
+	if !strings.EqualFold(k.Name, clientName) {
+		return fmt.Errorf("client info name %s does not match the ticket's client name %s", k.Name, clientName)
+	}
+	return nil
+}
+
+// ClientInfo returns the ClientInfo of the PAC's client information buffer.
+func (p *PACType) ClientInfo() (k ClientInfo, err error) {
+	b, ok := p.Buffer(InfoTypeClientInfo)
+	if !ok {
+		err = errors.New("PAC does not contain a client information buffer")
+		return
+	}
+	err = k.Unmarshal(b)
+	return
+}
@@ -0,0 +1,34 @@
+package pac
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testClientInfo = "00100b2807e1d2011200740065007300740075007300650072003100"
+
+func TestClientInfo_Unmarshal(t *testing.T) {
+	b, _ := hex.DecodeString(testClientInfo)
+	var k ClientInfo
+	err := k.Unmarshal(b)
+	if err != nil {
+		t.Fatalf("error unmarshaling ClientInfo: %v", err)
+	}
+	authTime := time.Date(2017, 6, 9, 10, 0, 0, 0, time.UTC)
+	assert.Equal(t, authTime, k.ClientID.Time().UTC(), "client ID time not as expected")
+	assert.Equal(t, uint16(18), k.NameLength, "name length not as expected")
+	assert.Equal(t, "testuser1", k.Name, "name not as expected")
+	assert.Equal(t, testClientInfo, hex.EncodeToString(k.Marshal()), "marshaled bytes not as expected")
+
+	assert.NoError(t, k.Validate(authTime.Add(time.Millisecond), "TestUser1"), "client info should be valid")
+	assert.Error(t, k.Validate(authTime.Add(time.Second), "testuser1"), "client info with a different time should not be valid")
+	assert.Error(t, k.Validate(authTime, "testuser2"), "client info with a different name should not be valid")
+
+	err = k.Unmarshal(b[:len(b)-2])
+	assert.Error(t, err, "name extending beyond the buffer should return an error")
+	err = k.Unmarshal(b[:6])
+	assert.Error(t, err, "too few bytes should return an error")
+}
@@ -0,0 +1,151 @@
+package pac
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/jcmturner/rpc/v2/mstypes"
+	"github.com/jcmturner/rpc/v2/ndr"
+)
+
+// CredentialKeyUsage is the key usage number, KERB_NON_KERB_SALT, used when encrypting the SerializedData of a
+// CredentialInfo with the key of the AS reply.
+const CredentialKeyUsage = 16
+
+const (
+	credentialInfoVersion = 0
+	credentialInfoBytes   = 8
+	// NTLMPackageName is the PackageName of the SecPkgSupplementalCred holding an NTLMSupplementalCredential.
+	NTLMPackageName = "NTLM"
+)
+
+// CredentialInfo implements https://msdn.microsoft.com/en-us/library/cc237953.aspx
+type CredentialInfo struct {
+	Version        uint32 // A 32-bit unsigned integer in little-endian format that defines the version. MUST be 0x00000000.
+	EncryptionType uint32 // The Kerberos encryption type used to encrypt the SerializedData
+	SerializedData []byte // The encrypted NDR encoded CredentialData
+}
+
+// CredentialDecryptFunc decrypts the SerializedData of a CredentialInfo with the key of the AS reply, using the
+// CredentialKeyUsage, and returns the NDR encoded CredentialData.
+type CredentialDecryptFunc func(key, data []byte) ([]byte, error)
+
+// CredentialDecrypters are the functions used to decrypt the SerializedData of a CredentialInfo keyed by the
+// Kerberos encryption type they implement.
+type CredentialDecrypters map[uint32]CredentialDecryptFunc
+
+// Unmarshal bytes into the CredentialInfo struct.
+func (c *CredentialInfo) Unmarshal(b []byte) error {
+	if len(b) < credentialInfoBytes {
+		return errors.New("error unmarshaling CredentialInfo: too few bytes")
+	}
+	c.Version = binary.LittleEndian.Uint32(b[0:4])
+	if c.Version != credentialInfoVersion {
+		return fmt.Errorf("error unmarshaling CredentialInfo: version %d not supported", c.Version)
+	}
+	c.EncryptionType = binary.LittleEndian.Uint32(b[4:8])
+	c.SerializedData = b[8:]
+	return nil
+}
+
+// Decrypt decrypts the SerializedData with the key of the AS reply, using the decrypter for the EncryptionType, and
+// unmarshals the CredentialData.
+func (c *CredentialInfo) Decrypt(d CredentialDecrypters, key []byte) (CredentialData, error) {
+	f, ok := d[c.EncryptionType]
+	if !ok {
+		return CredentialData{}, fmt.Errorf("no decrypter for CredentialInfo encryption type %d", c.EncryptionType)
+	}
+	b, err := f(key, c.SerializedData)
+	if err != nil {
+		return CredentialData{}, fmt.Errorf("error decrypting CredentialInfo: %v", err)
+	}
+	var k CredentialData
+	err = k.Unmarshal(b)
+	return k, err
+}
+
+// CredentialData implements https://msdn.microsoft.com/en-us/library/cc237952.aspx
+type CredentialData struct {
+	CredentialCount uint32
+	Credentials     []SecPkgSupplementalCred `ndr:"conformant"` // Size is the value of CredentialCount
+}
+
+// Unmarshal bytes, the decrypted SerializedData of a CredentialInfo, into the CredentialData struct.
+func (k *CredentialData) Unmarshal(b []byte) error {
+	err := ndr.Unmarshal(b, k)
+	if err != nil {
+		return fmt.Errorf("error unmarshaling CredentialData: %v", err)
+	}
+	return nil
+}
+
+// NTLM returns the NTLMSupplementalCredential of the NTLM package's credentials.
+func (k *CredentialData) NTLM() (NTLMSupplementalCredential, error) {
+	for _, c := range k.Credentials {
+		if c.PackageName.Value == NTLMPackageName {
+			var n NTLMSupplementalCredential
+			err := n.Unmarshal(c.Credentials)
+			return n, err
+		}
+	}
+	return NTLMSupplementalCredential{}, errors.New("CredentialData does not contain NTLM credentials")
+}
+
+// SecPkgSupplementalCred implements https://msdn.microsoft.com/en-us/library/cc237956.aspx
+type SecPkgSupplementalCred struct {
+	PackageName    mstypes.RPCUnicodeString // The name of the security package the credentials are for
+	CredentialSize uint32
+	Credentials    SupplementalCredentials `ndr:"pointer,conformant"`
+}
+
+// SupplementalCredentials are the bytes of a security package's credentials, the size of which is the
+// CredentialSize of the SecPkgSupplementalCred.
+type SupplementalCredentials []byte
+
+// Size returns the size of the bytes of the credentials
+func (c SupplementalCredentials) Size(s interface{}) int {
+	return int(s.(SecPkgSupplementalCred).CredentialSize)
+}
+
+// Flags of NTLMSupplementalCredential
+const (
+	NTLMSupCredLMOWF uint32 = 0x00000001 // The LmPassword field contains the LM one way function of the password.
+	NTLMSupCredNTOWF uint32 = 0x00000002 // The NtPassword field contains the NT one way function of the password.
+)
+
+const ntlmSupplementalCredentialBytes = 40
+
+// NTLMSupplementalCredential implements https://msdn.microsoft.com/en-us/library/cc237949.aspx
+type NTLMSupplementalCredential struct {
+	Version    uint32 // A 32-bit unsigned integer that defines the credential version.This field MUST be 0x00000000.
+	Flags      uint32
+	LMPassword [16]byte // A 16-element array of unsigned 8-bit integers that define the LM OWF.
+	NTPassword [16]byte // A 16-element array of unsigned 8-bit integers that define the NT OWF.
+}
+
+// Unmarshal bytes into the NTLMSupplementalCredential struct.
+func (n *NTLMSupplementalCredential) Unmarshal(b []byte) error {
+	if len(b) < ntlmSupplementalCredentialBytes {
+		return errors.New("error unmarshaling NTLMSupplementalCredential: too few bytes")
+	}
+	n.Version = binary.LittleEndian.Uint32(b[0:4])
+	if n.Version != 0 {
+		return fmt.Errorf("error unmarshaling NTLMSupplementalCredential: version %d not supported", n.Version)
+	}
+	n.Flags = binary.LittleEndian.Uint32(b[4:8])
+	copy(n.LMPassword[:], b[8:24])
+	copy(n.NTPassword[:], b[24:40])
+	return nil
+}
+
+// CredentialInfo returns the CredentialInfo of the PAC's credentials buffer.
+func (p *PACType) CredentialInfo() (c CredentialInfo, err error) {
+	b, ok := p.Buffer(InfoTypeCredentials)
+	if !ok {
+		err = errors.New("PAC does not contain a credentials buffer")
+		return
+	}
+	err = c.Unmarshal(b)
+	return
+}
@@ -0,0 +1,95 @@
+package pac
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testCredentialData = "01100800cccccccc6000000000000000000002000100000001000000080008000400020028000000080002000500000000000000050000004e0054004c004d0000000000280000000000000002000000000000000000000000000000000000008846f7eaee8fb117ad06bdd830b7586c"
+
+// testXOR is a stand in for a Kerberos decryption function.
+func testXOR(key, data []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, errors.New("no key")
+	}
+	b := make([]byte, len(data))
+	for i := range data {
+		b[i] = data[i] ^ key[i%len(key)]
+	}
+	return b, nil
+}
+
+func TestCredentialData_Unmarshal(t *testing.T) {
+	b, _ := hex.DecodeString(testCredentialData)
+	var k CredentialData
+	err := k.Unmarshal(b)
+	if err != nil {
+		t.Fatalf("error unmarshaling CredentialData: %v", err)
+	}
+	assert.Equal(t, uint32(1), k.CredentialCount, "CredentialCount not as expected")
+	assert.Equal(t, NTLMPackageName, k.Credentials[0].PackageName.String(), "PackageName not as expected")
+	assert.Equal(t, uint32(40), k.Credentials[0].CredentialSize, "CredentialSize not as expected")
+	assert.Len(t, k.Credentials[0].Credentials, 40, "Credentials not the CredentialSize")
+	n, err := k.NTLM()
+	if err != nil {
+		t.Fatalf("error getting NTLM credentials: %v", err)
+	}
+	assert.Equal(t, NTLMSupCredNTOWF, n.Flags, "NTLM flags not as expected")
+	assert.Equal(t, "8846f7eaee8fb117ad06bdd830b7586c", hex.EncodeToString(n.NTPassword[:]), "NT OWF not as expected")
+	assert.Equal(t, [16]byte{}, n.LMPassword, "LM OWF not as expected")
+
+	k.Credentials[0].PackageName.Value = "Other"
+	_, err = k.NTLM()
+	assert.Error(t, err, "missing NTLM credentials should return an error")
+	err = n.Unmarshal(make([]byte, 20))
+	assert.Error(t, err, "too few bytes should return an error")
+}
+
+func TestCredentialInfo_Decrypt(t *testing.T) {
+	plain, _ := hex.DecodeString(testCredentialData)
+	key := []byte{0x5a, 0xa5, 0x01}
+	enc, _ := testXOR(key, plain)
+	b := make([]byte, 8, 8+len(enc))
+	binary.LittleEndian.PutUint32(b[4:8], 18)
+	b = append(b, enc...)
+
+	var p PACType
+	err := p.Unmarshal(layoutPAC([]pacBuffer{{InfoTypeCredentials, b}}))
+	if err != nil {
+		t.Fatalf("error unmarshaling PAC: %v", err)
+	}
+	c, err := p.CredentialInfo()
+	if err != nil {
+		t.Fatalf("error getting CredentialInfo: %v", err)
+	}
+	assert.Equal(t, uint32(0), c.Version, "Version not as expected")
+	assert.Equal(t, uint32(18), c.EncryptionType, "EncryptionType not as expected")
+
+	d := CredentialDecrypters{18: testXOR}
+	k, err := c.Decrypt(d, key)
+	if err != nil {
+		t.Fatalf("error decrypting CredentialInfo: %v", err)
+	}
+	n, err := k.NTLM()
+	if err != nil {
+		t.Fatalf("error getting NTLM credentials: %v", err)
+	}
+	assert.Equal(t, "8846f7eaee8fb117ad06bdd830b7586c", hex.EncodeToString(n.NTPassword[:]), "NT OWF not as expected")
+
+	_, err = c.Decrypt(CredentialDecrypters{17: testXOR}, key)
+	assert.Error(t, err, "missing decrypter should return an error")
+	_, err = c.Decrypt(d, nil)
+	assert.Error(t, err, "decryption error should be returned")
+	_, err = c.Decrypt(d, []byte{1})
+	assert.Error(t, err, "decryption with the wrong key should return an error")
+
+	b[0] = 1
+	err = c.Unmarshal(b)
+	assert.Error(t, err, "unsupported version should return an error")
+	err = c.Unmarshal(b[:4])
+	assert.Error(t, err, "too few bytes should return an error")
+}
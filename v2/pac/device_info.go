@@ -0,0 +1,108 @@
+package pac
+
+import (
+	"fmt"
+
+	"github.com/jcmturner/rpc/v2/mstypes"
+	"github.com/jcmturner/rpc/v2/ndr"
+)
+
+// DeviceInfo implements https://msdn.microsoft.com/en-us/library/hh536402.aspx
+type DeviceInfo struct {
+	UserID            uint32                          // A 32-bit unsigned integer that contains the RID of the account.
+	PrimaryGroupID    uint32                          // A 32-bit unsigned integer that contains the RID for the primary group of the account.
+	AccountDomainID   mstypes.RPCSID                  `ndr:"pointer"` // A SID structure that contains the SID for the domain of the account.
+	AccountGroupCount uint32                          // A 32-bit unsigned integer that contains the number of groups within the account domain to which the account belongs
+	AccountGroupIDs   []mstypes.GroupMembership       `ndr:"pointer,conformant"` // A pointer to a list of GROUP_MEMBERSHIP structures that contains the groups to which the account belongs in the account domain.
+	SIDCount          uint32                          // A 32-bit unsigned integer that contains the total number of SIDs present in the ExtraSids member.
+	ExtraSIDs         []mstypes.KerbSidAndAttributes  `ndr:"pointer,conformant"` // A pointer to a list of KERB_SID_AND_ATTRIBUTES structures that contain a list of SIDs corresponding to groups not in domains.
+	DomainGroupCount  uint32                          // A 32-bit unsigned integer that contains the number of domains with groups to which the account belongs.
+	DomainGroup       []mstypes.DomainGroupMembership `ndr:"pointer,conformant"` // A pointer to a list of DOMAIN_GROUP_MEMBERSHIP structures that contain the domains to which the account belongs to a group.
+}
+
+// Unmarshal bytes into the DeviceInfo struct.
+func (k *DeviceInfo) Unmarshal(b []byte) error {
+	err := ndr.Unmarshal(b, k)
+	if err != nil {
+		return fmt.Errorf("error unmarshaling DeviceInfo: %v", err)
+	}
+	return nil
+}
+
+// DeviceSID returns the SID of the device formed from the AccountDomainID and the UserID.
+func (k *DeviceInfo) DeviceSID() (mstypes.RPCSID, error) {
+	return k.AccountDomainID.AppendRID(k.UserID)
+}
+
+// SIDs returns the SIDs of the device followed by those of the groups the device is a member of, along with their
+// attributes. The SIDs are in the order:
+//   - The device SID formed from the AccountDomainID and UserID.
+//   - The primary group SID formed from the AccountDomainID and PrimaryGroupID, if it is not also in the
+//     AccountGroupIDs.
+//   - The SIDs formed from the AccountDomainID and each of the AccountGroupIDs.
+//   - The ExtraSIDs.
+//   - The SIDs formed from the DomainID and each of the GroupIDs of each DomainGroup.
+//
+// The device SID has no attributes and the primary group, when not in the AccountGroupIDs, is mandatory and enabled.
+func (k *DeviceInfo) SIDs() ([]mstypes.KerbSidAndAttributes, error) {
+	dsid, err := k.DeviceSID()
+	if err != nil {
+		return nil, fmt.Errorf("could not form device SID: %v", err)
+	}
+	sids := []mstypes.KerbSidAndAttributes{{SID: dsid}}
+	if !k.inAccountGroupIDs(k.PrimaryGroupID) {
+		psid, err := k.AccountDomainID.AppendRID(k.PrimaryGroupID)
+		if err != nil {
+			return nil, fmt.Errorf("could not form primary group SID: %v", err)
+		}
+		var a mstypes.GroupAttributes
+		a.SetFlag(mstypes.SEGroupMandatory | mstypes.SEGroupEnabledByDefault | mstypes.SEGroupEnabled)
+		sids = append(sids, mstypes.KerbSidAndAttributes{SID: psid, Attributes: a})
+	}
+	for _, g := range k.AccountGroupIDs {
+		s, err := k.AccountDomainID.AppendRID(g.RelativeID)
+		if err != nil {
+			return nil, fmt.Errorf("could not form account group SID: %v", err)
+		}
+		sids = append(sids, mstypes.KerbSidAndAttributes{SID: s, Attributes: g.Attributes})
+	}
+	sids = append(sids, k.ExtraSIDs...)
+	for _, d := range k.DomainGroup {
+		for _, g := range d.GroupIDs {
+			s, err := d.DomainID.AppendRID(g.RelativeID)
+			if err != nil {
+				return nil, fmt.Errorf("could not form domain group SID: %v", err)
+			}
+			sids = append(sids, mstypes.KerbSidAndAttributes{SID: s, Attributes: g.Attributes})
+		}
+	}
+	return sids, nil
+}
+
+// GroupMembershipSIDs returns the string representations of the SIDs of the groups the device is a member of.
+func (k *DeviceInfo) GroupMembershipSIDs() ([]string, error) {
+	sids, err := k.SIDs()
+	if err != nil {
+		return nil, err
+	}
+	s := make([]string, 0, len(sids)-1)
+	for _, g := range sids[1:] {
+		s = append(s, g.SID.String())
+	}
+	return s, nil
+}
+
+func (k *DeviceInfo) inAccountGroupIDs(rid uint32) bool {
+	for _, g := range k.AccountGroupIDs {
+		if g.RelativeID == rid {
+			return true
+		}
+	}
+	return false
+}
+
+// DeviceInfo returns the DeviceInfo of the PAC's device information buffer.
+func (p *PACType) DeviceInfo() (k DeviceInfo, err error) {
+	err = p.decodeBuffer(InfoTypeDeviceInfo, "device information", &k)
+	return
+}
@@ -0,0 +1,70 @@
+package pac
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/jcmturner/rpc/v2/mstypes"
+	"github.com/stretchr/testify/assert"
+)
+
+const testDeviceInfo = "01100800ccccccccb000000000000000000002005004000003020000040002000100000008000200010000000c0002000100000014000200040000000104000000000005150000004c86cebca07160e63fdce887010000000302000007000000010000001000020007000000010000000101000000000012010000000100000018000200020000001c000200040000000104000000000005150000002057308834e7d1d0a2fb0444020000005604000007000020570400000700002000000000"
+
+func TestDeviceInfo_Unmarshal(t *testing.T) {
+	b, _ := hex.DecodeString(testDeviceInfo)
+	var k DeviceInfo
+	err := k.Unmarshal(b)
+	if err != nil {
+		t.Fatalf("error unmarshaling DeviceInfo: %v", err)
+	}
+	assert.Equal(t, uint32(1104), k.UserID, "UserID not as expected")
+	assert.Equal(t, uint32(515), k.PrimaryGroupID, "PrimaryGroupID not as expected")
+	assert.Equal(t, "S-1-5-21-3167651404-3865080224-2280184895", k.AccountDomainID.String(), "AccountDomainID not as expected")
+	assert.Equal(t, 1, len(k.AccountGroupIDs), "number of account groups not as expected")
+	assert.Equal(t, 1, len(k.ExtraSIDs), "number of extra SIDs not as expected")
+	if assert.Equal(t, 1, len(k.DomainGroup), "number of domain groups not as expected") {
+		assert.Equal(t, "S-1-5-21-2284869408-3503417140-1141177250", k.DomainGroup[0].DomainID.String(), "domain group DomainID not as expected")
+		assert.Equal(t, uint32(2), k.DomainGroup[0].GroupCount, "domain group GroupCount not as expected")
+	}
+
+	d, err := k.DeviceSID()
+	if err != nil {
+		t.Fatalf("error getting device SID: %v", err)
+	}
+	assert.Equal(t, "S-1-5-21-3167651404-3865080224-2280184895-1104", d.String(), "device SID not as expected")
+	g, err := k.GroupMembershipSIDs()
+	if err != nil {
+		t.Fatalf("error getting group membership SIDs: %v", err)
+	}
+	assert.Equal(t, []string{
+		"S-1-5-21-3167651404-3865080224-2280184895-515",
+		"S-1-18-1",
+		"S-1-5-21-2284869408-3503417140-1141177250-1110",
+		"S-1-5-21-2284869408-3503417140-1141177250-1111",
+	}, g, "group membership SIDs not as expected")
+	s, err := k.SIDs()
+	if err != nil {
+		t.Fatalf("error getting SIDs: %v", err)
+	}
+	assert.Equal(t, mstypes.GroupAttributes(0x20000007), s[3].Attributes, "domain group attributes not as expected")
+
+	// The primary group is added when not in the account groups.
+	k.PrimaryGroupID = 513
+	g, err = k.GroupMembershipSIDs()
+	if err != nil {
+		t.Fatalf("error getting group membership SIDs: %v", err)
+	}
+	assert.Equal(t, "S-1-5-21-3167651404-3865080224-2280184895-513", g[0], "primary group SID not as expected")
+	assert.Equal(t, 5, len(g), "number of group membership SIDs not as expected")
+
+	var p PACType
+	err = p.Unmarshal(layoutPAC([]pacBuffer{{InfoTypeDeviceInfo, b}}))
+	if err != nil {
+		t.Fatalf("error unmarshaling PAC: %v", err)
+	}
+	k, err = p.DeviceInfo()
+	if err != nil {
+		t.Fatalf("error getting DeviceInfo from PAC: %v", err)
+	}
+	assert.Equal(t, uint32(1104), k.UserID, "UserID from PAC not as expected")
+}
@@ -0,0 +1,162 @@
+// Package pac provides types for the Privilege Attribute Certificate (PAC) data structures
+// https://msdn.microsoft.com/en-us/library/cc237917.aspx
+package pac
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/jcmturner/rpc/v2/mstypes"
+	"github.com/jcmturner/rpc/v2/ndr"
+)
+
+// Flags of the UserFlags field of KerbValidationInfo https://msdn.microsoft.com/en-us/library/cc237948.aspx
+// The full set of flags are the mstypes UserFlag constants.
+const (
+	UserFlagExtraSIDs      = mstypes.UserFlagExtraSIDs      // The ExtraSIDs field contains SIDs.
+	UserFlagResourceGroups = mstypes.UserFlagResourceGroups // The ResourceGroupDomainSID and ResourceGroupIDs fields are populated.
+)
+
+// KerbValidationInfo implements https://msdn.microsoft.com/en-us/library/cc237948.aspx
+type KerbValidationInfo struct {
+	LogOnTime              mstypes.FileTime
+	LogOffTime             mstypes.FileTime
+	KickOffTime            mstypes.FileTime
+	PasswordLastSet        mstypes.FileTime
+	PasswordCanChange      mstypes.FileTime
+	PasswordMustChange     mstypes.FileTime
+	EffectiveName          mstypes.RPCUnicodeString
+	FullName               mstypes.RPCUnicodeString
+	LogonScript            mstypes.RPCUnicodeString
+	ProfilePath            mstypes.RPCUnicodeString
+	HomeDirectory          mstypes.RPCUnicodeString
+	HomeDirectoryDrive     mstypes.RPCUnicodeString
+	LogonCount             uint16
+	BadPasswordCount       uint16
+	UserID                 uint32
+	PrimaryGroupID         uint32
+	GroupCount             uint32
+	GroupIDs               []mstypes.GroupMembership `ndr:"pointer,conformant"`
+	UserFlags              mstypes.UserFlags
+	UserSessionKey         mstypes.UserSessionKey
+	LogonServer            mstypes.RPCUnicodeString
+	LogonDomainName        mstypes.RPCUnicodeString
+	LogonDomainID          mstypes.RPCSID `ndr:"pointer"`
+	Reserved1              [2]uint32      // Has 2 elements
+	UserAccountControl     mstypes.UserAccountControl
+	SubAuthStatus          uint32
+	LastSuccessfulILogon   mstypes.FileTime
+	LastFailedILogon       mstypes.FileTime
+	FailedILogonCount      uint32
+	Reserved3              uint32
+	SIDCount               uint32
+	ExtraSIDs              []mstypes.KerbSidAndAttributes `ndr:"pointer,conformant"`
+	ResourceGroupDomainSID mstypes.RPCSID                 `ndr:"pointer"`
+	ResourceGroupCount     uint32
+	ResourceGroupIDs       []mstypes.GroupMembership `ndr:"pointer,conformant"`
+}
+
+// Unmarshal bytes into the KerbValidationInfo struct.
+func (k *KerbValidationInfo) Unmarshal(b []byte) error {
+	err := ndr.Unmarshal(b, k)
+	if err != nil {
+		return fmt.Errorf("error unmarshaling KerbValidationInfo: %v", err)
+	}
+	return nil
+}
+
+// Marshal returns the NDR encoding of the KerbValidationInfo.
+func (k *KerbValidationInfo) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	err := ndr.NewEncoder(&buf).Encode(k)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling KerbValidationInfo: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// UserSID returns the SID of the user formed from the LogonDomainID and the UserID.
+func (k *KerbValidationInfo) UserSID() (mstypes.RPCSID, error) {
+	return k.LogonDomainID.AppendRID(k.UserID)
+}
+
+// SIDs returns the SIDs of the user followed by those of the groups the user is a member of, along with their
+// attributes. The SIDs are in the order:
+//   - The user SID formed from the LogonDomainID and UserID.
+//   - The primary group SID formed from the LogonDomainID and PrimaryGroupID, if it is not also in the GroupIDs.
+//   - The SIDs formed from the LogonDomainID and each of the GroupIDs.
+//   - The ExtraSIDs, if the UserFlagExtraSIDs flag is set.
+//   - The SIDs formed from the ResourceGroupDomainSID and each of the ResourceGroupIDs, if the UserFlagResourceGroups
+//     flag is set.
+//
+// The user SID has no attributes and the primary group, when not in the GroupIDs, is mandatory and enabled.
+func (k *KerbValidationInfo) SIDs() ([]mstypes.KerbSidAndAttributes, error) {
+	usid, err := k.UserSID()
+	if err != nil {
+		return nil, fmt.Errorf("could not form user SID: %v", err)
+	}
+	sids := []mstypes.KerbSidAndAttributes{{SID: usid}}
+	if !k.inGroupIDs(k.PrimaryGroupID) {
+		psid, err := k.LogonDomainID.AppendRID(k.PrimaryGroupID)
+		if err != nil {
+			return nil, fmt.Errorf("could not form primary group SID: %v", err)
+		}
+		var a mstypes.GroupAttributes
+		a.SetFlag(mstypes.SEGroupMandatory | mstypes.SEGroupEnabledByDefault | mstypes.SEGroupEnabled)
+		sids = append(sids, mstypes.KerbSidAndAttributes{SID: psid, Attributes: a})
+	}
+	for _, g := range k.GroupIDs {
+		s, err := k.LogonDomainID.AppendRID(g.RelativeID)
+		if err != nil {
+			return nil, fmt.Errorf("could not form group SID: %v", err)
+		}
+		sids = append(sids, mstypes.KerbSidAndAttributes{SID: s, Attributes: g.Attributes})
+	}
+	if k.UserFlags.Has(UserFlagExtraSIDs) {
+		sids = append(sids, k.ExtraSIDs...)
+	}
+	if k.UserFlags.Has(UserFlagResourceGroups) {
+		for _, g := range k.ResourceGroupIDs {
+			s, err := k.ResourceGroupDomainSID.AppendRID(g.RelativeID)
+			if err != nil {
+				return nil, fmt.Errorf("could not form resource group SID: %v", err)
+			}
+			sids = append(sids, mstypes.KerbSidAndAttributes{SID: s, Attributes: g.Attributes})
+		}
+	}
+	return sids, nil
+}
+
+// Token returns the access check token of the user and the groups the user is a member of.
+func (k *KerbValidationInfo) Token() (mstypes.Token, error) {
+	sids, err := k.SIDs()
+	if err != nil {
+		return mstypes.Token{}, err
+	}
+	return mstypes.Token{
+		UserSID: sids[0].SID,
+		Groups:  sids[1:],
+	}, nil
+}
+
+// GroupMembershipSIDs returns the string representations of the SIDs of the groups the user is a member of.
+func (k *KerbValidationInfo) GroupMembershipSIDs() ([]string, error) {
+	sids, err := k.SIDs()
+	if err != nil {
+		return nil, err
+	}
+	s := make([]string, 0, len(sids)-1)
+	for _, g := range sids[1:] {
+		s = append(s, g.SID.String())
+	}
+	return s, nil
+}
+
+func (k *KerbValidationInfo) inGroupIDs(rid uint32) bool {
+	for _, g := range k.GroupIDs {
+		if g.RelativeID == rid {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,330 @@
+package pac
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/jcmturner/rpc/v2/mstypes"
+	"github.com/jcmturner/rpc/v2/ndr"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testKerbValidationInfoMS     = "01100800cccccccca00400000000000000000200d186660f656ac601ffffffffffffff7fffffffffffffff7f17d439fe784ac6011794a328424bc601175424977a81c60108000800040002002400240008000200120012000c0002000000000010000200000000001400020000000000180002005410000097792c00010200001a0000001c000200200000000000000000000000000000000000000016001800200002000a000c002400020028000200000000000000000010000000000000000000000000000000000000000000000000000000000000000d0000002c0002000000000000000000000000000400000000000000040000006c007a00680075001200000000000000120000004c0069007100690061006e00670028004c006100720072007900290020005a00680075000900000000000000090000006e0074006400730032002e0062006100740000000000000000000000000000000000000000000000000000000000000000000000000000001a00000061c433000700000009c32d00070000005eb4320007000000010200000700000097b92c00070000002bf1320007000000ce30330007000000a72e2e00070000002af132000700000098b92c000700000062c4330007000000940133000700000076c4330007000000aefe2d000700000032d22c00070000001608320007000000425b2e00070000005fb4320007000000ca9c35000700000085442d0007000000c2f0320007000000e9ea310007000000ed8e2e0007000000b6eb310007000000ab2e2e0007000000720e2e00070000000c000000000000000b0000004e0054004400450056002d00440043002d003000350000000600000000000000050000004e0054004400450056000000040000000104000000000005150000005951b81766725d2564633b0b0d0000003000020007000000340002000700002038000200070000203c000200070000204000020007000020440002000700002048000200070000204c000200070000205000020007000020540002000700002058000200070000205c00020007000020600002000700002005000000010500000000000515000000b9301b2eb7414c6c8c3b351501020000050000000105000000000005150000005951b81766725d2564633b0b74542f00050000000105000000000005150000005951b81766725d2564633b0be8383200050000000105000000000005150000005951b81766725d2564633b0bcd383200050000000105000000000005150000005951b81766725d2564633b0b5db43200050000000105000000000005150000005951b81766725d2564633b0b41163500050000000105000000000005150000005951b81766725d2564633b0be8ea3100050000000105000000000005150000005951b81766725d2564633b0bc1193200050000000105000000000005150000005951b81766725d2564633b0b29f13200050000000105000000000005150000005951b81766725d2564633b0b0f5f2e00050000000105000000000005150000005951b81766725d2564633b0b2f5b2e00050000000105000000000005150000005951b81766725d2564633b0bef8f3100050000000105000000000005150000005951b81766725d2564633b0b075f2e0000000000"
+	testKerbValidationInfoGoKRB5 = "01100800cccccccc180200000000000000000200058e4fdd80c6d201ffffffffffffff7fffffffffffffff7fcc27969c39c6d201cce7ffc602c7d201ffffffffffffff7f12001200040002001600160008000200000000000c000200000000001000020000000000140002000000000018000200d80000005104000001020000050000001c000200200000000000000000000000000000000000000008000a002000020008000a00240002002800020000000000000000001002000000000000000000000000000000000000000000000000000000000000020000002c00020000000000000000000000000009000000000000000900000074006500730074007500730065007200310000000b000000000000000b000000540065007300740031002000550073006500720031000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000050000000102000007000000540400000700000055040000070000005b040000070000005c0400000700000005000000000000000400000041004400440043000500000000000000040000005400450053005400040000000104000000000005150000004c86cebca07160e63fdce8870200000030000200070000203400020007000020050000000105000000000005150000004c86cebca07160e63fdce8875a040000050000000105000000000005150000004c86cebca07160e63fdce8875704000000000000"
+	testKerbValidationInfoTrust  = "01100800cccccccc000200000000000000000200c30bcc79e444d301ffffffffffffff7fffffffffffffff7fc764125a0842d301c7247c84d142d301ffffffffffffff7f12001200040002001600160008000200000000000c0002000000000010000200000000001400020000000000180002002e0000005204000001020000030000001c0002002002000000000000000000000000000000000000060008002000020008000a00240002002800020000000000000000001002000000000000000000000000000000000000000000000000000000000000010000002c00020034000200020000003800020009000000000000000900000074006500730074007500730065007200310000000b000000000000000b0000005400650073007400310020005500730065007200310000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000300000056040000070000000102000007000000550400000700000004000000000000000300000055004400430000000500000000000000040000005500530045005200040000000104000000000005150000002057308834e7d1d0a2fb0444010000003000020007000000010000000101000000000012010000000400000001040000000000051500000062dc8db6c8705249b5459e75020000005304000007000020540400000700002000000000"
+)
+
+func TestKerbValidationInfo_Unmarshal(t *testing.T) {
+	var tests = []struct {
+		Hex           string
+		EffectiveName string
+		UserSID       string
+		GroupCount    int
+		ExtraSIDCount int
+	}{
+		{testKerbValidationInfoMS, "lzhu", "S-1-5-21-397955417-626881126-188441444-2914711", 26, 13},
+		{testKerbValidationInfoGoKRB5, "testuser1", "S-1-5-21-3167651404-3865080224-2280184895-1105", 5, 2},
+		{testKerbValidationInfoTrust, "testuser1", "S-1-5-21-2284869408-3503417140-1141177250-1106", 3, 1},
+	}
+	for i, test := range tests {
+		b, _ := hex.DecodeString(test.Hex)
+		var k KerbValidationInfo
+		err := k.Unmarshal(b)
+		if err != nil {
+			t.Fatalf("error unmarshaling test %d: %v", i, err)
+		}
+		assert.Equal(t, test.EffectiveName, k.EffectiveName.String(), "EffectiveName not as expected for test %d", i)
+		s, err := k.UserSID()
+		if err != nil {
+			t.Fatalf("error getting user SID for test %d: %v", i, err)
+		}
+		assert.Equal(t, test.UserSID, s.String(), "user SID not as expected for test %d", i)
+		assert.Equal(t, test.GroupCount, len(k.GroupIDs), "number of GroupIDs not as expected for test %d", i)
+		assert.Equal(t, test.ExtraSIDCount, len(k.ExtraSIDs), "number of ExtraSIDs not as expected for test %d", i)
+	}
+}
+
+func TestKerbValidationInfo_Marshal(t *testing.T) {
+	for i, h := range []string{testKerbValidationInfoMS, testKerbValidationInfoGoKRB5, testKerbValidationInfoTrust} {
+		b, _ := hex.DecodeString(h)
+		var k KerbValidationInfo
+		err := k.Unmarshal(b)
+		if err != nil {
+			t.Fatalf("error unmarshaling test %d: %v", i, err)
+		}
+		m, err := k.Marshal()
+		if err != nil {
+			t.Fatalf("error marshaling test %d: %v", i, err)
+		}
+		assert.Equal(t, h, hex.EncodeToString(m), "marshaled bytes not as expected for test %d", i)
+	}
+}
+
+func TestKerbValidationInfo_SIDs(t *testing.T) {
+	var tests = []struct {
+		Hex      string
+		Expected []string
+		Attrs    []mstypes.GroupAttributes
+	}{
+		{testKerbValidationInfoGoKRB5,
+			[]string{
+				"S-1-5-21-3167651404-3865080224-2280184895-1105",
+				"S-1-5-21-3167651404-3865080224-2280184895-513",
+				"S-1-5-21-3167651404-3865080224-2280184895-1108",
+				"S-1-5-21-3167651404-3865080224-2280184895-1109",
+				"S-1-5-21-3167651404-3865080224-2280184895-1115",
+				"S-1-5-21-3167651404-3865080224-2280184895-1116",
+				"S-1-5-21-3167651404-3865080224-2280184895-1114",
+				"S-1-5-21-3167651404-3865080224-2280184895-1111",
+			},
+			[]mstypes.GroupAttributes{0, 7, 7, 7, 7, 7, 536870919, 536870919},
+		},
+		{testKerbValidationInfoTrust,
+			[]string{
+				"S-1-5-21-2284869408-3503417140-1141177250-1106",
+				"S-1-5-21-2284869408-3503417140-1141177250-1110",
+				"S-1-5-21-2284869408-3503417140-1141177250-513",
+				"S-1-5-21-2284869408-3503417140-1141177250-1109",
+				"S-1-18-1",
+				"S-1-5-21-3062750306-1230139592-1973306805-1107",
+				"S-1-5-21-3062750306-1230139592-1973306805-1108",
+			},
+			[]mstypes.GroupAttributes{0, 7, 7, 7, 7, 536870919, 536870919},
+		},
+	}
+	for i, test := range tests {
+		b, _ := hex.DecodeString(test.Hex)
+		var k KerbValidationInfo
+		err := k.Unmarshal(b)
+		if err != nil {
+			t.Fatalf("error unmarshaling test %d: %v", i, err)
+		}
+		sids, err := k.SIDs()
+		if err != nil {
+			t.Fatalf("error getting SIDs for test %d: %v", i, err)
+		}
+		var s []string
+		var a []mstypes.GroupAttributes
+		for _, sid := range sids {
+			s = append(s, sid.SID.String())
+			a = append(a, sid.Attributes)
+		}
+		assert.Equal(t, test.Expected, s, "SIDs not as expected for test %d", i)
+		assert.Equal(t, test.Attrs, a, "SID attributes not as expected for test %d", i)
+		g, err := k.GroupMembershipSIDs()
+		if err != nil {
+			t.Fatalf("error getting group membership SIDs for test %d: %v", i, err)
+		}
+		assert.Equal(t, test.Expected[1:], g, "group membership SIDs not as expected for test %d", i)
+	}
+}
+
+func TestKerbValidationInfo_SIDsMS(t *testing.T) {
+	b, _ := hex.DecodeString(testKerbValidationInfoMS)
+	var k KerbValidationInfo
+	err := k.Unmarshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sids, err := k.SIDs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// User, 26 GroupIDs including the primary group and 13 ExtraSIDs. The resource groups flag is not set.
+	assert.Equal(t, 40, len(sids), "number of SIDs not as expected")
+	assert.Equal(t, "S-1-5-21-397955417-626881126-188441444-2914711", sids[0].SID.String(), "user SID not as expected")
+	for i, g := range k.GroupIDs {
+		assert.True(t, sids[i+1].SID.String() == k.LogonDomainID.String()+"-"+fmt.Sprint(g.RelativeID), "group %d SID not as expected", i)
+		rid, err := sids[i+1].SID.RID()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, g.RelativeID, rid, "group %d RID not as expected", i)
+		assert.Equal(t, g.Attributes, sids[i+1].Attributes, "group %d attributes not as expected", i)
+	}
+	assert.Equal(t, k.ExtraSIDs, sids[27:], "extra SIDs not as expected")
+	assert.Equal(t, "S-1-5-21-773533881-1816936887-355810188-513", sids[27].SID.String(), "first extra SID not as expected")
+}
+
+func TestKerbValidationInfo_SIDsFlags(t *testing.T) {
+	domain, _ := mstypes.ParseSID("S-1-5-21-1-2-3")
+	resource, _ := mstypes.ParseSID("S-1-5-21-4-5-6")
+	extra, _ := mstypes.ParseSID("S-1-18-1")
+	k := KerbValidationInfo{
+		UserID:                 1105,
+		PrimaryGroupID:         513,
+		GroupIDs:               []mstypes.GroupMembership{{RelativeID: 1200, Attributes: 7}},
+		LogonDomainID:          domain,
+		ExtraSIDs:              []mstypes.KerbSidAndAttributes{{SID: extra, Attributes: 7}},
+		ResourceGroupDomainSID: resource,
+		ResourceGroupIDs:       []mstypes.GroupMembership{{RelativeID: 1300, Attributes: 536870919}},
+	}
+	var tests = []struct {
+		UserFlags mstypes.UserFlags
+		Expected  []string
+	}{
+		{0, []string{"S-1-5-21-1-2-3-513", "S-1-5-21-1-2-3-1200"}},
+		{UserFlagExtraSIDs, []string{"S-1-5-21-1-2-3-513", "S-1-5-21-1-2-3-1200", "S-1-18-1"}},
+		{UserFlagResourceGroups, []string{"S-1-5-21-1-2-3-513", "S-1-5-21-1-2-3-1200", "S-1-5-21-4-5-6-1300"}},
+		{UserFlagExtraSIDs | UserFlagResourceGroups, []string{"S-1-5-21-1-2-3-513", "S-1-5-21-1-2-3-1200", "S-1-18-1", "S-1-5-21-4-5-6-1300"}},
+	}
+	for i, test := range tests {
+		k.UserFlags = test.UserFlags
+		g, err := k.GroupMembershipSIDs()
+		if err != nil {
+			t.Fatalf("error getting group membership SIDs for test %d: %v", i, err)
+		}
+		assert.Equal(t, test.Expected, g, "group membership SIDs not as expected for test %d", i)
+	}
+	tok, err := k.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "S-1-5-21-1-2-3-1105", tok.UserSID.String(), "token user SID not as expected")
+	assert.Equal(t, mstypes.SEGroupMandatory|mstypes.SEGroupEnabledByDefault|mstypes.SEGroupEnabled, tok.Groups[0].Attributes, "primary group attributes not as expected")
+	assert.False(t, tok.HasSID(resource, false), "resource domain SID should not be in the token")
+}
+
+func TestKerbValidationInfo_TokenAccessCheck(t *testing.T) {
+	b, _ := hex.DecodeString(testKerbValidationInfoTrust)
+	var k KerbValidationInfo
+	err := k.Unmarshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok, err := k.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests = []struct {
+		SDDL string
+		OK   bool
+	}{
+		{"D:(A;;FR;;;S-1-5-21-3062750306-1230139592-1973306805-1108)", true},
+		{"D:(A;;FR;;;S-1-5-21-2284869408-3503417140-1141177250-1106)", true},
+		{"D:(A;;FR;;;S-1-18-1)", true},
+		{"D:(D;;FR;;;S-1-5-21-2284869408-3503417140-1141177250-513)(A;;FR;;;S-1-5-21-2284869408-3503417140-1141177250-1106)", false},
+		{"D:(A;;FR;;;S-1-5-21-2284869408-3503417140-1141177250-512)", false},
+	}
+	for i, test := range tests {
+		sd, err := mstypes.ParseSDDL(test.SDDL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, ok := mstypes.AccessCheck(&sd, &tok, mstypes.AccessRequest{DesiredAccess: mstypes.AccessGenericRead, GenericMapping: mstypes.FileGenericMapping})
+		assert.Equal(t, test.OK, ok, "access check result not as expected for test %d", i)
+	}
+}
+
+// testKerbValidationInfoSchema describes KERB_VALIDATION_INFO, as specified in MS-PAC section 2.5, for decoding
+// without the Go structs.
+const testKerbValidationInfoSchema = `
+struct FILETIME {
+	uint32 LowDateTime;
+	uint32 HighDateTime;
+};
+
+struct RPC_UNICODE_STRING {
+	uint16 Length;
+	uint16 MaximumLength;
+	[pointer,conformant,varying] string Value;
+};
+
+struct RPC_SID {
+	uint8 Revision;
+	uint8 SubAuthorityCount;
+	uint8 IdentifierAuthority[6];
+	[conformant] uint32 SubAuthority[];
+};
+
+struct GROUP_MEMBERSHIP {
+	uint32 RelativeID;
+	uint32 Attributes;
+};
+
+struct KERB_SID_AND_ATTRIBUTES {
+	RPC_SID* SID;
+	uint32 Attributes;
+};
+
+struct CYPHER_BLOCK {
+	uint8 Data[8];
+};
+
+struct KERB_VALIDATION_INFO {
+	FILETIME LogOnTime;
+	FILETIME LogOffTime;
+	FILETIME KickOffTime;
+	FILETIME PasswordLastSet;
+	FILETIME PasswordCanChange;
+	FILETIME PasswordMustChange;
+	RPC_UNICODE_STRING EffectiveName;
+	RPC_UNICODE_STRING FullName;
+	RPC_UNICODE_STRING LogonScript;
+	RPC_UNICODE_STRING ProfilePath;
+	RPC_UNICODE_STRING HomeDirectory;
+	RPC_UNICODE_STRING HomeDirectoryDrive;
+	uint16 LogonCount;
+	uint16 BadPasswordCount;
+	uint32 UserID;
+	uint32 PrimaryGroupID;
+	uint32 GroupCount;
+	[pointer,conformant] GROUP_MEMBERSHIP GroupIDs[];
+	uint32 UserFlags;
+	CYPHER_BLOCK UserSessionKey[2];
+	RPC_UNICODE_STRING LogonServer;
+	RPC_UNICODE_STRING LogonDomainName;
+	RPC_SID* LogonDomainID;
+	uint32 Reserved1[2];
+	uint32 UserAccountControl;
+	uint32 SubAuthStatus;
+	FILETIME LastSuccessfulILogon;
+	FILETIME LastFailedILogon;
+	uint32 FailedILogonCount;
+	uint32 Reserved3;
+	uint32 SIDCount;
+	[pointer,conformant] KERB_SID_AND_ATTRIBUTES ExtraSIDs[];
+	RPC_SID* ResourceGroupDomainSID;
+	uint32 ResourceGroupCount;
+	[pointer,conformant] GROUP_MEMBERSHIP ResourceGroupIDs[];
+};
+`
+
+func TestKerbValidationInfo_DecodeTree(t *testing.T) {
+	s, err := ndr.ParseSchema(testKerbValidationInfoSchema)
+	if err != nil {
+		t.Fatalf("error parsing schema: %v", err)
+	}
+	typ, _ := s.Type("KERB_VALIDATION_INFO")
+	for i, test := range []string{testKerbValidationInfoMS, testKerbValidationInfoGoKRB5, testKerbValidationInfoTrust} {
+		b, _ := hex.DecodeString(test)
+		var k KerbValidationInfo
+		err := k.Unmarshal(b)
+		if err != nil {
+			t.Fatalf("error unmarshaling test %d: %v", i, err)
+		}
+		m, err := ndr.NewDecoder(bytes.NewReader(b)).DecodeTree(typ)
+		if err != nil {
+			t.Fatalf("error decoding tree for test %d: %v", i, err)
+		}
+		assert.Equal(t, k.EffectiveName.Value, m["EffectiveName"].(map[string]interface{})["Value"], "EffectiveName not as expected for test %d", i)
+		assert.Equal(t, k.LogonDomainName.Value, m["LogonDomainName"].(map[string]interface{})["Value"], "LogonDomainName not as expected for test %d", i)
+		assert.Equal(t, uint32(k.UserFlags), m["UserFlags"], "UserFlags not as expected for test %d", i)
+		assert.Equal(t, len(k.GroupIDs), len(m["GroupIDs"].([]interface{})), "number of GroupIDs not as expected for test %d", i)
+		assert.Equal(t, len(k.ExtraSIDs), len(m["ExtraSIDs"].([]interface{})), "number of ExtraSIDs not as expected for test %d", i)
+		sa := m["LogonDomainID"].(map[string]interface{})["SubAuthority"].([]interface{})
+		for j, a := range k.LogonDomainID.SubAuthority {
+			assert.Equal(t, a, sa[j], "LogonDomainID sub authority %d not as expected for test %d", j, i)
+		}
+		if len(k.ExtraSIDs) > 0 {
+			e := m["ExtraSIDs"].([]interface{})
+			last := e[len(e)-1].(map[string]interface{})
+			sid := last["SID"].(map[string]interface{})
+			assert.Equal(t, k.ExtraSIDs[len(e)-1].SID.SubAuthority[len(k.ExtraSIDs[len(e)-1].SID.SubAuthority)-1],
+				sid["SubAuthority"].([]interface{})[int(sid["SubAuthorityCount"].(uint8))-1], "last ExtraSID not as expected for test %d", i)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package pac
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/jcmturner/rpc/v2/mstypes"
+	"github.com/jcmturner/rpc/v2/ndr"
+)
+
+// PAC buffer types https://msdn.microsoft.com/en-us/library/cc237954.aspx
+const (
+	InfoTypeLogonInfo           uint32 = 1  // KERB_VALIDATION_INFO
+	InfoTypeCredentials         uint32 = 2  // PAC_CREDENTIAL_INFO
+	InfoTypeServerChecksum      uint32 = 6  // PAC_SIGNATURE_DATA
+	InfoTypeKDCChecksum         uint32 = 7  // PAC_SIGNATURE_DATA
+	InfoTypeClientInfo          uint32 = 10 // PAC_CLIENT_INFO
+	InfoTypeS4UDelegationInfo   uint32 = 11 // S4U_DELEGATION_INFO
+	InfoTypeUPNDNSInfo          uint32 = 12 // UPN_DNS_INFO
+	InfoTypeClientClaimsInfo    uint32 = 13 // PAC_CLIENT_CLAIMS_INFO
+	InfoTypeDeviceInfo          uint32 = 14 // PAC_DEVICE_INFO
+	InfoTypeDeviceClaimsInfo    uint32 = 15 // PAC_DEVICE_CLAIMS_INFO
+	InfoTypeTicketChecksum      uint32 = 16 // PAC_SIGNATURE_DATA
+	InfoTypeAttributesInfo      uint32 = 17 // PAC_ATTRIBUTES_INFO
+	InfoTypeRequestor           uint32 = 18 // PAC_REQUESTOR
+	InfoTypeExtendedKDCChecksum uint32 = 19 // PAC_SIGNATURE_DATA
+)
+
+const (
+	pacTypeHeaderBytes = 8
+	infoBufferBytes    = 16
+	pacBufferAlignment = 8
+	pacTypeVersion     = 0
+)
+
+// PACType implements https://msdn.microsoft.com/en-us/library/cc237950.aspx
+type PACType struct {
+	CBuffers uint32
+	Version  uint32
+	Buffers  []InfoBuffer
+	Data     []byte // The bytes of the PAC the buffers are located within
+}
+
+// InfoBuffer implements https://msdn.microsoft.com/en-us/library/cc237954.aspx
+type InfoBuffer struct {
+	ULType       uint32 // A PAC buffer type, see the InfoType constants
+	CBBufferSize uint32 // Size of the buffer in bytes
+	Offset       uint64 // Offset of the buffer from the start of the PAC, a multiple of 8
+}
+
+// Unmarshal bytes, from the AuthorizationData of an AD-WIN2K-PAC element, into the PACType struct.
+// The buffers are checked to be 8 byte aligned, within the bytes provided and not to overlap each other or the
+// buffer table.
+func (p *PACType) Unmarshal(b []byte) error {
+	if len(b) < pacTypeHeaderBytes {
+		return errors.New("error unmarshaling PAC: too few bytes for the PACTYPE header")
+	}
+	p.CBuffers = binary.LittleEndian.Uint32(b[0:4])
+	p.Version = binary.LittleEndian.Uint32(b[4:8])
+	if p.Version != pacTypeVersion {
+		return fmt.Errorf("error unmarshaling PAC: version %d not supported", p.Version)
+	}
+	end := uint64(pacTypeHeaderBytes) + uint64(p.CBuffers)*infoBufferBytes
+	if end > uint64(len(b)) {
+		return fmt.Errorf("error unmarshaling PAC: too few bytes for %d buffers", p.CBuffers)
+	}
+	p.Buffers = make([]InfoBuffer, p.CBuffers, p.CBuffers)
+	for i := range p.Buffers {
+		o := pacTypeHeaderBytes + i*infoBufferBytes
+		p.Buffers[i] = InfoBuffer{
+			ULType:       binary.LittleEndian.Uint32(b[o : o+4]),
+			CBBufferSize: binary.LittleEndian.Uint32(b[o+4 : o+8]),
+			Offset:       binary.LittleEndian.Uint64(b[o+8 : o+16]),
+		}
+	}
+	p.Data = b
+	err := p.validateBuffers(end)
+	if err != nil {
+		return fmt.Errorf("error unmarshaling PAC: %v", err)
+	}
+	return nil
+}
+
+// validateBuffers checks the buffers are aligned and lie within the PAC after the end of the buffer table without
+// overlapping.
+func (p *PACType) validateBuffers(end uint64) error {
+	bufs := make([]InfoBuffer, len(p.Buffers), len(p.Buffers))
+	copy(bufs, p.Buffers)
+	sort.Slice(bufs, func(i, j int) bool { return bufs[i].Offset < bufs[j].Offset })
+	for _, buf := range bufs {
+		if buf.Offset%pacBufferAlignment != 0 {
+			return fmt.Errorf("buffer of type %d at offset %d is not %d byte aligned", buf.ULType, buf.Offset, pacBufferAlignment)
+		}
+		if buf.Offset < end {
+			return fmt.Errorf("buffer of type %d at offset %d overlaps the preceding data", buf.ULType, buf.Offset)
+		}
+		if buf.Offset > uint64(len(p.Data)) || uint64(buf.CBBufferSize) > uint64(len(p.Data))-buf.Offset {
+			return fmt.Errorf("buffer of type %d at offset %d of %d bytes extends beyond the PAC", buf.ULType, buf.Offset, buf.CBBufferSize)
+		}
+		end = buf.Offset + uint64(buf.CBBufferSize)
+	}
+	return nil
+}
+
+// Buffer returns the bytes of the first buffer of the type provided and false if there is no buffer of the type.
+func (p *PACType) Buffer(t uint32) ([]byte, bool) {
+	for _, buf := range p.Buffers {
+		if buf.ULType == t {
+			return p.Data[buf.Offset : buf.Offset+uint64(buf.CBBufferSize)], true
+		}
+	}
+	return nil, false
+}
+
+// decodeBuffer NDR decodes the buffer of the type provided into the value provided.
+func (p *PACType) decodeBuffer(t uint32, name string, v interface{}) error {
+	b, ok := p.Buffer(t)
+	if !ok {
+		return fmt.Errorf("PAC does not contain a %s buffer", name)
+	}
+	err := ndr.Unmarshal(b, v)
+	if err != nil {
+		return fmt.Errorf("error unmarshaling %s: %v", name, err)
+	}
+	return nil
+}
+
+// LogonInfo returns the KerbValidationInfo of the PAC's logon information buffer.
+func (p *PACType) LogonInfo() (k KerbValidationInfo, err error) {
+	err = p.decodeBuffer(InfoTypeLogonInfo, "logon information", &k)
+	return
+}
+
+// ClientClaims returns the ClaimsSetMetadata of the PAC's client claims buffer.
+func (p *PACType) ClientClaims() (m mstypes.ClaimsSetMetadata, err error) {
+	err = p.decodeBuffer(InfoTypeClientClaimsInfo, "client claims", &m)
+	return
+}
+
+// DeviceClaims returns the ClaimsSetMetadata of the PAC's device claims buffer.
+func (p *PACType) DeviceClaims() (m mstypes.ClaimsSetMetadata, err error) {
+	err = p.decodeBuffer(InfoTypeDeviceClaimsInfo, "device claims", &m)
+	return
+}
@@ -0,0 +1,86 @@
+package pac
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testClientClaimsInfo = "01100800cccccccc000100000000000000000200d80000000400020000000000d8000000000000000000000000000000d800000001100800ccccccccc80000000000000000000200010000000400020000000000000000000000000001000000010000000100000008000200010000000c000200030003000100000010000200290000000000000029000000610064003a002f002f006500780074002f00730041004d004100630063006f0075006e0074004e0061006d0065003a0038003800640035006400390030003800350065006100350063003000630030000000000001000000140002000a000000000000000a00000074006500730074007500730065007200310000000000000000000000"
+
+func TestPACType_Unmarshal(t *testing.T) {
+	logon, _ := hex.DecodeString(testKerbValidationInfoGoKRB5)
+	claims, _ := hex.DecodeString(testClientClaimsInfo)
+	b := layoutPAC([]pacBuffer{
+		{InfoTypeLogonInfo, logon},
+		{InfoTypeClientClaimsInfo, claims},
+		{InfoTypeServerChecksum, []byte{0x76, 0xff, 0xff, 0xff, 1, 2, 3}},
+	})
+	var p PACType
+	err := p.Unmarshal(b)
+	if err != nil {
+		t.Fatalf("error unmarshaling PAC: %v", err)
+	}
+	assert.Equal(t, uint32(3), p.CBuffers, "number of buffers not as expected")
+	assert.Equal(t, uint32(0), p.Version, "version not as expected")
+	assert.Equal(t, uint64(56), p.Buffers[0].Offset, "offset of the first buffer not as expected")
+
+	k, err := p.LogonInfo()
+	if err != nil {
+		t.Fatalf("error getting logon info: %v", err)
+	}
+	assert.Equal(t, "testuser1", k.EffectiveName.String(), "EffectiveName not as expected")
+
+	m, err := p.ClientClaims()
+	if err != nil {
+		t.Fatalf("error getting client claims: %v", err)
+	}
+	c, err := m.Claims()
+	if err != nil {
+		t.Fatalf("error getting claims: %v", err)
+	}
+	v, ok := c.Strings("ad://ext/sAMAccountName:88d5d9085ea5c0c0")
+	assert.True(t, ok, "claim not found")
+	assert.Equal(t, []string{"testuser1"}, v, "claim value not as expected")
+
+	s, ok := p.Buffer(InfoTypeServerChecksum)
+	assert.True(t, ok, "server checksum buffer not found")
+	assert.Equal(t, []byte{0x76, 0xff, 0xff, 0xff, 1, 2, 3}, s, "server checksum buffer not as expected")
+	_, ok = p.Buffer(InfoTypeKDCChecksum)
+	assert.False(t, ok, "KDC checksum buffer should not be found")
+	_, err = p.DeviceClaims()
+	assert.Error(t, err, "missing device claims buffer should return an error")
+}
+
+func TestPACType_UnmarshalInvalid(t *testing.T) {
+	valid := func() []byte {
+		return layoutPAC([]pacBuffer{
+			{InfoTypeServerChecksum, make([]byte, 20)},
+			{InfoTypeKDCChecksum, make([]byte, 20)},
+		})
+	}
+	var tests = []struct {
+		Name   string
+		Modify func([]byte) []byte
+	}{
+		{"short header", func(b []byte) []byte { return b[:4] }},
+		{"version", func(b []byte) []byte { b[4] = 1; return b }},
+		{"buffer count", func(b []byte) []byte { b[0] = 0xff; return b }},
+		{"alignment", func(b []byte) []byte { b[16]++; return b }},
+		{"overlaps buffer table", func(b []byte) []byte { b[16] = 8; return b }},
+		{"overlaps buffer", func(b []byte) []byte { b[32] = 56; return b }},
+		{"beyond end", func(b []byte) []byte { return b[:len(b)-8] }},
+		{"size beyond end", func(b []byte) []byte { b[28] = 0xff; b[29] = 0xff; b[30] = 0xff; b[31] = 0xff; return b }},
+		{"offset beyond end", func(b []byte) []byte { b[39] = 0x10; return b }},
+	}
+	var p PACType
+	err := p.Unmarshal(valid())
+	if err != nil {
+		t.Fatalf("error unmarshaling valid PAC: %v", err)
+	}
+	for _, test := range tests {
+		err := p.Unmarshal(test.Modify(valid()))
+		assert.Error(t, err, "invalid PAC should return an error for test %s", test.Name)
+	}
+}
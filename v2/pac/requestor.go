@@ -0,0 +1,58 @@
+package pac
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jcmturner/rpc/v2/mstypes"
+)
+
+// Requestor implements https://msdn.microsoft.com/en-us/library/mt844290.aspx
+type Requestor struct {
+	SID mstypes.RPCSID // The SID of the account that requested the ticket
+}
+
+// Unmarshal bytes, the packed binary layout of a SID, into the Requestor struct.
+func (r *Requestor) Unmarshal(b []byte) (err error) {
+	r.SID, err = mstypes.SIDFromBytes(b)
+	if err != nil {
+		return fmt.Errorf("error unmarshaling Requestor: %v", err)
+	}
+	return nil
+}
+
+// Marshal returns the packed binary layout of the Requestor's SID.
+func (r *Requestor) Marshal() []byte {
+	return r.SID.Bytes()
+}
+
+// Requestor returns the Requestor of the PAC's requestor buffer.
+func (p *PACType) Requestor() (r Requestor, err error) {
+	b, ok := p.Buffer(InfoTypeRequestor)
+	if !ok {
+		err = errors.New("PAC does not contain a requestor buffer")
+		return
+	}
+	err = r.Unmarshal(b)
+	return
+}
+
+// ValidateRequestor checks the SID of the PAC's requestor buffer matches the user SID of the logon information.
+func (p *PACType) ValidateRequestor() error {
+	r, err := p.Requestor()
+	if err != nil {
+		return err
+	}
+	k, err := p.LogonInfo()
+	if err != nil {
+		return err
+	}
+	u, err := k.UserSID()
+	if err != nil {
+		return fmt.Errorf("could not form user SID: %v", err)
+	}
+	if !r.SID.Equal(u) {
+		return fmt.Errorf("requestor SID %s does not match the user SID %s", r.SID.String(), u.String())
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package pac
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/jcmturner/rpc/v2/mstypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPACType_ValidateRequestor(t *testing.T) {
+	logon, _ := hex.DecodeString(testKerbValidationInfoGoKRB5)
+	var tests = []struct {
+		SID   string
+		Valid bool
+	}{
+		{"S-1-5-21-3167651404-3865080224-2280184895-1105", true},
+		{"S-1-5-21-3167651404-3865080224-2280184895-1106", false},
+	}
+	for i, test := range tests {
+		s, _ := mstypes.ParseSID(test.SID)
+		var p PACType
+		err := p.Unmarshal(layoutPAC([]pacBuffer{{InfoTypeLogonInfo, logon}, {InfoTypeRequestor, s.Bytes()}}))
+		if err != nil {
+			t.Fatalf("error unmarshaling PAC for test %d: %v", i, err)
+		}
+		r, err := p.Requestor()
+		if err != nil {
+			t.Fatalf("error getting Requestor for test %d: %v", i, err)
+		}
+		assert.Equal(t, test.SID, r.SID.String(), "requestor SID not as expected for test %d", i)
+		err = p.ValidateRequestor()
+		if test.Valid {
+			assert.NoError(t, err, "requestor should be valid for test %d", i)
+		} else {
+			assert.Error(t, err, "requestor should not be valid for test %d", i)
+		}
+	}
+
+	var p PACType
+	err := p.Unmarshal(layoutPAC([]pacBuffer{{InfoTypeLogonInfo, logon}}))
+	if err != nil {
+		t.Fatalf("error unmarshaling PAC: %v", err)
+	}
+	assert.Error(t, p.ValidateRequestor(), "missing requestor buffer should return an error")
+	var r Requestor
+	assert.Error(t, r.Unmarshal([]byte{1, 5, 0}), "invalid SID bytes should return an error")
+}
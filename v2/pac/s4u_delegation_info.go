@@ -0,0 +1,39 @@
+package pac
+
+import (
+	"fmt"
+
+	"github.com/jcmturner/rpc/v2/mstypes"
+	"github.com/jcmturner/rpc/v2/ndr"
+)
+
+// S4UDelegationInfo implements https://msdn.microsoft.com/en-us/library/cc237944.aspx
+type S4UDelegationInfo struct {
+	S4U2proxyTarget      mstypes.RPCUnicodeString // The name of the principal to whom the application can forward the ticket.
+	TransitedListSize    uint32
+	S4UTransitedServices []mstypes.RPCUnicodeString `ndr:"pointer,conformant"` // List of all services that have been delegated through by this client and subsequent services or servers. Size is value of TransitedListSize
+}
+
+// Unmarshal bytes into the S4UDelegationInfo struct.
+func (k *S4UDelegationInfo) Unmarshal(b []byte) error {
+	err := ndr.Unmarshal(b, k)
+	if err != nil {
+		return fmt.Errorf("error unmarshaling S4UDelegationInfo: %v", err)
+	}
+	return nil
+}
+
+// TransitedServices returns the names of the services that have been delegated through.
+func (k *S4UDelegationInfo) TransitedServices() []string {
+	s := make([]string, len(k.S4UTransitedServices), len(k.S4UTransitedServices))
+	for i := range k.S4UTransitedServices {
+		s[i] = k.S4UTransitedServices[i].Value
+	}
+	return s
+}
+
+// S4UDelegationInfo returns the S4UDelegationInfo of the PAC's constrained delegation information buffer.
+func (p *PACType) S4UDelegationInfo() (k S4UDelegationInfo, err error) {
+	err = p.decodeBuffer(InfoTypeS4UDelegationInfo, "constrained delegation information", &k)
+	return
+}
@@ -0,0 +1,33 @@
+package pac
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testS4UDelegationInfo = "01100800ccccccccc000000000000000000002002800280004000200020000000800020015000000000000001500000068007400740070002f007700650062002e0074006500730074002e0067006f006b007200620035000000000002000000200020000c00020020002000100002001100000000000000110000007300760063003100400054004500530054002e0047004f004b00520042003500000000001100000000000000110000007300760063003200400054004500530054002e0047004f004b0052004200350000000000"
+
+func TestS4UDelegationInfo_Unmarshal(t *testing.T) {
+	b, _ := hex.DecodeString(testS4UDelegationInfo)
+	var k S4UDelegationInfo
+	err := k.Unmarshal(b)
+	if err != nil {
+		t.Fatalf("error unmarshaling S4UDelegationInfo: %v", err)
+	}
+	assert.Equal(t, "http/web.test.gokrb5", k.S4U2proxyTarget.String(), "S4U2proxyTarget not as expected")
+	assert.Equal(t, uint32(2), k.TransitedListSize, "TransitedListSize not as expected")
+	assert.Equal(t, []string{"svc1@TEST.GOKRB5", "svc2@TEST.GOKRB5"}, k.TransitedServices(), "transited services not as expected")
+
+	var p PACType
+	err = p.Unmarshal(layoutPAC([]pacBuffer{{InfoTypeS4UDelegationInfo, b}}))
+	if err != nil {
+		t.Fatalf("error unmarshaling PAC: %v", err)
+	}
+	k, err = p.S4UDelegationInfo()
+	if err != nil {
+		t.Fatalf("error getting S4UDelegationInfo from PAC: %v", err)
+	}
+	assert.Equal(t, "http/web.test.gokrb5", k.S4U2proxyTarget.String(), "S4U2proxyTarget from PAC not as expected")
+}
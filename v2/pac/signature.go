@@ -0,0 +1,318 @@
+package pac
+
+import (
+	"crypto/aes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Signature types of PAC_SIGNATURE_DATA https://msdn.microsoft.com/en-us/library/cc237955.aspx
+const (
+	SignatureTypeHMACSHA196AES128 uint32 = 15         // HMAC_SHA1_96_AES128 specified in RFC 3962
+	SignatureTypeHMACSHA196AES256 uint32 = 16         // HMAC_SHA1_96_AES256 specified in RFC 3962
+	SignatureTypeHMACMD5          uint32 = 0xFFFFFF76 // KERB_CHECKSUM_HMAC_MD5 (-138) specified in RFC 4757
+)
+
+const (
+	// signatureKeyUsage is the key usage number KERB_NON_KERB_CKSUM_SALT used for PAC signatures.
+	signatureKeyUsage  = 17
+	aesSignatureLength = 12
+	md5SignatureLength = md5.Size
+)
+
+// SignatureData implements https://msdn.microsoft.com/en-us/library/cc237955.aspx
+type SignatureData struct {
+	SignatureType  uint32 // A signature type, see the SignatureType constants
+	Signature      []byte
+	RODCIdentifier uint16 // Only present when the signing KDC is a read-only domain controller
+}
+
+// Unmarshal bytes into the SignatureData struct.
+func (s *SignatureData) Unmarshal(b []byte) error {
+	if len(b) < 4 {
+		return errors.New("error unmarshaling SignatureData: too few bytes for the signature type")
+	}
+	s.SignatureType = binary.LittleEndian.Uint32(b[0:4])
+	l, err := signatureLength(s.SignatureType)
+	if err != nil {
+		return fmt.Errorf("error unmarshaling SignatureData: %v", err)
+	}
+	if len(b) < 4+l {
+		return fmt.Errorf("error unmarshaling SignatureData: too few bytes for a signature of %d bytes", l)
+	}
+	s.Signature = b[4 : 4+l]
+	if len(b) >= 4+l+2 {
+		s.RODCIdentifier = binary.LittleEndian.Uint16(b[4+l : 4+l+2])
+	}
+	return nil
+}
+
+// Marshal returns the bytes of the SignatureData. The RODCIdentifier is only included if it is not zero.
+func (s *SignatureData) Marshal() []byte {
+	b := make([]byte, 4, 4+len(s.Signature)+2)
+	binary.LittleEndian.PutUint32(b, s.SignatureType)
+	b = append(b, s.Signature...)
+	if s.RODCIdentifier != 0 {
+		b = append(b, byte(s.RODCIdentifier), byte(s.RODCIdentifier>>8))
+	}
+	return b
+}
+
+func signatureLength(t uint32) (int, error) {
+	switch t {
+	case SignatureTypeHMACSHA196AES128, SignatureTypeHMACSHA196AES256:
+		return aesSignatureLength, nil
+	case SignatureTypeHMACMD5:
+		return md5SignatureLength, nil
+	}
+	return 0, fmt.Errorf("signature type %d not supported", int32(t))
+}
+
+// Checksum returns the checksum of the data with the key provided using the signature type's algorithm and the key
+// usage of PAC signatures.
+// For HMAC_SHA1_96_AES128 and HMAC_SHA1_96_AES256 the key is the AES key and for KERB_CHECKSUM_HMAC_MD5 the RC4 key.
+func Checksum(signatureType uint32, key, data []byte) ([]byte, error) {
+	switch signatureType {
+	case SignatureTypeHMACSHA196AES128, SignatureTypeHMACSHA196AES256:
+		keyLength := 16
+		if signatureType == SignatureTypeHMACSHA196AES256 {
+			keyLength = 32
+		}
+		if len(key) != keyLength {
+			return nil, fmt.Errorf("key of %d bytes not valid for signature type %d", len(key), signatureType)
+		}
+		// The checksum key is derived using the usage number followed by 0x99, as specified in RFC 3961 section 5.3
+		constant := make([]byte, 5)
+		binary.BigEndian.PutUint32(constant, signatureKeyUsage)
+		constant[4] = 0x99
+		kc, err := deriveKey(key, constant)
+		if err != nil {
+			return nil, err
+		}
+		mac := hmac.New(sha1.New, kc)
+		mac.Write(data)
+		return mac.Sum(nil)[:aesSignatureLength], nil
+	case SignatureTypeHMACMD5:
+		// As specified in RFC 4757 section 4
+		mac := hmac.New(md5.New, key)
+		mac.Write([]byte("signaturekey\x00"))
+		ksign := mac.Sum(nil)
+		var usage [4]byte
+		binary.LittleEndian.PutUint32(usage[:], signatureKeyUsage)
+		h := md5.New()
+		h.Write(usage[:])
+		h.Write(data)
+		mac = hmac.New(md5.New, ksign)
+		mac.Write(h.Sum(nil))
+		return mac.Sum(nil), nil
+	}
+	return nil, fmt.Errorf("signature type %d not supported", int32(signatureType))
+}
+
+// Verify checks the signature is the checksum of the data with the key provided.
+func (s *SignatureData) Verify(key, data []byte) error {
+	c, err := Checksum(s.SignatureType, key, data)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(c, s.Signature) {
+		return errors.New("signature not valid")
+	}
+	return nil
+}
+
+// deriveKey implements the DK function for AES keys as specified in RFC 3961 section 5.1 and RFC 3962.
+// The constant is n-folded to the block size and encrypted repeatedly until there are enough bytes for a key.
+func deriveKey(key, constant []byte) ([]byte, error) {
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("could not derive key: %v", err)
+	}
+	block := nFold(constant, aes.BlockSize*8)
+	k := make([]byte, 0, len(key)+aes.BlockSize)
+	for len(k) < len(key) {
+		c.Encrypt(block, block)
+		k = append(k, block...)
+	}
+	return k[:len(key)], nil
+}
+
+// nFold implements the n-fold operation specified in RFC 3961 section 5.1, returning n bits.
+// n must be a multiple of 8.
+func nFold(in []byte, n int) []byte {
+	inBits := len(in) * 8
+	outBytes := n / 8
+	l := lcm(inBits, n)
+	// The input is repeated, each repetition rotated right by 13 bits more than the last, to lcm bits which are
+	// then added together in n bit blocks with ones' complement addition.
+	sum := make([]int, outBytes)
+	for i := 0; i < l/8; i++ {
+		rot := 13 * (i * 8 / inBits)
+		bit := i*8%inBits - rot
+		sum[i%outBytes] += int(rotatedByte(in, bit))
+	}
+	out := make([]byte, outBytes)
+	carry := 0
+	// Ones' complement addition is done by carrying from the least significant byte, with any final carry added
+	// back in to the least significant byte.
+	for {
+		for i := outBytes - 1; i >= 0; i-- {
+			v := sum[i] + carry
+			out[i] = byte(v)
+			carry = v >> 8
+			sum[i] = int(out[i])
+		}
+		if carry == 0 {
+			break
+		}
+	}
+	return out
+}
+
+// rotatedByte returns the byte starting at the bit position of the input, wrapping around the input.
+func rotatedByte(in []byte, bit int) byte {
+	inBits := len(in) * 8
+	bit = ((bit % inBits) + inBits) % inBits
+	var b byte
+	for j := 0; j < 8; j++ {
+		p := (bit + j) % inBits
+		b = b<<1 | (in[p/8]>>(7-uint(p%8)))&1
+	}
+	return b
+}
+
+func lcm(a, b int) int {
+	x, y := a, b
+	for y != 0 {
+		x, y = y, x%y
+	}
+	return a / x * b
+}
+
+// ServerChecksum returns the SignatureData of the PAC's server checksum buffer.
+func (p *PACType) ServerChecksum() (SignatureData, error) {
+	return p.signatureData(InfoTypeServerChecksum, "server checksum")
+}
+
+// KDCChecksum returns the SignatureData of the PAC's KDC checksum buffer.
+func (p *PACType) KDCChecksum() (SignatureData, error) {
+	return p.signatureData(InfoTypeKDCChecksum, "KDC checksum")
+}
+
+// TicketChecksum returns the SignatureData of the PAC's ticket checksum buffer.
+func (p *PACType) TicketChecksum() (SignatureData, error) {
+	return p.signatureData(InfoTypeTicketChecksum, "ticket checksum")
+}
+
+// ExtendedKDCChecksum returns the SignatureData of the PAC's extended KDC, or full PAC, checksum buffer.
+func (p *PACType) ExtendedKDCChecksum() (SignatureData, error) {
+	return p.signatureData(InfoTypeExtendedKDCChecksum, "extended KDC checksum")
+}
+
+func (p *PACType) signatureData(t uint32, name string) (s SignatureData, err error) {
+	b, ok := p.Buffer(t)
+	if !ok {
+		err = fmt.Errorf("PAC does not contain a %s buffer", name)
+		return
+	}
+	err = s.Unmarshal(b)
+	return
+}
+
+// zeroedSignatures returns a copy of the PAC with the Signature fields of the signature buffers of the types provided
+// set to zero. The signatures of any other signature buffers, such as the ticket checksum, are left in place.
+func (p *PACType) zeroedSignatures(types ...uint32) ([]byte, error) {
+	b := make([]byte, len(p.Data), len(p.Data))
+	copy(b, p.Data)
+	for _, buf := range p.Buffers {
+		for _, t := range types {
+			if buf.ULType != t {
+				continue
+			}
+			var s SignatureData
+			err := s.Unmarshal(p.Data[buf.Offset : buf.Offset+uint64(buf.CBBufferSize)])
+			if err != nil {
+				return nil, err
+			}
+			o := buf.Offset + 4
+			for i := range s.Signature {
+				b[o+uint64(i)] = 0
+			}
+		}
+	}
+	return b, nil
+}
+
+// VerifyServerChecksum verifies the server checksum of the PAC with the key of the service the ticket was issued
+// for, as specified in MS-PAC section 2.8.1. The server checksum is computed over the PAC with only the server and KDC
+// signatures zero, so it covers the ticket and extended KDC signatures, which the KDC computes first.
+func (p *PACType) VerifyServerChecksum(key []byte) error {
+	s, err := p.ServerChecksum()
+	if err != nil {
+		return err
+	}
+	b, err := p.zeroedSignatures(InfoTypeServerChecksum, InfoTypeKDCChecksum)
+	if err != nil {
+		return fmt.Errorf("could not verify server checksum: %v", err)
+	}
+	err = s.Verify(key, b)
+	if err != nil {
+		return fmt.Errorf("server checksum: %v", err)
+	}
+	return nil
+}
+
+// VerifyKDCChecksum verifies the KDC checksum of the PAC, computed over the server checksum's signature, with the
+// key of the KDC, as specified in MS-PAC section 2.8.2.
+func (p *PACType) VerifyKDCChecksum(key []byte) error {
+	server, err := p.ServerChecksum()
+	if err != nil {
+		return err
+	}
+	s, err := p.KDCChecksum()
+	if err != nil {
+		return err
+	}
+	err = s.Verify(key, server.Signature)
+	if err != nil {
+		return fmt.Errorf("KDC checksum: %v", err)
+	}
+	return nil
+}
+
+// VerifyTicketChecksum verifies the ticket checksum of the PAC with the key of the KDC.
+// The data is the encoding of the ticket's EncTicketPart prepared as specified in MS-PAC section 2.8.3.
+func (p *PACType) VerifyTicketChecksum(key, data []byte) error {
+	s, err := p.TicketChecksum()
+	if err != nil {
+		return err
+	}
+	err = s.Verify(key, data)
+	if err != nil {
+		return fmt.Errorf("ticket checksum: %v", err)
+	}
+	return nil
+}
+
+// VerifyExtendedKDCChecksum verifies the extended KDC checksum of the PAC with the key of the KDC, as specified in
+// MS-PAC section 2.8.5. Like the server checksum it is computed over the PAC with the server and KDC signatures zero,
+// and as it is computed before it is written into the PAC, with its own signature zero too.
+func (p *PACType) VerifyExtendedKDCChecksum(key []byte) error {
+	s, err := p.ExtendedKDCChecksum()
+	if err != nil {
+		return err
+	}
+	b, err := p.zeroedSignatures(InfoTypeServerChecksum, InfoTypeKDCChecksum, InfoTypeExtendedKDCChecksum)
+	if err != nil {
+		return fmt.Errorf("could not verify extended KDC checksum: %v", err)
+	}
+	err = s.Verify(key, b)
+	if err != nil {
+		return fmt.Errorf("extended KDC checksum: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package pac
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNFold(t *testing.T) {
+	// Test vectors from RFC 3961 appendix A.1
+	var tests = []struct {
+		In  string
+		N   int
+		Out string
+	}{
+		{"012345", 64, "be072631276b1955"},
+		{"password", 56, "78a07b6caf85fa"},
+		{"Rough Consensus, and Running Code", 64, "bb6ed30870b7f0e0"},
+		{"password", 168, "59e4a8ca7c0385c3c37b3f6d2000247cb6e6bd5b3e"},
+		{"MASSACHVSETTS INSTITVTE OF TECHNOLOGY", 192, "db3b0d8f0b061e603282b308a50841229ad798fab9540c1b"},
+		{"Q", 168, "518a54a215a8452a518a54a215a8452a518a54a215"},
+		{"ba", 168, "fb25d531ae8974499f52fd92ea9857c4ba24cf297e"},
+		{"kerberos", 64, "6b65726265726f73"},
+		{"kerberos", 128, "6b65726265726f737b9b5b2b93132b93"},
+		{"kerberos", 168, "8372c236344e5f1550cd0747e15d62ca7a5a3bcea4"},
+		{"kerberos", 256, "6b65726265726f737b9b5b2b93132b935c9bdcdad95c9899c4cae4dee6d6cae4"},
+	}
+	for i, test := range tests {
+		assert.Equal(t, test.Out, hex.EncodeToString(nFold([]byte(test.In), test.N)), "n-fold not as expected for test %d", i)
+	}
+}
+
+func TestDeriveKey(t *testing.T) {
+	// Test vectors from RFC 3962 appendix B, the key derived with the constant "kerberos" from the output of PBKDF2 for
+	// the password "password", salt "ATHENA.MIT.EDUraeburn" and an iteration count of 1.
+	var tests = []struct {
+		Key     string
+		Derived string
+	}{
+		{"cdedb5281bb2f801565a1122b2563515", "42263c6e89f4fc28b8df68ee09799f15"},
+		{"cdedb5281bb2f801565a1122b25635150ad1f7a04bb9f3a333ecc0e2e1f70837", "fe697b52bc0d3ce14432ba036a92e65bbb52280990a2fa27883998d72af30161"},
+	}
+	for i, test := range tests {
+		key, _ := hex.DecodeString(test.Key)
+		k, err := deriveKey(key, []byte("kerberos"))
+		if err != nil {
+			t.Fatalf("error deriving key for test %d: %v", i, err)
+		}
+		assert.Equal(t, test.Derived, hex.EncodeToString(k), "derived key not as expected for test %d", i)
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	// RFC 3962 and RFC 4757 do not publish keyed checksum test vectors. These were computed with a separate
+	// implementation of RFC 3962 section 6 and RFC 4757 section 4 built on OpenSSL's AES and Python's HMAC, whose key
+	// derivation reproduces the RFC 3962 appendix B vectors above.
+	var tests = []struct {
+		SignatureType uint32
+		Key           string
+		Checksum      string
+	}{
+		{SignatureTypeHMACSHA196AES128, "000102030405060708090a0b0c0d0e0f", "3ad6d96fb9e046e0dbd6c922"},
+		{SignatureTypeHMACSHA196AES256, "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f", "d5082976e6ce6d6c5226f452"},
+		{SignatureTypeHMACMD5, "000102030405060708090a0b0c0d0e0f", "698405ac85b3a9cf7d44be8884210606"},
+	}
+	for i, test := range tests {
+		key, _ := hex.DecodeString(test.Key)
+		c, err := Checksum(test.SignatureType, key, []byte("the quick brown fox"))
+		if err != nil {
+			t.Fatalf("error computing checksum for test %d: %v", i, err)
+		}
+		assert.Equal(t, test.Checksum, hex.EncodeToString(c), "checksum not as expected for test %d", i)
+	}
+	_, err := Checksum(SignatureTypeHMACSHA196AES256, make([]byte, 16), nil)
+	assert.Error(t, err, "a key of the wrong length should return an error")
+	_, err = Checksum(1, make([]byte, 16), nil)
+	assert.Error(t, err, "an unsupported signature type should return an error")
+}
+
+func TestSignatureData_Unmarshal(t *testing.T) {
+	b, _ := hex.DecodeString("10000000000102030405060708090a0b0100")
+	var s SignatureData
+	err := s.Unmarshal(b)
+	if err != nil {
+		t.Fatalf("error unmarshaling SignatureData: %v", err)
+	}
+	assert.Equal(t, SignatureTypeHMACSHA196AES256, s.SignatureType, "signature type not as expected")
+	assert.Equal(t, "000102030405060708090a0b", hex.EncodeToString(s.Signature), "signature not as expected")
+	assert.Equal(t, uint16(1), s.RODCIdentifier, "RODC identifier not as expected")
+	assert.Equal(t, b, s.Marshal(), "marshaled bytes not as expected")
+
+	err = s.Unmarshal(b[:10])
+	assert.Error(t, err, "too few bytes should return an error")
+	err = s.Unmarshal([]byte{1, 0, 0, 0})
+	assert.Error(t, err, "an unsupported signature type should return an error")
+}
+
+// testSignedPAC returns a PAC containing the logon info and signature buffers signed with the keys provided, in the
+// order a KDC signs them: the ticket checksum, the extended KDC checksum over the PAC with the server and KDC signatures
+// zero, the server checksum over the PAC with the extended KDC signature in place, then the KDC checksum over the
+// server signature.
+func testSignedPAC(t *testing.T, sigType uint32, serverKey, kdcKey []byte) []byte {
+	logon, _ := hex.DecodeString(testKerbValidationInfoGoKRB5)
+	l, _ := signatureLength(sigType)
+	sig := make([]byte, 4+l)
+	sig[0], sig[1], sig[2], sig[3] = byte(sigType), byte(sigType>>8), byte(sigType>>16), byte(sigType>>24)
+	b := layoutPAC([]pacBuffer{
+		{InfoTypeLogonInfo, logon},
+		{InfoTypeServerChecksum, sig},
+		{InfoTypeKDCChecksum, sig},
+		{InfoTypeTicketChecksum, sig},
+		{InfoTypeExtendedKDCChecksum, sig},
+	})
+	var p PACType
+	err := p.Unmarshal(b)
+	if err != nil {
+		t.Fatalf("error unmarshaling PAC: %v", err)
+	}
+	offsets := make(map[uint32]uint64)
+	for _, buf := range p.Buffers {
+		offsets[buf.ULType] = buf.Offset + 4
+	}
+	ticket, _ := Checksum(sigType, kdcKey, []byte(testTicketData))
+	copy(b[offsets[InfoTypeTicketChecksum]:], ticket)
+	full, _ := Checksum(sigType, kdcKey, b)
+	copy(b[offsets[InfoTypeExtendedKDCChecksum]:], full)
+	server, _ := Checksum(sigType, serverKey, b)
+	copy(b[offsets[InfoTypeServerChecksum]:], server)
+	kdc, _ := Checksum(sigType, kdcKey, server)
+	copy(b[offsets[InfoTypeKDCChecksum]:], kdc)
+	return b
+}
+
+// testTicketData stands in for the encoding of the EncTicketPart the ticket checksum is computed over.
+const testTicketData = "EncTicketPart"
+
+func TestPACType_VerifyChecksums(t *testing.T) {
+	var tests = []struct {
+		SignatureType uint32
+		KeyLength     int
+	}{
+		{SignatureTypeHMACSHA196AES128, 16},
+		{SignatureTypeHMACSHA196AES256, 32},
+		{SignatureTypeHMACMD5, 16},
+	}
+	for i, test := range tests {
+		serverKey := make([]byte, test.KeyLength)
+		kdcKey := make([]byte, test.KeyLength)
+		for j := range serverKey {
+			serverKey[j] = byte(j)
+			kdcKey[j] = byte(0xff - j)
+		}
+		b := testSignedPAC(t, test.SignatureType, serverKey, kdcKey)
+		var p PACType
+		err := p.Unmarshal(b)
+		if err != nil {
+			t.Fatalf("error unmarshaling PAC for test %d: %v", i, err)
+		}
+		assert.NoError(t, p.VerifyServerChecksum(serverKey), "server checksum should verify for test %d", i)
+		assert.NoError(t, p.VerifyKDCChecksum(kdcKey), "KDC checksum should verify for test %d", i)
+		assert.NoError(t, p.VerifyExtendedKDCChecksum(kdcKey), "extended KDC checksum should verify for test %d", i)
+		assert.Error(t, p.VerifyServerChecksum(kdcKey), "server checksum should not verify with the wrong key for test %d", i)
+		assert.Error(t, p.VerifyKDCChecksum(serverKey), "KDC checksum should not verify with the wrong key for test %d", i)
+		assert.NoError(t, p.VerifyTicketChecksum(kdcKey, []byte(testTicketData)), "ticket checksum should verify for test %d", i)
+
+		// Modify the extended KDC signature, which the server checksum covers
+		full := p.Buffers[4].Offset + 4
+		b[full]++
+		assert.Error(t, p.VerifyServerChecksum(serverKey), "server checksum should not verify a modified extended KDC signature for test %d", i)
+		assert.Error(t, p.VerifyExtendedKDCChecksum(kdcKey), "extended KDC checksum should not verify when modified for test %d", i)
+		b[full]--
+
+		// Modify the ticket signature, which the server and extended KDC checksums cover
+		ticket := p.Buffers[3].Offset + 4
+		b[ticket]++
+		assert.Error(t, p.VerifyServerChecksum(serverKey), "server checksum should not verify a modified ticket signature for test %d", i)
+		assert.Error(t, p.VerifyExtendedKDCChecksum(kdcKey), "extended KDC checksum should not verify a modified ticket signature for test %d", i)
+		b[ticket]--
+		assert.NoError(t, p.VerifyServerChecksum(serverKey), "server checksum should verify once restored for test %d", i)
+
+		// Modify the logon info
+		b[p.Buffers[0].Offset+100]++
+		assert.Error(t, p.VerifyServerChecksum(serverKey), "server checksum should not verify a modified PAC for test %d", i)
+		assert.Error(t, p.VerifyExtendedKDCChecksum(kdcKey), "extended KDC checksum should not verify a modified PAC for test %d", i)
+		assert.NoError(t, p.VerifyKDCChecksum(kdcKey), "KDC checksum should still verify the server checksum for test %d", i)
+	}
+}
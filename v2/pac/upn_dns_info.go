@@ -0,0 +1,150 @@
+package pac
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"unicode/utf16"
+
+	"github.com/jcmturner/rpc/v2/mstypes"
+)
+
+// Flags of UPNDNSInfo
+const (
+	UPNNoUPNAttr    uint32 = 0x00000001 // The user has no UPN attribute and the UPN is constructed from the account name.
+	UPNExtendedInfo uint32 = 0x00000002 // The SamName and SID fields are present.
+)
+
+const (
+	upnDNSInfoBytes         = 12
+	upnDNSInfoExtendedBytes = 20
+)
+
+// UPNDNSInfo implements https://msdn.microsoft.com/en-us/library/dd240468.aspx
+type UPNDNSInfo struct {
+	UPNLength           uint16 // Length of the UPN in bytes
+	UPNOffset           uint16 // Offset of the UPN from the start of the buffer
+	DNSDomainNameLength uint16
+	DNSDomainNameOffset uint16
+	Flags               uint32
+	SamNameLength       uint16 // Only present when the UPNExtendedInfo flag is set
+	SamNameOffset       uint16
+	SIDLength           uint16
+	SIDOffset           uint16
+	UPN                 string
+	DNSDomain           string
+	SamName             string
+	SID                 mstypes.RPCSID
+}
+
+// Unmarshal bytes into the UPNDNSInfo struct.
+// The offsets and lengths of the fields are checked to be within the bytes provided.
+func (k *UPNDNSInfo) Unmarshal(b []byte) (err error) {
+	if len(b) < upnDNSInfoBytes {
+		return errors.New("error unmarshaling UPNDNSInfo: too few bytes")
+	}
+	k.UPNLength = binary.LittleEndian.Uint16(b[0:2])
+	k.UPNOffset = binary.LittleEndian.Uint16(b[2:4])
+	k.DNSDomainNameLength = binary.LittleEndian.Uint16(b[4:6])
+	k.DNSDomainNameOffset = binary.LittleEndian.Uint16(b[6:8])
+	k.Flags = binary.LittleEndian.Uint32(b[8:12])
+	k.UPN, err = utf16Field(b, k.UPNOffset, k.UPNLength)
+	if err != nil {
+		return fmt.Errorf("error unmarshaling UPNDNSInfo UPN: %v", err)
+	}
+	k.DNSDomain, err = utf16Field(b, k.DNSDomainNameOffset, k.DNSDomainNameLength)
+	if err != nil {
+		return fmt.Errorf("error unmarshaling UPNDNSInfo DNS domain name: %v", err)
+	}
+	if k.Flags&UPNExtendedInfo == 0 {
+		return nil
+	}
+	if len(b) < upnDNSInfoExtendedBytes {
+		return errors.New("error unmarshaling UPNDNSInfo: too few bytes for the SamName and SID fields")
+	}
+	k.SamNameLength = binary.LittleEndian.Uint16(b[12:14])
+	k.SamNameOffset = binary.LittleEndian.Uint16(b[14:16])
+	k.SIDLength = binary.LittleEndian.Uint16(b[16:18])
+	k.SIDOffset = binary.LittleEndian.Uint16(b[18:20])
+	k.SamName, err = utf16Field(b, k.SamNameOffset, k.SamNameLength)
+	if err != nil {
+		return fmt.Errorf("error unmarshaling UPNDNSInfo SamName: %v", err)
+	}
+	s, err := field(b, k.SIDOffset, k.SIDLength)
+	if err != nil {
+		return fmt.Errorf("error unmarshaling UPNDNSInfo SID: %v", err)
+	}
+	k.SID, err = mstypes.SIDFromBytes(s)
+	if err != nil {
+		return fmt.Errorf("error unmarshaling UPNDNSInfo SID: %v", err)
+	}
+	return nil
+}
+
+// Marshal returns the bytes of the UPNDNSInfo. The SamName and SID are included if the UPNExtendedInfo flag is set.
+// The lengths and offsets are those of the values, which are each placed on an 8 byte boundary as Windows does.
+func (k *UPNDNSInfo) Marshal() []byte {
+	fields := [][]byte{
+		appendUTF16(nil, utf16.Encode([]rune(k.UPN))),
+		appendUTF16(nil, utf16.Encode([]rune(k.DNSDomain))),
+	}
+	h := upnDNSInfoBytes
+	if k.Flags&UPNExtendedInfo != 0 {
+		fields = append(fields, appendUTF16(nil, utf16.Encode([]rune(k.SamName))), k.SID.Bytes())
+		h = upnDNSInfoExtendedBytes
+	}
+	b := make([]byte, h)
+	binary.LittleEndian.PutUint32(b[8:12], k.Flags)
+	for i, f := range fields {
+		b = padTo8(b)
+		// The length and offset pairs are before and after the flags.
+		p := 4 * i
+		if i > 1 {
+			p += 4
+		}
+		binary.LittleEndian.PutUint16(b[p:p+2], uint16(len(f)))
+		binary.LittleEndian.PutUint16(b[p+2:p+4], uint16(len(b)))
+		b = append(b, f...)
+	}
+	return padTo8(b)
+}
+
+// padTo8 appends zero bytes to make the length a multiple of 8.
+func padTo8(b []byte) []byte {
+	for len(b)%8 != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+// field returns the bytes at the offset and of the length provided.
+func field(b []byte, offset, length uint16) ([]byte, error) {
+	if int(offset)+int(length) > len(b) {
+		return nil, fmt.Errorf("%d bytes at offset %d extend beyond the buffer of %d bytes", length, offset, len(b))
+	}
+	return b[int(offset) : int(offset)+int(length)], nil
+}
+
+// utf16Field returns the UTF-16 encoded string at the offset and of the length, in bytes, provided.
+func utf16Field(b []byte, offset, length uint16) (string, error) {
+	if length%2 != 0 {
+		return "", fmt.Errorf("length %d not valid for a UTF-16 string", length)
+	}
+	s, err := field(b, offset, length)
+	if err != nil {
+		return "", err
+	}
+	return mstypes.NewReader(bytes.NewReader(s)).UTF16String(len(s))
+}
+
+// UPNDNSInfo returns the UPNDNSInfo of the PAC's UPN and DNS information buffer.
+func (p *PACType) UPNDNSInfo() (k UPNDNSInfo, err error) {
+	b, ok := p.Buffer(InfoTypeUPNDNSInfo)
+	if !ok {
+		err = errors.New("PAC does not contain a UPN and DNS information buffer")
+		return
+	}
+	err = k.Unmarshal(b)
+	return
+}
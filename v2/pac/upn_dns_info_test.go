@@ -0,0 +1,107 @@
+package pac
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testUPNDNSInfo         = "2a001000160040000000000000000000740065007300740075007300650072003100400074006500730074002e0067006f006b0072006200350000000000000054004500530054002e0047004f004b005200420035000000"
+	testUPNDNSInfoExtended = "2a0018001600480002000000120060001c00780000000000740065007300740075007300650072003100400074006500730074002e0067006f006b0072006200350000000000000054004500530054002e0047004f004b0052004200350000007400650073007400750073006500720031000000000000000105000000000005150000004c86cebca07160e63fdce8875104000000000000"
+)
+
+func TestUPNDNSInfo_Unmarshal(t *testing.T) {
+	var tests = []struct {
+		Hex     string
+		Flags   uint32
+		SamName string
+		SID     string
+	}{
+		{testUPNDNSInfo, 0, "", "S-0-0"},
+		{testUPNDNSInfoExtended, UPNExtendedInfo, "testuser1", "S-1-5-21-3167651404-3865080224-2280184895-1105"},
+	}
+	for i, test := range tests {
+		b, _ := hex.DecodeString(test.Hex)
+		var k UPNDNSInfo
+		err := k.Unmarshal(b)
+		if err != nil {
+			t.Fatalf("error unmarshaling UPNDNSInfo for test %d: %v", i, err)
+		}
+		assert.Equal(t, "testuser1@test.gokrb5", k.UPN, "UPN not as expected for test %d", i)
+		assert.Equal(t, "TEST.GOKRB5", k.DNSDomain, "DNS domain not as expected for test %d", i)
+		assert.Equal(t, test.Flags, k.Flags, "flags not as expected for test %d", i)
+		assert.Equal(t, test.SamName, k.SamName, "SamName not as expected for test %d", i)
+		if test.SamName != "" {
+			assert.Equal(t, test.SID, k.SID.String(), "SID not as expected for test %d", i)
+		}
+		assert.Equal(t, test.Hex, hex.EncodeToString(k.Marshal()), "marshaled bytes not as expected for test %d", i)
+	}
+}
+
+func TestUPNDNSInfo_UnmarshalInvalid(t *testing.T) {
+	var tests = []struct {
+		Name   string
+		Modify func([]byte) []byte
+	}{
+		{"short", func(b []byte) []byte { return b[:10] }},
+		{"short extended", func(b []byte) []byte { return b[:16] }},
+		{"UPN beyond end", func(b []byte) []byte { b[2] = 0xf0; return b }},
+		{"odd UPN length", func(b []byte) []byte { b[0]++; return b }},
+		{"DNS domain beyond end", func(b []byte) []byte { b[4] = 0xf0; return b }},
+		{"SamName beyond end", func(b []byte) []byte { b[14] = 0xf0; return b }},
+		{"SID beyond end", func(b []byte) []byte { b[16] = 0xf0; return b }},
+		{"SID length", func(b []byte) []byte { b[16] -= 4; return b }},
+		{"UPN beyond end of large buffer", func(b []byte) []byte { return testUPNDNSInfoLarge(b, 0x1000f) }},
+	}
+	for _, test := range tests {
+		b, _ := hex.DecodeString(testUPNDNSInfoExtended)
+		var k UPNDNSInfo
+		err := k.Unmarshal(test.Modify(b))
+		assert.Error(t, err, "invalid UPNDNSInfo should return an error for test %s", test.Name)
+	}
+}
+
+// testUPNDNSInfoLarge returns the UPNDNSInfo extended to the length provided with a UPN of 16 characters at offset
+// 0xfff0, which ends beyond the range of the 16 bit offset.
+func testUPNDNSInfoLarge(b []byte, length int) []byte {
+	l := make([]byte, length)
+	copy(l, b)
+	l[0], l[1], l[2], l[3] = 0x20, 0x00, 0xf0, 0xff
+	for i := 0xfff0; i < 0x10010 && i < length; i += 2 {
+		l[i] = 'a'
+	}
+	return l
+}
+
+func TestUPNDNSInfo_UnmarshalLargeBuffer(t *testing.T) {
+	b, _ := hex.DecodeString(testUPNDNSInfoExtended)
+	var k UPNDNSInfo
+	err := k.Unmarshal(testUPNDNSInfoLarge(b, 0x10100))
+	if err != nil {
+		t.Fatalf("error unmarshaling UPNDNSInfo: %v", err)
+	}
+	assert.Equal(t, strings.Repeat("a", 16), k.UPN, "UPN not as expected")
+}
+
+func TestPACType_ClientInfoUPNDNSInfo(t *testing.T) {
+	c, _ := hex.DecodeString(testClientInfo)
+	u, _ := hex.DecodeString(testUPNDNSInfoExtended)
+	var p PACType
+	err := p.Unmarshal(layoutPAC([]pacBuffer{{InfoTypeClientInfo, c}, {InfoTypeUPNDNSInfo, u}}))
+	if err != nil {
+		t.Fatalf("error unmarshaling PAC: %v", err)
+	}
+	k, err := p.ClientInfo()
+	if err != nil {
+		t.Fatalf("error getting client info: %v", err)
+	}
+	assert.Equal(t, "testuser1", k.Name, "client info name not as expected")
+	i, err := p.UPNDNSInfo()
+	if err != nil {
+		t.Fatalf("error getting UPN and DNS info: %v", err)
+	}
+	assert.Equal(t, "testuser1", i.SamName, "SamName not as expected")
+}
@@ -0,0 +1,103 @@
+package xca
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type compressFunc func([]byte) []byte
+
+func compressors() []struct {
+	Name       string
+	Compress   compressFunc
+	Decompress decompressFunc
+} {
+	return []struct {
+		Name       string
+		Compress   compressFunc
+		Decompress decompressFunc
+	}{
+		{"LZ77", CompressLZ77, DecompressLZ77},
+		{"LZ77+Huffman", CompressLZ77Huffman, DecompressLZ77Huffman},
+		{"LZNT1", CompressLZNT1, DecompressLZNT1},
+	}
+}
+
+func testCompressInputs() map[string][]byte {
+	r := rand.New(rand.NewSource(3))
+	random := make([]byte, 70000)
+	r.Read(random)
+	// Text like data of words chosen at random.
+	words := strings.Fields("the quick brown fox jumps over a lazy dog with claims of kerberos tickets")
+	var text []byte
+	for len(text) < 150000 {
+		text = append(text, words[r.Intn(len(words))]...)
+		text = append(text, ' ')
+	}
+	// Byte frequencies following the Fibonacci sequence need codes longer than the maximum code length.
+	var fib []byte
+	for i, a, b := 0, 1, 1; i < 25; i, a, b = i+1, b, a+b {
+		for j := 0; j < a; j++ {
+			fib = append(fib, byte(i))
+		}
+	}
+	r.Shuffle(len(fib), func(i, j int) { fib[i], fib[j] = fib[j], fib[i] })
+	return map[string][]byte{
+		"empty":      {},
+		"one byte":   {'a'},
+		"abc":        []byte(strings.Repeat("abc", 100)),
+		"alphabet":   []byte("abcdefghijklmnopqrstuvwxyz"),
+		"random":     random,
+		"text":       text,
+		"fibonacci":  fib,
+		"long match": make([]byte, 200000),
+		"chunks":     bytes.Repeat(append(random[:4000:4000], make([]byte, 200)...), 5),
+	}
+}
+
+func TestCompress_RoundTrip(t *testing.T) {
+	for _, test := range compressors() {
+		for name, in := range testCompressInputs() {
+			c := test.Compress(in)
+			out, err := test.Decompress(c, len(in))
+			if err != nil {
+				t.Errorf("error decompressing %s compression of %s: %v", test.Name, name, err)
+				continue
+			}
+			assert.True(t, bytes.Equal(in, out), "%s decompression of %s not as expected", test.Name, name)
+		}
+	}
+}
+
+func TestCompress_Ratio(t *testing.T) {
+	in := testCompressInputs()
+	for _, test := range compressors() {
+		for _, name := range []string{"text", "long match"} {
+			c := test.Compress(in[name])
+			assert.True(t, len(c) < len(in[name])/2, "%s compression of %s to %d bytes not effective", test.Name, name, len(c))
+		}
+		// Incompressible data should not grow by more than the overhead of the format.
+		c := test.Compress(in["random"])
+		assert.True(t, len(c) < len(in["random"])*9/8+300, "%s compression of random data to %d bytes", test.Name, len(c))
+	}
+}
+
+func TestCompress_SpecExamples(t *testing.T) {
+	abc := []byte(strings.Repeat("abc", 100))
+	var tests = []struct {
+		Name     string
+		Compress compressFunc
+		Hex      string
+	}{
+		{"LZ77", CompressLZ77, testLZ77ABC},
+		{"LZNT1", CompressLZNT1, testLZNT1ABC},
+	}
+	for _, test := range tests {
+		assert.Equal(t, strings.Replace(test.Hex, " ", "", -1), hex.EncodeToString(test.Compress(abc)), "%s compression not as expected", test.Name)
+	}
+}
@@ -0,0 +1,93 @@
+package xca
+
+import (
+	"encoding/hex"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Randomised tests of malformed input to the decompressors. Any read outside of the input buffer would panic so these
+// check that decompression of corrupted, truncated and random data returns rather than panics, and never returns more
+// than the uncompressed size requested.
+
+const fuzzIterations = 2000
+
+type decompressFunc func([]byte, int) ([]byte, error)
+
+func fuzzDecompressors() []struct {
+	Name       string
+	Decompress decompressFunc
+	Seeds      []string
+} {
+	return []struct {
+		Name       string
+		Decompress decompressFunc
+		Seeds      []string
+	}{
+		{"LZ77", DecompressLZ77, []string{testLZ77ABC, testLZ77HalfBytes}},
+		{"LZ77+Huffman", DecompressLZ77Huffman, []string{testLZ77HuffmanABC, testLZ77HuffmanAlphabet}},
+		{"LZNT1", DecompressLZNT1, []string{testLZNT1ABC, testLZNT1Alphabet, testLZNT1Hello}},
+	}
+}
+
+func fuzzDecompress(t *testing.T, name string, d decompressFunc, in []byte, size int) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("%s decompression of %s with size %d panicked: %v", name, hex.EncodeToString(in), size, r)
+		}
+	}()
+	out, _ := d(in, size)
+	assert.True(t, len(out) <= size, "%s decompression of %s produced %d bytes, more than size %d", name, hex.EncodeToString(in), len(out), size)
+}
+
+func TestDecompress_Mutated(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, test := range fuzzDecompressors() {
+		for _, s := range test.Seeds {
+			seed, _ := hex.DecodeString(strings.Replace(s, " ", "", -1))
+			for i := 0; i < fuzzIterations; i++ {
+				in := make([]byte, len(seed))
+				copy(in, seed)
+				for j := r.Intn(8) + 1; j > 0; j-- {
+					in[r.Intn(len(in))] = byte(r.Intn(256))
+				}
+				fuzzDecompress(t, test.Name, test.Decompress, in, r.Intn(1024))
+			}
+		}
+	}
+}
+
+func TestDecompress_Truncated(t *testing.T) {
+	for _, test := range fuzzDecompressors() {
+		for _, s := range test.Seeds {
+			seed, _ := hex.DecodeString(strings.Replace(s, " ", "", -1))
+			for i := 0; i < len(seed); i++ {
+				for _, size := range []int{0, 1, 300, 4096} {
+					fuzzDecompress(t, test.Name, test.Decompress, seed[:i], size)
+				}
+			}
+		}
+	}
+}
+
+func TestDecompress_Random(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for _, test := range fuzzDecompressors() {
+		for i := 0; i < fuzzIterations; i++ {
+			in := make([]byte, r.Intn(600))
+			r.Read(in)
+			fuzzDecompress(t, test.Name, test.Decompress, in, r.Intn(70000))
+		}
+	}
+}
+
+func TestDecompress_LargeMatchBounded(t *testing.T) {
+	// A match length near the maximum of the 4 byte length encoding must be bounded by the size requested.
+	b, _ := hex.DecodeString("ffffff1f6162631700" + "0fff0000" + "ffffff7f")
+	out, err := DecompressLZ77(b, 1024)
+	assert.Error(t, err, "expected error for match larger than size")
+	assert.Equal(t, 3, len(out), "output should not include the match larger than the size")
+}
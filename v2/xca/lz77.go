@@ -0,0 +1,188 @@
+package xca
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// lz77InitialExpansion is the multiple of the input length allocated for the output of DecompressLZ77.
+const lz77InitialExpansion = 8
+
+// DecompressLZ77 decompresses data compressed with the plain LZ77 format
+// as specified in MS-XCA section 2.4.
+// size is the length of the uncompressed data. An error is returned if the data does not decompress to exactly this
+// many bytes, and decompression stops before more than size bytes are produced.
+func DecompressLZ77(in []byte, size int) ([]byte, error) {
+	if size < 0 {
+		return nil, fmt.Errorf("invalid uncompressed size %d", size)
+	}
+	// A match can have a length of up to 2^32 bytes so there is no useful bound on the expansion of the input. The
+	// capacity starts at a multiple of it and the output grows, to no more than size, as it is decompressed.
+	out := make([]byte, 0, outputCapacity(size, len(in)*lz77InitialExpansion))
+	var flags uint32
+	var flagCount uint
+	var p, halfByte int
+	for {
+		if flagCount == 0 {
+			if p+4 > len(in) {
+				break
+			}
+			flags = binary.LittleEndian.Uint32(in[p:])
+			p += 4
+			flagCount = 32
+		}
+		flagCount--
+		if flags&(1<<flagCount) == 0 {
+			if p >= len(in) {
+				break
+			}
+			if len(out) >= size {
+				return out, fmt.Errorf("LZ77 data decompresses to more than %d bytes", size)
+			}
+			out = append(out, in[p])
+			p++
+			continue
+		}
+		if p == len(in) {
+			break
+		}
+		if p+2 > len(in) {
+			return out, fmt.Errorf("LZ77 input ended reading match at %d", p)
+		}
+		m := binary.LittleEndian.Uint16(in[p:])
+		p += 2
+		length := int(m % 8)
+		offset := int(m/8) + 1
+		if length == 7 {
+			if halfByte == 0 {
+				if p >= len(in) {
+					return out, fmt.Errorf("LZ77 input ended reading match length at %d", p)
+				}
+				length = int(in[p] % 16)
+				halfByte = p
+				p++
+			} else {
+				length = int(in[halfByte] / 16)
+				halfByte = 0
+			}
+			if length == 15 {
+				if p >= len(in) {
+					return out, fmt.Errorf("LZ77 input ended reading match length at %d", p)
+				}
+				length = int(in[p])
+				p++
+				if length == 255 {
+					if p+2 > len(in) {
+						return out, fmt.Errorf("LZ77 input ended reading match length at %d", p)
+					}
+					length = int(binary.LittleEndian.Uint16(in[p:]))
+					p += 2
+					if length == 0 {
+						if p+4 > len(in) {
+							return out, fmt.Errorf("LZ77 input ended reading match length at %d", p)
+						}
+						length = int(binary.LittleEndian.Uint32(in[p:]))
+						p += 4
+					}
+					if length < 15+7 {
+						return out, fmt.Errorf("LZ77 match length %d not valid", length)
+					}
+					length -= 15 + 7
+				}
+				length += 15
+			}
+			length += 7
+		}
+		length += 3
+		if offset > len(out) {
+			return out, fmt.Errorf("LZ77 match offset %d before start of output at %d", offset, len(out))
+		}
+		if length > size-len(out) {
+			return out, fmt.Errorf("LZ77 data decompresses to more than %d bytes", size)
+		}
+		out = copyMatch(out, offset, length, size)
+	}
+	if len(out) != size {
+		return out, fmt.Errorf("LZ77 data decompressed to %d bytes, expected %d", len(out), size)
+	}
+	return out, nil
+}
+
+const lz77MaxOffset = 1 << 13
+
+// CompressLZ77 compresses data with the plain LZ77 format as specified in MS-XCA section 2.3.
+func CompressLZ77(in []byte) []byte {
+	out := make([]byte, 4, len(in)+len(in)/32+8)
+	m := newMatchFinder(in)
+	var flags uint32
+	var flagCount uint
+	var flagPos, halfByte int
+	for i := 0; i < len(in); {
+		if flagCount == 32 {
+			binary.LittleEndian.PutUint32(out[flagPos:], flags)
+			flagPos = len(out)
+			out = append(out, 0, 0, 0, 0)
+			flagCount = 0
+		}
+		flagCount++
+		offset, length := m.find(i, 0, lz77MaxOffset, len(in))
+		if length == 0 {
+			flags <<= 1
+			out = append(out, in[i])
+			m.insert(i)
+			i++
+			continue
+		}
+		flags = flags<<1 | 1
+		l := length - 3
+		t := uint16(offset-1) << 3
+		if l < 7 {
+			out = appendUint16(out, t|uint16(l))
+		} else {
+			out = appendUint16(out, t|7)
+			l -= 7
+			n := l
+			if n > 15 {
+				n = 15
+			}
+			// Lengths are held in half bytes, the second half of a byte is used by the next long match.
+			if halfByte == 0 {
+				halfByte = len(out)
+				out = append(out, byte(n))
+			} else {
+				out[halfByte] |= byte(n) << 4
+				halfByte = 0
+			}
+			if l >= 15 {
+				l -= 15
+				if l < 255 {
+					out = append(out, byte(l))
+				} else {
+					out = append(out, 255)
+					out = appendLength(out, l+15+7)
+				}
+			}
+		}
+		for j := i; j < i+length; j++ {
+			m.insert(j)
+		}
+		i += length
+	}
+	// The unused flags are set so the end of the input is reached where a match is expected.
+	flags = flags<<(32-flagCount) | (1<<(32-flagCount) - 1)
+	binary.LittleEndian.PutUint32(out[flagPos:], flags)
+	return out
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v), byte(v>>8))
+}
+
+// appendLength appends a match length as 2 bytes, or if it is too large as 2 zero bytes followed by 4 bytes.
+func appendLength(b []byte, l int) []byte {
+	if l < 1<<16 {
+		return appendUint16(b, uint16(l))
+	}
+	b = appendUint16(b, 0)
+	return append(b, byte(l), byte(l>>8), byte(l>>16), byte(l>>24))
+}
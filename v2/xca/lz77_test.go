@@ -0,0 +1,73 @@
+package xca
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	// Example from MS-XCA section 3.1.
+	testLZ77ABC = "ffffff1f6162631700 0fff2601"
+	// Two matches sharing the byte holding their length half bytes.
+	testLZ77HalfBytes = "ffffff0061626364656667683f00323f00"
+)
+
+func TestDecompressLZ77(t *testing.T) {
+	var tests = []struct {
+		Hex      string
+		Expected string
+	}{
+		{testLZ77ABC, strings.Repeat("abc", 100)},
+		{testLZ77HalfBytes, "abcdefghabcdefghabcdefghabcdefgha"},
+		// Literals only, terminated by running out of input.
+		{"00000000616263", "abc"},
+		// Match length encoded in 4 bytes.
+		{"ffffff1f6162631700 0fff0000 26010000", strings.Repeat("abc", 100)},
+		{"", ""},
+	}
+	for i, test := range tests {
+		b, _ := hex.DecodeString(strings.Replace(test.Hex, " ", "", -1))
+		out, err := DecompressLZ77(b, len(test.Expected))
+		if err != nil {
+			t.Errorf("error decompressing test %d: %v", i, err)
+		}
+		assert.Equal(t, test.Expected, string(out), "decompressed output not as expected for test %d", i)
+	}
+}
+
+func TestDecompressLZ77_HugeSize(t *testing.T) {
+	testDecompressHugeSize(t, DecompressLZ77, []byte{0x00, 0xb0})
+	b, _ := hex.DecodeString(testLZ77ABC)
+	testDecompressHugeSize(t, DecompressLZ77, b)
+}
+
+func TestDecompressLZ77_Invalid(t *testing.T) {
+	var tests = []struct {
+		Name string
+		Hex  string
+		Size int
+	}{
+		{"size too small", testLZ77HalfBytes, 32},
+		{"size too large", testLZ77HalfBytes, 34},
+		{"literals larger than size", "00000000616263", 2},
+		{"negative size", "00000000616263", -1},
+		{"offset before output", "ffffff7f610800", 4},
+		{"truncated match", "ffffff1f61626317", 300},
+		{"truncated half byte", "ffffff1f6162631700", 300},
+		{"truncated length byte", "ffffff1f6162631700 0f", 300},
+		{"truncated 2 byte length", "ffffff1f6162631700 0fff26", 300},
+		{"truncated 4 byte length", "ffffff1f6162631700 0fff0000260100", 300},
+		{"2 byte length too small", "ffffff1f6162631700 0fff1500", 300},
+	}
+	for _, test := range tests {
+		b, _ := hex.DecodeString(strings.Replace(test.Hex, " ", "", -1))
+		out, err := DecompressLZ77(b, test.Size)
+		assert.Error(t, err, "expected error for %s", test.Name)
+		if test.Size >= 0 {
+			assert.True(t, len(out) <= test.Size, "output larger than size for %s", test.Name)
+		}
+	}
+}
@@ -0,0 +1,416 @@
+// Package xca implements the compression algorithms of MS-XCA: Xpress Compression Algorithm
+// https://docs.microsoft.com/en-us/openspecs/windows_protocols/ms-xca/a8b7cb0a-92a6-4187-a23b-5e14273b96f8
+package xca
+
+import (
+	"container/heap"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+const (
+	huffmanSymbols       = 512
+	huffmanTableBytes    = huffmanSymbols / 2
+	huffmanMaxCodeLength = 15
+	huffmanBlockSize     = 65536
+	huffmanMaxOffset     = 1<<16 - 1
+	huffmanEOF           = 256
+)
+
+// DecompressLZ77Huffman decompresses data compressed with the LZ77+Huffman format
+// as specified in MS-XCA section 2.2.
+// size is the length of the uncompressed data. Decompression stops once this many bytes have been produced so it
+// also bounds the memory that can be allocated by malformed or malicious input.
+func DecompressLZ77Huffman(in []byte, size int) ([]byte, error) {
+	if size < 0 {
+		return nil, fmt.Errorf("invalid uncompressed size %d", size)
+	}
+	// Each block of up to huffmanBlockSize bytes of output is preceded by its Huffman table.
+	out := make([]byte, 0, outputCapacity(size, (len(in)/huffmanTableBytes+1)*huffmanBlockSize))
+	r := &huffmanReader{in: in}
+	for len(out) < size {
+		if len(in)-r.pos < huffmanTableBytes {
+			return out, fmt.Errorf("LZ77+Huffman input ended at %d bytes before the Huffman table, %d of %d bytes decompressed", r.pos, len(out), size)
+		}
+		t, err := newHuffmanDecodeTable(in[r.pos : r.pos+huffmanTableBytes])
+		if err != nil {
+			return out, fmt.Errorf("LZ77+Huffman Huffman table at %d not valid: %v", r.pos, err)
+		}
+		r.pos += huffmanTableBytes
+		r.bits = uint32(r.read16()) << 16
+		r.bits |= uint32(r.read16())
+		r.extra = 16
+		blockEnd := len(out) + huffmanBlockSize
+		for len(out) < blockEnd && len(out) < size {
+			sym, l := t.lookup(r.bits >> (32 - huffmanMaxCodeLength))
+			if l == 0 {
+				return out, fmt.Errorf("LZ77+Huffman code not valid at %d", r.pos)
+			}
+			r.consume(l)
+			if sym < 256 {
+				out = append(out, byte(sym))
+				continue
+			}
+			sym -= 256
+			length := int(sym & 15)
+			offsetBits := uint(sym >> 4)
+			if length == 15 {
+				if r.pos >= len(in) {
+					return out, errors.New("LZ77+Huffman input ended reading match length")
+				}
+				length = int(in[r.pos])
+				r.pos++
+				if length == 255 {
+					if r.pos+2 > len(in) {
+						return out, errors.New("LZ77+Huffman input ended reading match length")
+					}
+					length = int(binary.LittleEndian.Uint16(in[r.pos:]))
+					r.pos += 2
+					if length == 0 {
+						if r.pos+4 > len(in) {
+							return out, errors.New("LZ77+Huffman input ended reading match length")
+						}
+						length = int(binary.LittleEndian.Uint32(in[r.pos:]))
+						r.pos += 4
+					}
+					if length < 15 {
+						return out, fmt.Errorf("LZ77+Huffman match length %d not valid", length)
+					}
+					length -= 15
+				}
+				length += 15
+			}
+			length += 3
+			offset := int(r.bits>>(32-offsetBits)) | 1<<offsetBits
+			r.consume(offsetBits)
+			if r.pos > len(in)+4 {
+				return out, fmt.Errorf("LZ77+Huffman input ended, %d of %d bytes decompressed", len(out), size)
+			}
+			if offset > len(out) {
+				return out, fmt.Errorf("LZ77+Huffman match offset %d before start of output at %d", offset, len(out))
+			}
+			out = copyMatch(out, offset, length, size)
+		}
+		if r.pos > len(in)+4 {
+			return out, fmt.Errorf("LZ77+Huffman input ended, %d of %d bytes decompressed", len(out), size)
+		}
+	}
+	return out, nil
+}
+
+// outputCapacity returns the capacity to allocate for the output of decompressing to size bytes. The size is often
+// read from the same untrusted source as the compressed data, so the capacity is no more than bound, the most the input
+// can decompress to, and the output grows beyond it only as it is decompressed.
+func outputCapacity(size, bound int) int {
+	if bound < size {
+		return bound
+	}
+	return size
+}
+
+// copyMatch appends length bytes from offset bytes back in the output, which may overlap the bytes being appended.
+// No more bytes are appended than would take the output beyond max.
+func copyMatch(out []byte, offset, length, max int) []byte {
+	if length > max-len(out) {
+		length = max - len(out)
+	}
+	s := len(out) - offset
+	for i := 0; i < length; i++ {
+		out = append(out, out[s+i])
+	}
+	return out
+}
+
+// huffmanReader reads the bit stream of a LZ77+Huffman block.
+// Reads beyond the end of the input return zeros as the bit buffer is filled ahead of the bits being consumed.
+type huffmanReader struct {
+	in    []byte
+	pos   int
+	bits  uint32
+	extra int
+}
+
+func (r *huffmanReader) read16() uint16 {
+	var v uint16
+	if r.pos+2 <= len(r.in) {
+		v = binary.LittleEndian.Uint16(r.in[r.pos:])
+	}
+	r.pos += 2
+	return v
+}
+
+func (r *huffmanReader) consume(n uint) {
+	r.bits <<= n
+	r.extra -= int(n)
+	if r.extra < 0 {
+		r.bits |= uint32(r.read16()) << uint(-r.extra)
+		r.extra += 16
+	}
+}
+
+// huffmanDecodeTable maps each possible 15 bit prefix of the bit stream to the symbol and code length it begins with.
+type huffmanDecodeTable struct {
+	symbols [1 << huffmanMaxCodeLength]uint16
+	lengths [1 << huffmanMaxCodeLength]uint8
+}
+
+// newHuffmanDecodeTable builds the decoding table from the 256 byte encoding of the 512 symbol code lengths.
+func newHuffmanDecodeTable(b []byte) (*huffmanDecodeTable, error) {
+	var lengths [huffmanSymbols]uint8
+	for i := 0; i < huffmanTableBytes; i++ {
+		lengths[2*i] = b[i] & 0x0f
+		lengths[2*i+1] = b[i] >> 4
+	}
+	t := new(huffmanDecodeTable)
+	var code int
+	for l := uint8(1); l <= huffmanMaxCodeLength; l++ {
+		for sym, sl := range lengths {
+			if sl != l {
+				continue
+			}
+			n := 1 << (huffmanMaxCodeLength - l)
+			if code+n > len(t.symbols) {
+				return nil, errors.New("code lengths over-subscribed")
+			}
+			for i := code; i < code+n; i++ {
+				t.symbols[i] = uint16(sym)
+				t.lengths[i] = l
+			}
+			code += n
+		}
+	}
+	if code == 0 {
+		return nil, errors.New("no symbols have a code")
+	}
+	return t, nil
+}
+
+func (t *huffmanDecodeTable) lookup(prefix uint32) (uint16, uint) {
+	return t.symbols[prefix], uint(t.lengths[prefix])
+}
+
+// CompressLZ77Huffman compresses data with the LZ77+Huffman format as specified in MS-XCA section 2.1.
+func CompressLZ77Huffman(in []byte) []byte {
+	out := make([]byte, 0, huffmanTableBytes+len(in)+len(in)/8+8)
+	m := newMatchFinder(in)
+	for s := 0; ; {
+		e := s + huffmanBlockSize
+		if e > len(in) {
+			e = len(in)
+		}
+		// Matches do not extend beyond the end of the block so the block decompresses to exactly its size.
+		var tokens []huffmanToken
+		var freq [huffmanSymbols]int
+		for i := s; i < e; {
+			offset, length := m.find(i, 0, huffmanMaxOffset, e-i)
+			if length == 0 {
+				tokens = append(tokens, huffmanToken{symbol: uint16(in[i])})
+				freq[in[i]]++
+				m.insert(i)
+				i++
+				continue
+			}
+			offsetBits := uint(bits.Len(uint(offset)) - 1)
+			l := length - 3
+			if l > 15 {
+				l = 15
+			}
+			t := huffmanToken{symbol: uint16(huffmanEOF + offsetBits<<4 + uint(l)), length: length, offset: offset}
+			tokens = append(tokens, t)
+			freq[t.symbol]++
+			for j := i; j < i+length; j++ {
+				m.insert(j)
+			}
+			i += length
+		}
+		last := e == len(in)
+		if last {
+			// The end of the data is marked with the EOF symbol.
+			tokens = append(tokens, huffmanToken{symbol: huffmanEOF})
+			freq[huffmanEOF]++
+		}
+		out = appendHuffmanBlock(out, tokens, freq)
+		if last {
+			return out
+		}
+		s = e
+	}
+}
+
+// huffmanToken is a literal byte or a match to be Huffman encoded.
+type huffmanToken struct {
+	symbol uint16
+	length int
+	offset int
+}
+
+// appendHuffmanBlock appends the Huffman table and encoded tokens of a block.
+func appendHuffmanBlock(out []byte, tokens []huffmanToken, freq [huffmanSymbols]int) []byte {
+	lengths := huffmanCodeLengths(freq)
+	codes := huffmanCodes(lengths)
+	for i := 0; i < huffmanTableBytes; i++ {
+		out = append(out, lengths[2*i]|lengths[2*i+1]<<4)
+	}
+	w := newHuffmanWriter(out)
+	for _, t := range tokens {
+		w.writeBits(uint(lengths[t.symbol]), uint32(codes[t.symbol]))
+		if t.symbol < huffmanEOF || t.length == 0 {
+			continue
+		}
+		l := t.length - 3
+		if l >= 15 {
+			l -= 15
+			if l < 255 {
+				w.out = append(w.out, byte(l))
+			} else {
+				w.out = append(w.out, 255)
+				w.out = appendLength(w.out, l+15)
+			}
+		}
+		offsetBits := uint(t.symbol-huffmanEOF) >> 4
+		w.writeBits(offsetBits, uint32(t.offset)&(1<<offsetBits-1))
+	}
+	return w.end()
+}
+
+// huffmanWriter writes the bit stream of a LZ77+Huffman block.
+// Bits are written into 16 bit words which are interleaved with the extra bytes of match lengths. The positions of the
+// next two words are reserved in advance as the decoder reads ahead by two words before reading the extra bytes.
+type huffmanWriter struct {
+	out    []byte
+	w1, w2 int    // positions of the word being filled and the next word
+	bits   uint32 // bits of the word being filled
+	free   uint   // number of bits still to be filled in the word
+}
+
+func newHuffmanWriter(out []byte) *huffmanWriter {
+	w := &huffmanWriter{out: out, w1: len(out), w2: len(out) + 2, free: 16}
+	w.out = append(w.out, 0, 0, 0, 0)
+	return w
+}
+
+// writeBits writes the n least significant bits of v, most significant first. n must not be more than 16.
+func (w *huffmanWriter) writeBits(n uint, v uint32) {
+	if n <= w.free {
+		w.free -= n
+		w.bits = w.bits<<n | v
+		return
+	}
+	n -= w.free
+	w.bits = w.bits<<w.free | v>>n
+	binary.LittleEndian.PutUint16(w.out[w.w1:], uint16(w.bits))
+	w.w1 = w.w2
+	w.w2 = len(w.out)
+	w.out = append(w.out, 0, 0)
+	w.free = 16 - n
+	w.bits = v & (1<<n - 1)
+}
+
+// end writes the remaining bits and returns the output.
+func (w *huffmanWriter) end() []byte {
+	binary.LittleEndian.PutUint16(w.out[w.w1:], uint16(w.bits<<w.free))
+	return w.out
+}
+
+// huffmanCodeLengths returns the code lengths of a Huffman code for the symbol frequencies provided.
+// Code lengths are limited to the maximum by flattening the frequencies until the code fits. At least two symbols are
+// given a code so the code is always complete.
+func huffmanCodeLengths(freq [huffmanSymbols]int) [huffmanSymbols]uint8 {
+	var n int
+	for _, f := range freq {
+		if f > 0 {
+			n++
+		}
+	}
+	for i := 0; n < 2; i++ {
+		if freq[i] == 0 {
+			freq[i] = 1
+			n++
+		}
+	}
+	for {
+		lengths, ok := huffmanTreeLengths(freq)
+		if ok {
+			return lengths
+		}
+		for i, f := range freq {
+			if f > 0 {
+				freq[i] = f>>1 | 1
+			}
+		}
+	}
+}
+
+// huffmanTreeLengths builds a Huffman tree and returns the depth of each symbol's leaf.
+// false is returned if any depth is more than the maximum code length.
+func huffmanTreeLengths(freq [huffmanSymbols]int) (lengths [huffmanSymbols]uint8, ok bool) {
+	var h huffmanHeap
+	for sym, f := range freq {
+		if f > 0 {
+			h = append(h, &huffmanNode{weight: f, symbol: sym, order: sym})
+		}
+	}
+	heap.Init(&h)
+	for order := huffmanSymbols; h.Len() > 1; order++ {
+		a := heap.Pop(&h).(*huffmanNode)
+		b := heap.Pop(&h).(*huffmanNode)
+		heap.Push(&h, &huffmanNode{weight: a.weight + b.weight, symbol: -1, order: order, children: [2]*huffmanNode{a, b}})
+	}
+	ok = true
+	var walk func(n *huffmanNode, depth uint8)
+	walk = func(n *huffmanNode, depth uint8) {
+		if n.symbol >= 0 {
+			if depth > huffmanMaxCodeLength {
+				ok = false
+			}
+			lengths[n.symbol] = depth
+			return
+		}
+		walk(n.children[0], depth+1)
+		walk(n.children[1], depth+1)
+	}
+	walk(h[0], 0)
+	return
+}
+
+// huffmanCodes returns the canonical codes for the code lengths, assigned in the same order as the decoding table.
+func huffmanCodes(lengths [huffmanSymbols]uint8) (codes [huffmanSymbols]uint16) {
+	var code int
+	for l := uint8(1); l <= huffmanMaxCodeLength; l++ {
+		for sym, sl := range lengths {
+			if sl != l {
+				continue
+			}
+			codes[sym] = uint16(code >> (huffmanMaxCodeLength - l))
+			code += 1 << (huffmanMaxCodeLength - l)
+		}
+	}
+	return
+}
+
+type huffmanNode struct {
+	weight   int
+	symbol   int // -1 for an internal node
+	order    int // breaks ties between equal weights so the tree is deterministic
+	children [2]*huffmanNode
+}
+
+type huffmanHeap []*huffmanNode
+
+func (h huffmanHeap) Len() int { return len(h) }
+func (h huffmanHeap) Less(i, j int) bool {
+	if h[i].weight != h[j].weight {
+		return h[i].weight < h[j].weight
+	}
+	return h[i].order < h[j].order
+}
+func (h huffmanHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *huffmanHeap) Push(x interface{}) { *h = append(*h, x.(*huffmanNode)) }
+func (h *huffmanHeap) Pop() interface{} {
+	old := *h
+	n := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return n
+}
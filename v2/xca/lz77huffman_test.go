@@ -0,0 +1,94 @@
+package xca
+
+import (
+	"bytes"
+	"encoding/hex"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Examples from MS-XCA section 3.2.
+const (
+	testLZ77HuffmanABC      = "00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000030230000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000200000000000000000000000000002000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000a8dc0000ff2601"
+	testLZ77HuffmanAlphabet = "00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000050555555555555555555554544040000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000400000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000d8523ed794115be9195ff9d67cdf8d0400000000"
+)
+
+func TestDecompressLZ77Huffman(t *testing.T) {
+	var tests = []struct {
+		Hex      string
+		Expected string
+	}{
+		{testLZ77HuffmanABC, strings.Repeat("abc", 100)},
+		{testLZ77HuffmanAlphabet, "abcdefghijklmnopqrstuvwxyz"},
+	}
+	for i, test := range tests {
+		b, _ := hex.DecodeString(test.Hex)
+		out, err := DecompressLZ77Huffman(b, len(test.Expected))
+		if err != nil {
+			t.Errorf("error decompressing test %d: %v", i, err)
+		}
+		assert.Equal(t, test.Expected, string(out), "decompressed output not as expected for test %d", i)
+	}
+}
+
+func TestDecompressLZ77Huffman_SizeBound(t *testing.T) {
+	b, _ := hex.DecodeString(testLZ77HuffmanABC)
+	out, err := DecompressLZ77Huffman(b, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "abcabcabca", string(out), "output not bounded by the size provided")
+	out, err = DecompressLZ77Huffman(b, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 0, len(out), "output should be empty for a size of zero")
+	_, err = DecompressLZ77Huffman(b, -1)
+	assert.Error(t, err, "negative size should error")
+}
+
+// testDecompressHugeSize checks that decompressing the input with a declared size far larger than it could decompress
+// to fails without allocating for the declared size.
+func testDecompressHugeSize(t *testing.T, d func([]byte, int) ([]byte, error), in []byte) {
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	_, err := d(in, 0xfffffff0)
+	runtime.ReadMemStats(&after)
+	assert.Error(t, err, "expected error for input that does not decompress to the size")
+	assert.True(t, after.TotalAlloc-before.TotalAlloc < 1<<20, "%d bytes allocated for the declared size", after.TotalAlloc-before.TotalAlloc)
+}
+
+func TestDecompressLZ77Huffman_HugeSize(t *testing.T) {
+	testDecompressHugeSize(t, DecompressLZ77Huffman, []byte{0, 0})
+	b, _ := hex.DecodeString(testLZ77HuffmanABC)
+	testDecompressHugeSize(t, DecompressLZ77Huffman, b)
+}
+
+func TestDecompressLZ77Huffman_Invalid(t *testing.T) {
+	abc, _ := hex.DecodeString(testLZ77HuffmanABC)
+	oversubscribed := bytes.Repeat([]byte{0x11}, huffmanTableBytes)
+	noCodes := make([]byte, huffmanTableBytes+4)
+	// Only the symbol for a match of length 3 with offset 1 has a code, which refers to before the start of the output.
+	matchOnly := make([]byte, huffmanTableBytes+4)
+	matchOnly[128] = 0x01
+	var tests = []struct {
+		Name string
+		In   []byte
+		Size int
+	}{
+		{"empty", []byte{}, 1},
+		{"truncated table", abc[:100], 300},
+		{"oversubscribed table", append(oversubscribed, 0, 0, 0, 0), 1},
+		{"no codes", noCodes, 1},
+		{"offset before output", matchOnly, 10},
+		{"input ended", abc, 1000},
+	}
+	for _, test := range tests {
+		out, err := DecompressLZ77Huffman(test.In, test.Size)
+		assert.Error(t, err, "expected error for %s", test.Name)
+		assert.True(t, len(out) <= test.Size, "output larger than size for %s", test.Name)
+	}
+}
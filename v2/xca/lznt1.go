@@ -0,0 +1,164 @@
+package xca
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	lznt1ChunkSize       = 4096
+	lznt1ChunkHeaderSize = 2
+	lznt1SizeMask        = 0x0fff
+	lznt1SignatureMask   = 0x7000
+	lznt1Signature       = 0x3000
+	lznt1Compressed      = 0x8000
+)
+
+// DecompressLZNT1 decompresses data compressed with the LZNT1 format
+// as specified in MS-XCA section 2.5.
+// size is the length of the uncompressed data. An error is returned if the data does not decompress to exactly this
+// many bytes, and decompression stops before more than size bytes are produced.
+func DecompressLZNT1(in []byte, size int) ([]byte, error) {
+	if size < 0 {
+		return nil, fmt.Errorf("invalid uncompressed size %d", size)
+	}
+	// Each chunk of at least a header and a byte decompresses to no more than lznt1ChunkSize bytes.
+	out := make([]byte, 0, outputCapacity(size, (len(in)/(lznt1ChunkHeaderSize+1)+1)*lznt1ChunkSize))
+	var p int
+	for p+lznt1ChunkHeaderSize <= len(in) {
+		h := binary.LittleEndian.Uint16(in[p:])
+		if h == 0 {
+			// End of data marker.
+			break
+		}
+		if h&lznt1SignatureMask != lznt1Signature {
+			return out, fmt.Errorf("LZNT1 chunk header at %d has invalid signature", p)
+		}
+		p += lznt1ChunkHeaderSize
+		n := int(h&lznt1SizeMask) + 1
+		if p+n > len(in) {
+			return out, fmt.Errorf("LZNT1 chunk at %d of %d bytes extends beyond the input", p, n)
+		}
+		var err error
+		if h&lznt1Compressed == 0 {
+			if len(out)+n > size {
+				return out, fmt.Errorf("LZNT1 data decompresses to more than %d bytes", size)
+			}
+			out = append(out, in[p:p+n]...)
+		} else {
+			out, err = decompressLZNT1Chunk(in[p:p+n], out, size)
+			if err != nil {
+				return out, fmt.Errorf("LZNT1 chunk at %d not valid: %v", p, err)
+			}
+		}
+		p += n
+	}
+	if len(out) != size {
+		return out, fmt.Errorf("LZNT1 data decompressed to %d bytes, expected %d", len(out), size)
+	}
+	return out, nil
+}
+
+// decompressLZNT1Chunk appends the decompressed data of a compressed chunk to out.
+func decompressLZNT1Chunk(in, out []byte, size int) ([]byte, error) {
+	start := len(out)
+	var p int
+	for p < len(in) {
+		flags := in[p]
+		p++
+		for i := uint(0); i < 8 && p < len(in); i++ {
+			pos := len(out) - start
+			if pos >= lznt1ChunkSize {
+				return out, fmt.Errorf("chunk decompresses to more than %d bytes", lznt1ChunkSize)
+			}
+			if flags&(1<<i) == 0 {
+				if len(out) >= size {
+					return out, fmt.Errorf("data decompresses to more than %d bytes", size)
+				}
+				out = append(out, in[p])
+				p++
+				continue
+			}
+			if p+2 > len(in) {
+				return out, fmt.Errorf("chunk ended reading match at %d", p)
+			}
+			t := binary.LittleEndian.Uint16(in[p:])
+			p += 2
+			lengthBits := lznt1LengthBits(pos)
+			offset := int(t>>lengthBits) + 1
+			length := int(t&(1<<lengthBits-1)) + 3
+			if offset > pos {
+				return out, fmt.Errorf("match offset %d before start of chunk at %d", offset, pos)
+			}
+			if pos+length > lznt1ChunkSize {
+				return out, fmt.Errorf("chunk decompresses to more than %d bytes", lznt1ChunkSize)
+			}
+			if len(out)+length > size {
+				return out, fmt.Errorf("data decompresses to more than %d bytes", size)
+			}
+			out = copyMatch(out, offset, length, size)
+		}
+	}
+	return out, nil
+}
+
+// lznt1LengthBits returns the number of bits of a match token used for the length for a match at the position in the
+// chunk provided. The remaining bits are used for the offset which must be able to reach back to the start of the chunk.
+func lznt1LengthBits(pos int) uint {
+	l := uint(12)
+	for i := pos - 1; i >= 0x10; i >>= 1 {
+		l--
+	}
+	return l
+}
+
+// CompressLZNT1 compresses data with the LZNT1 format as specified in MS-XCA section 2.5.
+// Chunks that do not compress are stored uncompressed.
+func CompressLZNT1(in []byte) []byte {
+	out := make([]byte, 0, len(in)+len(in)/lznt1ChunkSize*lznt1ChunkHeaderSize+lznt1ChunkHeaderSize)
+	for s := 0; s < len(in); s += lznt1ChunkSize {
+		e := s + lznt1ChunkSize
+		if e > len(in) {
+			e = len(in)
+		}
+		c := compressLZNT1Chunk(in[s:e])
+		if len(c) < e-s {
+			out = appendUint16(out, lznt1Compressed|lznt1Signature|uint16(len(c)-1))
+			out = append(out, c...)
+		} else {
+			out = appendUint16(out, lznt1Signature|uint16(e-s-1))
+			out = append(out, in[s:e]...)
+		}
+	}
+	return out
+}
+
+// compressLZNT1Chunk returns the compressed data of a chunk.
+func compressLZNT1Chunk(in []byte) []byte {
+	out := make([]byte, 0, len(in)+len(in)/8+1)
+	m := newMatchFinder(in)
+	var flagPos int
+	bit := uint(8)
+	for i := 0; i < len(in); bit++ {
+		if bit == 8 {
+			flagPos = len(out)
+			out = append(out, 0)
+			bit = 0
+		}
+		lengthBits := lznt1LengthBits(i)
+		offset, length := m.find(i, 0, 1<<(16-lengthBits), 1<<lengthBits+2)
+		if length == 0 {
+			out = append(out, in[i])
+			m.insert(i)
+			i++
+			continue
+		}
+		out[flagPos] |= 1 << bit
+		out = appendUint16(out, uint16(offset-1)<<lengthBits|uint16(length-3))
+		for j := i; j < i+length; j++ {
+			m.insert(j)
+		}
+		i += length
+	}
+	return out
+}
@@ -0,0 +1,75 @@
+package xca
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	// Compressed chunk of three literals followed by a match of length 297 at offset 3.
+	testLZNT1ABC = "05b0086162632621"
+	// Compressed chunk of 26 literals followed by a match of length 26 at offset 26 which uses an 11 bit length.
+	testLZNT1Alphabet = "1fb000616263646566676800696a6b6c6d6e6f7000717273747576777804797a17c8"
+	// Uncompressed chunk.
+	testLZNT1Hello = "043068656c6c6f"
+)
+
+func TestDecompressLZNT1(t *testing.T) {
+	var tests = []struct {
+		Hex      string
+		Expected string
+	}{
+		{testLZNT1ABC, strings.Repeat("abc", 100)},
+		{testLZNT1Alphabet, strings.Repeat("abcdefghijklmnopqrstuvwxyz", 2)},
+		{testLZNT1Hello, "hello"},
+		{testLZNT1ABC + testLZNT1Hello + "0000", strings.Repeat("abc", 100) + "hello"},
+		{testLZNT1Hello + testLZNT1Alphabet, "hello" + strings.Repeat("abcdefghijklmnopqrstuvwxyz", 2)},
+		{"", ""},
+	}
+	for i, test := range tests {
+		b, _ := hex.DecodeString(test.Hex)
+		out, err := DecompressLZNT1(b, len(test.Expected))
+		if err != nil {
+			t.Errorf("error decompressing test %d: %v", i, err)
+		}
+		assert.Equal(t, test.Expected, string(out), "decompressed output not as expected for test %d", i)
+	}
+}
+
+func TestDecompressLZNT1_HugeSize(t *testing.T) {
+	testDecompressHugeSize(t, DecompressLZNT1, []byte{0x00, 0xb0})
+	b, _ := hex.DecodeString(testLZNT1ABC)
+	testDecompressHugeSize(t, DecompressLZNT1, b)
+}
+
+func TestDecompressLZNT1_Invalid(t *testing.T) {
+	var tests = []struct {
+		Name string
+		Hex  string
+		Size int
+	}{
+		{"size too small", testLZNT1ABC, 299},
+		{"size too large", testLZNT1ABC, 301},
+		{"uncompressed chunk larger than size", testLZNT1Hello, 4},
+		{"negative size", testLZNT1Hello, -1},
+		{"bad signature", "05a0086162632621", 300},
+		{"chunk beyond input", "06b0086162632621", 300},
+		{"truncated match", "04b00861626326", 300},
+		{"offset before chunk", "02b0010000", 300},
+		// Match of length 4098 at the start of a chunk.
+		{"chunk larger than 4096", "03b00261ff0f", 4099},
+		// Match at offset 3 from an uncompressed chunk is not permitted to cross into a compressed chunk.
+		{"offset into previous chunk", "023061626302b0010020", 300},
+	}
+	for _, test := range tests {
+		b, _ := hex.DecodeString(test.Hex)
+		out, err := DecompressLZNT1(b, test.Size)
+		assert.Error(t, err, "expected error for %s", test.Name)
+		if test.Size >= 0 {
+			assert.True(t, len(out) <= test.Size, "output larger than size for %s", test.Name)
+		}
+	}
+}
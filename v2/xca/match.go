@@ -0,0 +1,75 @@
+package xca
+
+const (
+	matchMinLength = 3
+	matchHashBits  = 15
+	matchMaxChain  = 64 // The number of earlier positions with the same hash examined for each match
+)
+
+// matchFinder finds earlier occurrences of the bytes at a position of the input for LZ77 style compression.
+// Earlier positions are kept in chains of positions with the same hash of the 3 bytes starting at the position.
+type matchFinder struct {
+	in   []byte
+	head []int32
+	prev []int32
+}
+
+func newMatchFinder(in []byte) *matchFinder {
+	m := &matchFinder{
+		in:   in,
+		head: make([]int32, 1<<matchHashBits),
+		prev: make([]int32, len(in)),
+	}
+	for i := range m.head {
+		m.head[i] = -1
+	}
+	return m
+}
+
+func (m *matchFinder) hash(i int) uint32 {
+	v := uint32(m.in[i]) | uint32(m.in[i+1])<<8 | uint32(m.in[i+2])<<16
+	return (v * 2654435761) >> (32 - matchHashBits)
+}
+
+// insert adds the position to the chains so it can be found by later positions.
+// Positions must be inserted in order and after any find at the position.
+func (m *matchFinder) insert(i int) {
+	if i+matchMinLength > len(m.in) {
+		return
+	}
+	h := m.hash(i)
+	m.prev[i] = m.head[h]
+	m.head[h] = int32(i)
+}
+
+// find returns the offset back from position i, and the length, of the longest match for the bytes at i.
+// The match must not start before start or more than maxOffset bytes back, and is limited to maxLength bytes.
+// A length of zero is returned if there is no match of at least the minimum length.
+func (m *matchFinder) find(i, start, maxOffset, maxLength int) (offset, length int) {
+	if maxLength > len(m.in)-i {
+		maxLength = len(m.in) - i
+	}
+	if maxLength < matchMinLength {
+		return 0, 0
+	}
+	limit := i - maxOffset
+	if limit < start {
+		limit = start
+	}
+	for j, n := m.head[m.hash(i)], 0; j >= 0 && int(j) >= limit && n < matchMaxChain; j, n = m.prev[j], n+1 {
+		var l int
+		for l < maxLength && m.in[int(j)+l] == m.in[i+l] {
+			l++
+		}
+		if l > length {
+			offset, length = i-int(j), l
+			if l == maxLength {
+				break
+			}
+		}
+	}
+	if length < matchMinLength {
+		return 0, 0
+	}
+	return
+}